@@ -0,0 +1,102 @@
+// Command json2go prints the Go types that decode sample JSON documents.
+//
+//	json2go [-pkg name] [-type name] [-o file] [-progress] [sample.json...]
+//
+// Every file is one sample; without files stdin is the only sample. An
+// interrupt stops the parsing of large samples.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/json"
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/json2go"
+	"github.com/GabiBizdoc/golang-playground/pkg/progressbar"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("json2go", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: json2go [flags] [sample.json...]")
+		flags.PrintDefaults()
+	}
+	g := &json2go.Generator{}
+	flags.StringVar(&g.Package, "pkg", "main", "package clause of the output")
+	flags.StringVar(&g.Name, "type", "Root", "name of the root type")
+	output := flags.String("o", "", "write to this file instead of stdout")
+	progress := flags.Bool("progress", false, "show the parsing progress of every sample on stderr")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	samples := flags.Args()
+	if len(samples) == 0 {
+		samples = []string{"-"}
+	}
+	for _, name := range samples {
+		var bar *progressbar.ProgressBar
+		if *progress {
+			bar = progressbar.NewProgressBar(1)
+			bar.Writer, bar.Label = stderr, name
+		}
+		document, err := parse(ctx, name, stdin, bar)
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			fmt.Fprintf(stderr, "json2go: %s: %s", name, syntaxErr.Pretty())
+			return 1
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "json2go: %s: %v\n", name, err)
+			return 1
+		}
+		g.Add(document)
+	}
+
+	src, err := g.Generate()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if *output != "" {
+		err = os.WriteFile(*output, src, 0o644)
+	} else {
+		_, err = stdout.Write(src)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "json2go: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// parse reads one sample, "-" is stdin, and reports to bar when not nil.
+func parse(ctx context.Context, name string, stdin io.Reader, bar *progressbar.ProgressBar) (any, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bar == nil {
+		return json.ParseContext(ctx, string(data), nil)
+	}
+	return json.ParseContext(ctx, string(data), bar.Set)
+}
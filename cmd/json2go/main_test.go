@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.json"), filepath.Join(dir, "second.json")
+	if err := os.WriteFile(first, []byte(`{"id": 1, "name": "a"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte(`{"id": 2}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-pkg", "api", "-type", "User", first, second}, nil, &stdout, &stderr)
+	expected := "package api\n\ntype User struct {\n\tID   int64  `json:\"id\"`\n\tName string `json:\"name,omitempty\"`\n}\n"
+	if code != 0 || stdout.String() != expected {
+		t.Errorf("FAIL: expected %q but got %q %q", expected, stdout.String(), stderr.String())
+	}
+
+	output := filepath.Join(dir, "user.go")
+	stdout.Reset()
+	code = run([]string{"-o", output}, strings.NewReader(`[1, 2]`), &stdout, &stderr)
+	src, err := os.ReadFile(output)
+	if code != 0 || err != nil || string(src) != "package main\n\ntype Root []int64\n" || stdout.Len() != 0 {
+		t.Errorf("FAIL: got %q %v %q", src, err, stderr.String())
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	cases := []struct {
+		args  []string
+		stdin string
+		err   string
+	}{
+		{[]string{"-x"}, ``, "flag provided but not defined"},
+		{nil, `{"a": }`, "json2go: -: syntax error at line 1, column 7"},
+		{nil, `{"a": }`, "^ a value is missing after ':'"},
+		{[]string{"/does/not/exist"}, ``, "no such file"},
+		{[]string{"-type", "a b"}, `1`, "invalid package or type name"},
+	}
+	for _, c := range cases {
+		var stdout, stderr bytes.Buffer
+		code := run(c.args, strings.NewReader(c.stdin), &stdout, &stderr)
+		if code == 0 || !strings.Contains(stderr.String(), c.err) {
+			t.Errorf("FAIL: %v: expected %q but got %d %q", c.args, c.err, code, stderr.String())
+		}
+	}
+}
+
+func TestRun_Progress(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-progress"}, strings.NewReader(`{"a": [1, 2, 3]}`), &stdout, &stderr)
+	if code != 0 || !strings.Contains(stderr.String(), "-: 100%\t DONE!") {
+		t.Errorf("FAIL: got %d %q", code, stderr.String())
+	}
+}
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/jsonexplorer"
+)
+
+func identity(input any) ([]any, error) {
+	return []any{input}, nil
+}
+
+func constant(value any) filter {
+	return func(any) ([]any, error) {
+		return []any{value}, nil
+	}
+}
+
+// then feeds every output of first to second.
+func then(first, second filter) filter {
+	return func(input any) ([]any, error) {
+		values, err := first(input)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]any, 0, len(values))
+		for _, value := range values {
+			results, err := second(value)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, results...)
+		}
+		return out, nil
+	}
+}
+
+// optional drops the error of f, and its outputs, like jq's ? operator.
+func optional(f filter) filter {
+	return func(input any) ([]any, error) {
+		values, err := f(input)
+		if err != nil {
+			return []any{}, nil
+		}
+		return values, nil
+	}
+}
+
+// collect gathers the outputs of f into an array.
+func collect(f filter) filter {
+	return func(input any) ([]any, error) {
+		values, err := f(input)
+		if err != nil {
+			return nil, err
+		}
+		return []any{values}, nil
+	}
+}
+
+func indexBy(key any) filter {
+	return func(input any) ([]any, error) {
+		value, err := index(input, key)
+		if err != nil {
+			return nil, err
+		}
+		return []any{value}, nil
+	}
+}
+
+// index looks a key up through JSONExplorer. Missing keys, out of range
+// indices and null inputs give null.
+func index(input any, key any) (any, error) {
+	if input == nil {
+		return nil, nil
+	}
+	explorer := jsonexplorer.NewJSONExplorer(input)
+	switch key := key.(type) {
+	case string:
+		explorer = explorer.Field(key)
+	case float64:
+		i := int(math.Floor(key))
+		if array, ok := input.([]any); ok && i < 0 {
+			if i += len(array); i < 0 {
+				return nil, nil
+			}
+		}
+		explorer = explorer.At(i)
+	default:
+		return nil, fmt.Errorf("cannot index %s with %s", typeName(input), describe(key))
+	}
+	value, err := explorer.Value()
+	switch {
+	case errors.Is(err, jsonexplorer.ErrNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("cannot index %s with %s", typeName(input), describe(key))
+	}
+	return value, nil
+}
+
+func iterate(input any) ([]any, error) {
+	switch value := input.(type) {
+	case []any:
+		return value, nil
+	case map[string]any:
+		out := make([]any, 0, len(value))
+		for _, key := range sortedKeys(value) {
+			out = append(out, value[key])
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("cannot iterate over %s", describe(input))
+}
+
+// recurse outputs the input and every value nested in it.
+func recurse(input any) ([]any, error) {
+	out := []any{input}
+	children, err := iterate(input)
+	if err != nil {
+		return out, nil
+	}
+	for _, child := range children {
+		values, _ := recurse(child)
+		out = append(out, values...)
+	}
+	return out, nil
+}
+
+// slice implements .[from:to] on arrays and strings. Strings are sliced by
+// code points.
+func slice(input any, from, to any) (any, error) {
+	length := 0
+	switch value := input.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		length = len(value)
+	case string:
+		length = utf8.RuneCountInString(value)
+	default:
+		return nil, fmt.Errorf("cannot slice %s", typeName(input))
+	}
+	bound := func(b any, fallback int) (int, error) {
+		if b == nil {
+			return fallback, nil
+		}
+		n, ok := b.(float64)
+		if !ok {
+			return 0, fmt.Errorf("slice indices must be numbers, got %s", typeName(b))
+		}
+		i := int(math.Floor(n))
+		if i < 0 {
+			i += length
+		}
+		return min(max(i, 0), length), nil
+	}
+	start, err := bound(from, 0)
+	if err != nil {
+		return nil, err
+	}
+	end, err := bound(to, length)
+	if err != nil {
+		return nil, err
+	}
+	end = max(start, end)
+	if s, ok := input.(string); ok {
+		runes := []rune(s)
+		return string(runes[start:end]), nil
+	}
+	return input.([]any)[start:end], nil
+}
+
+func truthy(value any) bool {
+	return value != nil && value != false
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// describe names a value in error messages, like jq does.
+func describe(value any) string {
+	out, err := marshal(value)
+	if err != nil {
+		return typeName(value)
+	}
+	if len(out) > 11 {
+		out = out[:10] + "..."
+	}
+	return fmt.Sprintf("%s (%s)", typeName(value), out)
+}
+
+func sortedKeys(obj map[string]any) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// order ranks the types: null < false < true < numbers < strings < arrays <
+// objects.
+func order(value any) int {
+	switch value := value.(type) {
+	case nil:
+		return 0
+	case bool:
+		if value {
+			return 2
+		}
+		return 1
+	case float64:
+		return 3
+	case string:
+		return 4
+	case []any:
+		return 5
+	}
+	return 6
+}
+
+// compare orders any two values the way jq sorts them.
+func compare(a, b any) int {
+	if oa, ob := order(a), order(b); oa != ob {
+		return oa - ob
+	}
+	switch a := a.(type) {
+	case float64:
+		b := b.(float64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	case []any:
+		b := b.([]any)
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if c := compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+		return len(a) - len(b)
+	case map[string]any:
+		b := b.(map[string]any)
+		ka, kb := sortedKeys(a), sortedKeys(b)
+		if c := compare(stringsToAny(ka), stringsToAny(kb)); c != 0 {
+			return c
+		}
+		for _, key := range ka {
+			if c := compare(a[key], b[key]); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+func stringsToAny(s []string) []any {
+	out := make([]any, len(s))
+	for i, v := range s {
+		out[i] = v
+	}
+	return out
+}
+
+var operators = map[string]func(a, b any) (any, error){
+	"==": func(a, b any) (any, error) { return compare(a, b) == 0, nil },
+	"!=": func(a, b any) (any, error) { return compare(a, b) != 0, nil },
+	"<":  func(a, b any) (any, error) { return compare(a, b) < 0, nil },
+	"<=": func(a, b any) (any, error) { return compare(a, b) <= 0, nil },
+	">":  func(a, b any) (any, error) { return compare(a, b) > 0, nil },
+	">=": func(a, b any) (any, error) { return compare(a, b) >= 0, nil },
+	"+":  add,
+	"-": func(a, b any) (any, error) {
+		switch x := a.(type) {
+		case float64:
+			if y, ok := b.(float64); ok {
+				return x - y, nil
+			}
+		case []any:
+			if y, ok := b.([]any); ok {
+				out := make([]any, 0, len(x))
+				for _, item := range x {
+					if !slices.ContainsFunc(y, func(other any) bool { return compare(item, other) == 0 }) {
+						out = append(out, item)
+					}
+				}
+				return out, nil
+			}
+		}
+		return nil, fmt.Errorf("%s and %s cannot be subtracted", describe(a), describe(b))
+	},
+	"*": func(a, b any) (any, error) {
+		if x, ok := a.(float64); ok {
+			if y, ok := b.(float64); ok {
+				return x * y, nil
+			}
+		}
+		return nil, fmt.Errorf("%s and %s cannot be multiplied", describe(a), describe(b))
+	},
+	"/": func(a, b any) (any, error) {
+		if x, ok := a.(float64); ok {
+			if y, ok := b.(float64); ok {
+				if y == 0 {
+					return nil, fmt.Errorf("%s and %s cannot be divided because the divisor is zero", describe(a), describe(b))
+				}
+				return x / y, nil
+			}
+		}
+		if x, ok := a.(string); ok {
+			if y, ok := b.(string); ok {
+				return stringsToAny(strings.Split(x, y)), nil
+			}
+		}
+		return nil, fmt.Errorf("%s and %s cannot be divided", describe(a), describe(b))
+	},
+	"%": func(a, b any) (any, error) {
+		if x, ok := a.(float64); ok {
+			if y, ok := b.(float64); ok {
+				if int64(y) == 0 {
+					return nil, fmt.Errorf("%s and %s cannot be divided because the divisor is zero", describe(a), describe(b))
+				}
+				return float64(int64(x) % int64(y)), nil
+			}
+		}
+		return nil, fmt.Errorf("%s and %s cannot be divided", describe(a), describe(b))
+	},
+}
+
+// add adds numbers, concatenates strings and arrays and merges objects. null
+// is the identity.
+func add(a, b any) (any, error) {
+	if a == nil {
+		return b, nil
+	}
+	if b == nil {
+		return a, nil
+	}
+	switch x := a.(type) {
+	case float64:
+		if y, ok := b.(float64); ok {
+			return x + y, nil
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return x + y, nil
+		}
+	case []any:
+		if y, ok := b.([]any); ok {
+			return append(x[:len(x):len(x)], y...), nil
+		}
+	case map[string]any:
+		if y, ok := b.(map[string]any); ok {
+			out := make(map[string]any, len(x)+len(y))
+			for k, v := range x {
+				out[k] = v
+			}
+			for k, v := range y {
+				out[k] = v
+			}
+			return out, nil
+		}
+	}
+	return nil, fmt.Errorf("%s and %s cannot be added", describe(a), describe(b))
+}
+
+// builtins are keyed by name/arity.
+var builtins = map[string]func(args []filter) filter{
+	"empty/0": func([]filter) filter {
+		return func(any) ([]any, error) { return []any{}, nil }
+	},
+	"not/0": func([]filter) filter {
+		return func(input any) ([]any, error) { return []any{!truthy(input)}, nil }
+	},
+	"length/0": func([]filter) filter {
+		return func(input any) ([]any, error) {
+			switch value := input.(type) {
+			case nil:
+				return []any{0.0}, nil
+			case float64:
+				return []any{math.Abs(value)}, nil
+			case string:
+				return []any{float64(utf8.RuneCountInString(value))}, nil
+			case []any:
+				return []any{float64(len(value))}, nil
+			case map[string]any:
+				return []any{float64(len(value))}, nil
+			}
+			return nil, fmt.Errorf("%s has no length", describe(input))
+		}
+	},
+	"keys/0": func([]filter) filter {
+		return func(input any) ([]any, error) {
+			switch value := input.(type) {
+			case map[string]any:
+				return []any{stringsToAny(sortedKeys(value))}, nil
+			case []any:
+				keys := make([]any, len(value))
+				for i := range value {
+					keys[i] = float64(i)
+				}
+				return []any{keys}, nil
+			}
+			return nil, fmt.Errorf("%s has no keys", describe(input))
+		}
+	},
+	"has/1": func(args []filter) filter {
+		return func(input any) ([]any, error) {
+			keys, err := args[0](input)
+			if err != nil {
+				return nil, err
+			}
+			out := make([]any, 0, len(keys))
+			for _, key := range keys {
+				switch value := input.(type) {
+				case map[string]any:
+					if name, ok := key.(string); ok {
+						_, found := value[name]
+						out = append(out, found)
+						continue
+					}
+				case []any:
+					if n, ok := key.(float64); ok {
+						out = append(out, n >= 0 && int(n) < len(value))
+						continue
+					}
+				}
+				return nil, fmt.Errorf("cannot check whether %s has a %s key", typeName(input), typeName(key))
+			}
+			return out, nil
+		}
+	},
+	"type/0": func([]filter) filter {
+		return func(input any) ([]any, error) { return []any{typeName(input)}, nil }
+	},
+	"select/1": func(args []filter) filter {
+		return func(input any) ([]any, error) {
+			conditions, err := args[0](input)
+			if err != nil {
+				return nil, err
+			}
+			out := make([]any, 0, 1)
+			for _, condition := range conditions {
+				if truthy(condition) {
+					out = append(out, input)
+				}
+			}
+			return out, nil
+		}
+	},
+	"map/1": func(args []filter) filter {
+		return collect(then(iterate, args[0]))
+	},
+	"add/0": func([]filter) filter {
+		return func(input any) ([]any, error) {
+			values, err := iterate(input)
+			if err != nil {
+				return nil, err
+			}
+			var sum any
+			for _, value := range values {
+				if sum, err = add(sum, value); err != nil {
+					return nil, err
+				}
+			}
+			return []any{sum}, nil
+		}
+	},
+}
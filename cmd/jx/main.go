@@ -0,0 +1,237 @@
+// Command jx evaluates jq style queries over JSON and NDJSON input.
+//
+//	jx [-r] [-c] [-C | -M] query [file...]
+//	jx stats [-top n] [-json] [file...]
+//
+// Without files jx reads stdin. Every value of the input, one after the other
+// like in NDJSON, goes through the query and each output is printed on its own
+// line. The stats subcommand reports what the input is made of instead.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/json"
+)
+
+const (
+	exitUsage   = 2
+	exitCompile = 3
+	exitRuntime = 5
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 0 && args[0] == "stats" {
+		return runStats(args[1:], stdin, stdout, stderr)
+	}
+	flags := flag.NewFlagSet("jx", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: jx [flags] query [file...]\n       jx stats [-top n] [-json] [file...]")
+		flags.PrintDefaults()
+	}
+	raw := flags.Bool("r", false, "write strings without quotes")
+	compact := flags.Bool("c", false, "write every output on a single line")
+	color := flags.Bool("C", false, "always color the output")
+	monochrome := flags.Bool("M", false, "never color the output")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	query, err := Compile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "jx: compile error: %v\n", err)
+		return exitCompile
+	}
+
+	printer := json.NewPrinter(stdout)
+	switch {
+	case *monochrome:
+		printer.Color = json.ColorNever
+	case *color:
+		printer.Color = json.ColorAlways
+	}
+
+	output := func(value any) error {
+		if s, ok := value.(string); ok && *raw {
+			_, err := fmt.Fprintln(stdout, s)
+			return err
+		}
+		out, err := marshal(value)
+		if err != nil {
+			return err
+		}
+		if *compact {
+			_, err := fmt.Fprintln(stdout, out)
+			return err
+		}
+		return printer.Print(out)
+	}
+	eval := func(input any) error {
+		values, err := query(input)
+		if err != nil {
+			return err
+		}
+		for _, value := range values {
+			if err := output(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	inputs := flags.Args()[1:]
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
+	}
+	for _, name := range inputs {
+		if err := process(name, stdin, eval); err != nil {
+			var runtime *runtimeError
+			if errors.As(err, &runtime) {
+				fmt.Fprintf(stderr, "jx: %v\n", err)
+				return exitRuntime
+			}
+			printInputError(stderr, name, err)
+			return exitUsage
+		}
+	}
+	return 0
+}
+
+// runtimeError marks the errors raised by the query, as opposed to the ones
+// coming from the input.
+type runtimeError struct {
+	err error
+}
+
+func (e *runtimeError) Error() string { return e.err.Error() }
+func (e *runtimeError) Unwrap() error { return e.err }
+
+// process streams the values of one input through eval. "-" is stdin.
+func process(name string, stdin io.Reader, eval func(any) error) error {
+	r := stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	splitter := newSplitter(func(value string) error {
+		input, err := json.ParseJson(value)
+		if err != nil {
+			return err
+		}
+		if err := eval(input); err != nil {
+			return &runtimeError{err}
+		}
+		return nil
+	})
+	if _, err := io.Copy(splitter, r); err != nil {
+		return inputError(name, err)
+	}
+	return inputError(name, splitter.Close())
+}
+
+// splitter cuts a stream into its top-level values, checked on the fly by a
+// PushParser, and hands the text of each one to onValue. Only the value being
+// read is kept in memory.
+type splitter struct {
+	parser  *json.PushParser
+	onValue func(string) error
+	// pending holds the input from offset base on, and the values ending
+	// before offset done are dropped from it after every chunk.
+	pending []byte
+	base    int64
+	done    int64
+	depth   int
+	start   int64
+}
+
+func newSplitter(onValue func(string) error) *splitter {
+	s := &splitter{onValue: onValue}
+	s.parser = &json.PushParser{OnEvent: s.event}
+	return s
+}
+
+func (s *splitter) Write(chunk []byte) (int, error) {
+	s.pending = append(s.pending, chunk...)
+	n, err := s.parser.Write(chunk)
+	s.pending = s.pending[:copy(s.pending, s.pending[s.done-s.base:])]
+	s.base = s.done
+	return n, err
+}
+
+func (s *splitter) Close() error {
+	return s.parser.Close()
+}
+
+func (s *splitter) event(e json.Event) error {
+	switch e.Kind {
+	case json.TokenKindBraceOpen, json.TokenKindBracketOpen:
+		if s.depth == 0 {
+			s.start = e.Offset
+		}
+		s.depth++
+	case json.TokenKindBraceClose, json.TokenKindBracketClose:
+		s.depth--
+		if s.depth == 0 {
+			return s.value(e.Offset + 1)
+		}
+	default:
+		if s.depth == 0 {
+			s.start = e.Offset
+			return s.value(e.Offset + int64(len(e.Value)))
+		}
+	}
+	return nil
+}
+
+func (s *splitter) value(end int64) error {
+	s.done = end
+	return s.onValue(string(s.pending[s.start-s.base : end-s.base]))
+}
+
+func inputError(name string, err error) error {
+	var runtime *runtimeError
+	if err == nil || errors.As(err, &runtime) {
+		return err
+	}
+	return fmt.Errorf("%s: %w", inputName(name), err)
+}
+
+// printInputError writes err, returned by inputError, with the lines around
+// the offending token for syntax errors.
+func printInputError(w io.Writer, name string, err error) {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		fmt.Fprintf(w, "jx: %s: %s", inputName(name), syntaxErr.Pretty())
+		return
+	}
+	fmt.Fprintf(w, "jx: %v\n", err)
+}
+
+func inputName(name string) string {
+	if name == "-" {
+		return "stdin"
+	}
+	return name
+}
+
+func marshal(value any) (string, error) {
+	out, err := json.Marshal(value)
+	return string(out), err
+}
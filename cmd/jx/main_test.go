@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const logs = `{"level": "info", "msg": "started", "ms": 12, "tags": ["a", "b"]}
+{"level": "error", "msg": "failed", "ms": 340, "tags": [], "user": {"id": 7, "name": "ana"}}
+{"level": "info", "msg": "done", "ms": 45.5, "tags": ["b"]}
+`
+
+func jx(t *testing.T, stdin string, args ...string) (string, string, int) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func TestRun_Queries(t *testing.T) {
+	cases := []struct {
+		query    string
+		expected string
+	}{
+		{`.`, `{"level":"info","ms":12,"msg":"started","tags":["a","b"]}`},
+		{`.msg`, `"started"`},
+		{`.user.name`, `null`},
+		{`."level"`, `"info"`},
+		{`.tags[0]`, `"a"`},
+		{`.tags[-1]`, `"b"`},
+		{`.tags[5]`, `null`},
+		{`.tags[]`, "\"a\"\n\"b\""},
+		{`.tags[1:]`, `["b"]`},
+		{`.msg[1:4]`, `"tar"`},
+		{`.tags | length`, `2`},
+		{`.msg | length`, `7`},
+		{`keys`, `["level","ms","msg","tags"]`},
+		{`.tags | keys`, `[0,1]`},
+		{`.[]`, "\"info\"\n12\n\"started\"\n[\"a\",\"b\"]"},
+		{`.msg, .ms`, "\"started\"\n12"},
+		{`{msg, slow: (.ms > 100)}`, `{"msg":"started","slow":false}`},
+		{`{"m": .msg, (.level): .ms}`, `{"info":12,"m":"started"}`},
+		{`{tag: .tags[]}`, "{\"tag\":\"a\"}\n{\"tag\":\"b\"}"},
+		{`[.tags[], .msg]`, `["a","b","started"]`},
+		{`.tags | map(. + "!")`, `["a!","b!"]`},
+		{`select(.ms < 20) | .msg`, `"started"`},
+		{`select(.ms > 20)`, ``},
+		{`.ms * 2 + 1 - 5 / 5`, `24`},
+		{`.ms % 5`, `2`},
+		{`-.ms`, `-12`},
+		{`.tags + ["c"] | add`, `"abc"`},
+		{`{a: 1} + {b: 2}`, `{"a":1,"b":2}`},
+		{`null + 1`, `1`},
+		{`.level == "info" and (.tags | has(1))`, `true`},
+		{`.missing or false, (true | not)`, "false\nfalse"},
+		{`[.ms, .msg, null, true, false, [], {}] | map(type)`, `["number","string","null","boolean","boolean","array","object"]`},
+		{`.msg[0]?`, ``},
+		{`[..] | length`, `7`},
+		{`.tags | .[1]`, `"b"`},
+		{`.tags.[0]`, `"a"`},
+		{`empty, 1`, `1`},
+	}
+	first := strings.SplitN(logs, "\n", 2)[0]
+	for _, c := range cases {
+		out, errOut, code := jx(t, first, "-c", "--", c.query)
+		if code != 0 {
+			t.Errorf("FAIL: %s: exit code %d: %s", c.query, code, errOut)
+			continue
+		}
+		if out = strings.TrimSuffix(out, "\n"); out != c.expected {
+			t.Errorf("FAIL: %s: expected %s but got %s", c.query, c.expected, out)
+		}
+	}
+}
+
+func TestRun_NDJSON(t *testing.T) {
+	out, errOut, code := jx(t, logs, "-r", `select(.level == "info") | .msg`)
+	if code != 0 || out != "started\ndone\n" {
+		t.Errorf("FAIL: got %q %q %d", out, errOut, code)
+	}
+
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.json"), filepath.Join(dir, "second.ndjson")
+	if err := os.WriteFile(first, []byte(`{"ms": 1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte(logs), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out, errOut, code = jx(t, "", "-c", ".ms", first, second)
+	if code != 0 || out != "1\n12\n340\n45.5\n" {
+		t.Errorf("FAIL: got %q %q %d", out, errOut, code)
+	}
+}
+
+func TestSplitter(t *testing.T) {
+	const input = "12 \"a b\"\n{\"a\": [1, {}]}\t[true, null]\nfalse -3.5"
+	expected := []string{`12`, `"a b"`, `{"a": [1, {}]}`, `[true, null]`, `false`, `-3.5`}
+	for _, size := range []int{1, 2, 5, 64} {
+		var values []string
+		s := newSplitter(func(value string) error {
+			values = append(values, value)
+			return nil
+		})
+		for i := 0; i < len(input); i += size {
+			if _, err := s.Write([]byte(input[i:min(i+size, len(input))])); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(values, "|") != strings.Join(expected, "|") {
+			t.Errorf("FAIL: chunks of %d: expected %q but got %q", size, expected, values)
+		}
+		if len(s.pending) > 5 {
+			t.Errorf("FAIL: chunks of %d: %q left pending", size, s.pending)
+		}
+	}
+}
+
+func TestRun_Pretty(t *testing.T) {
+	out, _, code := jx(t, `{"a": [1, {}], "b": "x"}`, "-M", ".")
+	expected := "{\n  \"a\": [\n    1,\n    {}\n  ],\n  \"b\": \"x\"\n}\n"
+	if code != 0 || out != expected {
+		t.Errorf("FAIL: expected %q but got %q", expected, out)
+	}
+
+	out, _, _ = jx(t, `"x"`, "-C", ".")
+	if !strings.Contains(out, "\x1b[") {
+		t.Errorf("FAIL: expected colors but got %q", out)
+	}
+	out, _, _ = jx(t, `"x"`, ".")
+	if out != "\"x\"\n" {
+		t.Errorf("FAIL: expected no colors when not writing to a terminal but got %q", out)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	cases := []struct {
+		args  []string
+		stdin string
+		code  int
+		err   string
+	}{
+		{nil, ``, exitUsage, "usage"},
+		{[]string{"-x", "."}, ``, exitUsage, "flag provided but not defined"},
+		{[]string{".a |"}, ``, exitCompile, "unexpected end of query"},
+		{[]string{".a ]"}, ``, exitCompile, `unexpected "]" at position 3`},
+		{[]string{"nope"}, ``, exitCompile, "nope/0 is not defined"},
+		{[]string{`"abc`}, ``, exitCompile, "unterminated string"},
+		{[]string{"{a: 1"}, ``, exitCompile, "unexpected end of query"},
+		{[]string{".a"}, `[1]`, exitRuntime, "cannot index array with string"},
+		{[]string{".[]"}, `1`, exitRuntime, "cannot iterate over number (1)"},
+		{[]string{"keys"}, `true`, exitRuntime, "boolean (true) has no keys"},
+		{[]string{"{(.a): 1}"}, `{"a": 2}`, exitRuntime, "object keys must be strings"},
+		{[]string{".a + 1"}, `{"a": "x"}`, exitRuntime, "cannot be added"},
+		{[]string{"1 / 0"}, `1`, exitRuntime, "divisor is zero"},
+		{[]string{"."}, `{"a": }`, exitUsage, "stdin: "},
+		{[]string{"."}, "{\"a\":\n 1,\n}", exitUsage, "stdin: syntax error at line 3, column 1"},
+		{[]string{"."}, "{\"a\":\n 1,\n}", exitUsage, "3 | }\n  | ^ trailing comma before '}'\n"},
+		{[]string{"stats"}, "[1 2]", exitUsage, "1 | [1 2]\n  |    ^ missing comma before this value\n"},
+		{[]string{".", "/does/not/exist"}, ``, exitUsage, "no such file"},
+	}
+	for _, c := range cases {
+		_, errOut, code := jx(t, c.stdin, c.args...)
+		if code != c.code || !strings.Contains(errOut, c.err) {
+			t.Errorf("FAIL: %v: expected %d %q but got %d %q", c.args, c.code, c.err, code, errOut)
+		}
+	}
+}
+
+func TestRun_Stats(t *testing.T) {
+	out, errOut, code := jx(t, `{"a": [1, "xy", null], "b": {"a": true}}`, "stats", "-top", "2")
+	expected := `size       40 B
+values     1
+max depth  2
+
+kinds
+  objects   2
+  arrays    1
+  strings   1
+  numbers   1
+  booleans  1
+  nulls     1
+
+keys
+  "a"  2
+  "b"  1
+
+largest values
+  (root)  40 B
+  /a      15 B
+
+string lengths
+  0    0
+  1    0
+  2-3  1
+`
+	if code != 0 || out != expected {
+		t.Errorf("FAIL: expected\n%s\nbut got\n%s%s", expected, out, errOut)
+	}
+
+	out, _, code = jx(t, "1\n[2]\n", "stats", "-json")
+	if code != 0 || !strings.Contains(out, `"Values":2,"MaxDepth":1`) || !strings.Contains(out, `{"Value":1,"Pointer":"","Bytes":3}`) {
+		t.Errorf("FAIL: got %s", out)
+	}
+
+	_, errOut, code = jx(t, `[1,`, "stats")
+	if code != exitUsage || !strings.Contains(errOut, "stdin: ") {
+		t.Errorf("FAIL: got %d %s", code, errOut)
+	}
+}
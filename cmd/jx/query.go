@@ -0,0 +1,598 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/json"
+)
+
+// filter is a compiled query. Like in jq, a filter produces any number of
+// outputs for one input.
+type filter func(input any) ([]any, error)
+
+type tokenKind int8
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenField // .name or ."name"
+	tokenString
+	tokenNumber
+	tokenPunct
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value any
+	pos   int
+}
+
+// lexQuery splits a query into tokens.
+func lexQuery(query string) ([]token, error) {
+	tokens := make([]token, 0)
+	for i := 0; i < len(query); {
+		c := query[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '"':
+			end, err := stringEnd(query, i)
+			if err != nil {
+				return nil, err
+			}
+			value, err := json.ParseJson(query[i:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at position %d: %w", i, err)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: query[i:end], value: value, pos: start})
+			i = end
+		case c == '.' && i+1 < len(query) && query[i+1] == '"':
+			end, err := stringEnd(query, i+1)
+			if err != nil {
+				return nil, err
+			}
+			value, err := json.ParseJson(query[i+1 : end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at position %d: %w", i+1, err)
+			}
+			tokens = append(tokens, token{kind: tokenField, text: query[i:end], value: value, pos: start})
+			i = end
+		case c == '.' && i+1 < len(query) && isIdentStart(query[i+1]):
+			i++
+			for i < len(query) && isIdentPart(query[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenField, text: query[start:i], value: query[start+1 : i], pos: start})
+		case isDigit(c):
+			for i < len(query) && (isDigit(query[i]) || query[i] == '.' || query[i] == 'e' || query[i] == 'E' ||
+				(query[i] == '-' || query[i] == '+') && (query[i-1] == 'e' || query[i-1] == 'E')) {
+				i++
+			}
+			n, err := strconv.ParseFloat(query[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", query[start:i], start)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: query[start:i], value: n, pos: start})
+		case isIdentStart(c):
+			for i < len(query) && isIdentPart(query[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: query[start:i], pos: start})
+		default:
+			punct := ""
+			for _, p := range []string{"..", "==", "!=", "<=", ">=", ".", "|", ",", "(", ")", "[", "]", "{", "}", ":", ";", "?", "<", ">", "+", "-", "*", "/", "%"} {
+				if strings.HasPrefix(query[i:], p) {
+					punct = p
+					break
+				}
+			}
+			if punct == "" {
+				return nil, fmt.Errorf("unexpected %q at position %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokenPunct, text: punct, pos: start})
+			i += len(punct)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(query)}), nil
+}
+
+func stringEnd(query string, start int) (int, error) {
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated string at position %d", start)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+// queryParser is a recursive descent parser. From the lowest to the highest
+// precedence: |, ",", or, and, comparisons, + -, * / %, postfix paths.
+type queryParser struct {
+	tokens []token
+	pos    int
+}
+
+// Compile parses a query written in the jq subset supported by jx.
+func Compile(query string) (filter, error) {
+	tokens, err := lexQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens}
+	f, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, p.unexpected()
+	}
+	return f, nil
+}
+
+func (p *queryParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// back undoes next, which does not move past the end.
+func (p *queryParser) back(t token) {
+	if t.kind != tokenEOF {
+		p.pos--
+	}
+}
+
+func (p *queryParser) accept(text string) bool {
+	if t := p.peek(); (t.kind == tokenPunct || t.kind == tokenIdent) && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) expect(text string) error {
+	if !p.accept(text) {
+		return p.unexpected()
+	}
+	return nil
+}
+
+func (p *queryParser) unexpected() error {
+	t := p.peek()
+	if t.kind == tokenEOF {
+		return fmt.Errorf("unexpected end of query")
+	}
+	return fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+}
+
+func (p *queryParser) pipe() (filter, error) {
+	left, err := p.comma()
+	if err != nil || !p.accept("|") {
+		return left, err
+	}
+	right, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+	return then(left, right), nil
+}
+
+func (p *queryParser) comma() (filter, error) {
+	left, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(",") {
+		right, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		first := left
+		left = func(input any) ([]any, error) {
+			a, err := first(input)
+			if err != nil {
+				return nil, err
+			}
+			b, err := right(input)
+			if err != nil {
+				return nil, err
+			}
+			return append(a, b...), nil
+		}
+	}
+	return left, nil
+}
+
+func (p *queryParser) or() (filter, error) {
+	return p.binary(p.and, "or")
+}
+
+func (p *queryParser) and() (filter, error) {
+	return p.binary(p.comparison, "and")
+}
+
+func (p *queryParser) comparison() (filter, error) {
+	left, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.accept(op) {
+			right, err := p.additive()
+			if err != nil {
+				return nil, err
+			}
+			return combine(left, right, operators[op]), nil
+		}
+	}
+	return left, nil
+}
+
+func (p *queryParser) additive() (filter, error) {
+	return p.binary(p.multiplicative, "+", "-")
+}
+
+func (p *queryParser) multiplicative() (filter, error) {
+	return p.binary(p.postfix, "*", "/", "%")
+}
+
+// binary parses left associative operators.
+func (p *queryParser) binary(operand func() (filter, error), ops ...string) (filter, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		matched := ""
+		for _, op := range ops {
+			if p.accept(op) {
+				matched = op
+				break
+			}
+		}
+		if matched == "" {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if matched == "and" || matched == "or" {
+			left = logical(left, right, matched == "and")
+		} else {
+			left = combine(left, right, operators[matched])
+		}
+	}
+}
+
+// combine applies op to every pair of outputs, iterating over the outputs of
+// right in the outer loop as jq does.
+func combine(left, right filter, op func(a, b any) (any, error)) filter {
+	return func(input any) ([]any, error) {
+		a, err := left(input)
+		if err != nil {
+			return nil, err
+		}
+		b, err := right(input)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]any, 0, len(a)*len(b))
+		for _, y := range b {
+			for _, x := range a {
+				value, err := op(x, y)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, value)
+			}
+		}
+		return out, nil
+	}
+}
+
+// logical implements and and or, which only evaluate right when needed.
+func logical(left, right filter, and bool) filter {
+	return func(input any) ([]any, error) {
+		a, err := left(input)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]any, 0, len(a))
+		for _, x := range a {
+			if truthy(x) != and {
+				out = append(out, !and)
+				continue
+			}
+			b, err := right(input)
+			if err != nil {
+				return nil, err
+			}
+			for _, y := range b {
+				out = append(out, truthy(y))
+			}
+		}
+		return out, nil
+	}
+}
+
+func (p *queryParser) postfix() (filter, error) {
+	f, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokenField:
+			p.next()
+			f = then(f, indexBy(t.value))
+		case t.kind == tokenPunct && t.text == "[":
+			p.next()
+			suffix, err := p.brackets()
+			if err != nil {
+				return nil, err
+			}
+			f = then(f, suffix)
+		case t.kind == tokenPunct && t.text == "." && p.tokens[p.pos+1].text == "[":
+			p.next()
+		case t.kind == tokenPunct && t.text == "?":
+			p.next()
+			f = optional(f)
+		default:
+			return f, nil
+		}
+	}
+}
+
+// brackets parses what follows [ in a path: [], [e] or [e:e].
+func (p *queryParser) brackets() (filter, error) {
+	if p.accept("]") {
+		return iterate, nil
+	}
+	var from, to filter
+	var err error
+	if !p.accept(":") {
+		if from, err = p.pipe(); err != nil {
+			return nil, err
+		}
+		if p.accept("]") {
+			return func(input any) ([]any, error) {
+				keys, err := from(input)
+				if err != nil {
+					return nil, err
+				}
+				out := make([]any, 0, len(keys))
+				for _, key := range keys {
+					value, err := index(input, key)
+					if err != nil {
+						return nil, err
+					}
+					out = append(out, value)
+				}
+				return out, nil
+			}, nil
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+	}
+	if !p.accept("]") {
+		if to, err = p.pipe(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	}
+	bound := func(f filter, input any) (any, error) {
+		if f == nil {
+			return nil, nil
+		}
+		values, err := f(input)
+		if err != nil || len(values) == 0 {
+			return nil, err
+		}
+		return values[0], nil
+	}
+	return func(input any) ([]any, error) {
+		start, err := bound(from, input)
+		if err != nil {
+			return nil, err
+		}
+		end, err := bound(to, input)
+		if err != nil {
+			return nil, err
+		}
+		value, err := slice(input, start, end)
+		if err != nil {
+			return nil, err
+		}
+		return []any{value}, nil
+	}, nil
+}
+
+func (p *queryParser) term() (filter, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber, tokenString:
+		return constant(t.value), nil
+	case tokenField:
+		return indexBy(t.value), nil
+	case tokenIdent:
+		switch t.text {
+		case "true", "false":
+			return constant(t.text == "true"), nil
+		case "null":
+			return constant(nil), nil
+		}
+		return p.call(t)
+	case tokenPunct:
+		switch t.text {
+		case ".":
+			return identity, nil
+		case "..":
+			return recurse, nil
+		case "-":
+			operand, err := p.postfix()
+			if err != nil {
+				return nil, err
+			}
+			return combine(constant(0.0), operand, operators["-"]), nil
+		case "(":
+			f, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			return f, p.expect(")")
+		case "[":
+			if p.accept("]") {
+				return constant([]any{}), nil
+			}
+			f, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			return collect(f), nil
+		case "{":
+			return p.object()
+		}
+	}
+	p.back(t)
+	return nil, p.unexpected()
+}
+
+// object parses an object construction such as {a, "b": .c, (.k): .v}.
+func (p *queryParser) object() (filter, error) {
+	type member struct {
+		key, value filter
+	}
+	members := make([]member, 0)
+	for !p.accept("}") {
+		if len(members) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		var m member
+		t := p.next()
+		switch {
+		case t.kind == tokenIdent || t.kind == tokenString:
+			key := t.text
+			if t.kind == tokenString {
+				key = t.value.(string)
+			}
+			m.key = constant(key)
+			m.value = indexBy(key)
+		case t.kind == tokenPunct && t.text == "(":
+			key, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			m.key = key
+		default:
+			p.back(t)
+			return nil, p.unexpected()
+		}
+		if p.accept(":") {
+			value, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			m.value = value
+		} else if m.value == nil {
+			return nil, p.unexpected()
+		}
+		members = append(members, m)
+	}
+
+	return func(input any) ([]any, error) {
+		objects := []any{map[string]any{}}
+		for _, m := range members {
+			keys, err := m.key(input)
+			if err != nil {
+				return nil, err
+			}
+			values, err := m.value(input)
+			if err != nil {
+				return nil, err
+			}
+			next := make([]any, 0, len(objects)*len(keys)*len(values))
+			for _, obj := range objects {
+				for _, key := range keys {
+					name, ok := key.(string)
+					if !ok {
+						return nil, fmt.Errorf("object keys must be strings, got %s", typeName(key))
+					}
+					for _, value := range values {
+						extended := make(map[string]any, len(obj.(map[string]any))+1)
+						for k, v := range obj.(map[string]any) {
+							extended[k] = v
+						}
+						extended[name] = value
+						next = append(next, extended)
+					}
+				}
+			}
+			objects = next
+		}
+		return objects, nil
+	}, nil
+}
+
+// call parses a builtin, with its arguments separated by ; when it takes any.
+func (p *queryParser) call(name token) (filter, error) {
+	args := make([]filter, 0)
+	if p.accept("(") {
+		for {
+			arg, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.accept(")") {
+				break
+			}
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	b, ok := builtins[fmt.Sprintf("%s/%d", name.text, len(args))]
+	if !ok {
+		return nil, fmt.Errorf("%s/%d is not defined at position %d", name.text, len(args), name.pos)
+	}
+	return b(args), nil
+}
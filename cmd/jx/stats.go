@@ -0,0 +1,140 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/json"
+)
+
+// runStats implements jx stats, which streams every input through the
+// analyzer and reports on it.
+func runStats(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("jx stats", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: jx stats [flags] [file...]")
+		flags.PrintDefaults()
+	}
+	n := flags.Int("top", 10, "number of keys and values listed")
+	asJSON := flags.Bool("json", false, "write the report as JSON")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	inputs := flags.Args()
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
+	}
+	for i, name := range inputs {
+		stats, err := analyze(name, stdin, *n)
+		if err != nil {
+			printInputError(stderr, name, inputError(name, err))
+			return exitUsage
+		}
+		if *asJSON {
+			out, err := json.Marshal(stats)
+			if err != nil {
+				fmt.Fprintf(stderr, "jx: %v\n", err)
+				return exitRuntime
+			}
+			fmt.Fprintf(stdout, "%s\n", out)
+			continue
+		}
+		if len(inputs) > 1 {
+			if i > 0 {
+				fmt.Fprintln(stdout)
+			}
+			fmt.Fprintf(stdout, "==> %s <==\n", name)
+		}
+		report(stdout, stats)
+	}
+	return 0
+}
+
+func analyze(name string, stdin io.Reader, n int) (*json.Stats, error) {
+	r := stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	a := json.NewAnalyzer()
+	a.TopKeys, a.TopSubtrees = n, n
+	if _, err := io.Copy(a, r); err != nil {
+		return nil, err
+	}
+	if err := a.Close(); err != nil {
+		return nil, err
+	}
+	return a.Stats(), nil
+}
+
+func report(w io.Writer, stats *json.Stats) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	defer tw.Flush()
+
+	fmt.Fprintf(tw, "size\t%s\n", size(stats.Bytes))
+	fmt.Fprintf(tw, "values\t%d\n", stats.Values)
+	fmt.Fprintf(tw, "max depth\t%d\n", stats.MaxDepth)
+
+	k := stats.Kinds
+	fmt.Fprintln(tw, "\nkinds")
+	fmt.Fprintf(tw, "  objects\t%d\n  arrays\t%d\n  strings\t%d\n", k.Objects, k.Arrays, k.Strings)
+	fmt.Fprintf(tw, "  numbers\t%d\n  booleans\t%d\n  nulls\t%d\n", k.Numbers, k.Booleans, k.Nulls)
+
+	if len(stats.Keys) > 0 {
+		fmt.Fprintln(tw, "\nkeys")
+		for _, key := range stats.Keys {
+			fmt.Fprintf(tw, "  %s\t%d\n", strconv.Quote(key.Key), key.Count)
+		}
+	}
+
+	if len(stats.Largest) > 0 {
+		fmt.Fprintln(tw, "\nlargest values")
+		for _, subtree := range stats.Largest {
+			path := subtree.Pointer
+			if path == "" {
+				path = "(root)"
+			}
+			if stats.Values > 1 {
+				path = fmt.Sprintf("#%d %s", subtree.Value, path)
+			}
+			fmt.Fprintf(tw, "  %s\t%s\n", path, size(subtree.Bytes))
+		}
+	}
+
+	if len(stats.StringLengths) > 0 {
+		fmt.Fprintln(tw, "\nstring lengths")
+		for _, bucket := range stats.StringLengths {
+			bounds := strconv.Itoa(bucket.Min)
+			if bucket.Max != bucket.Min {
+				bounds += "-" + strconv.Itoa(bucket.Max)
+			}
+			fmt.Fprintf(tw, "  %s\t%d\n", bounds, bucket.Count)
+		}
+	}
+}
+
+func size(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value, suffix := float64(bytes), ""
+	for _, s := range []string{"KiB", "MiB", "GiB", "TiB"} {
+		value /= unit
+		suffix = s
+		if value < unit {
+			break
+		}
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
@@ -0,0 +1,343 @@
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"time"
+)
+
+const (
+	majorUnsigned byte = iota
+	majorNegative
+	majorBytes
+	majorText
+	majorArray
+	majorMap
+	majorTag
+	majorSimple
+)
+
+const (
+	TagDateTimeString uint64 = 0
+	TagEpochDateTime  uint64 = 1
+	TagPositiveBignum uint64 = 2
+	TagNegativeBignum uint64 = 3
+	TagBase64URL      uint64 = 21
+	TagBase64         uint64 = 22
+	TagBase16         uint64 = 23
+)
+
+const (
+	infoIndefinite = 31
+	breakByte      = 0xff
+)
+
+// Tag is a tagged data item whose tag number has no specific Go type.
+type Tag struct {
+	Number  uint64
+	Content any
+}
+
+// Simple is a simple value other than false, true, null and undefined.
+type Simple uint8
+
+// Undefined is the CBOR undefined simple value.
+type Undefined struct{}
+
+// EncOptions controls the encoding. The zero value uses the preferred
+// serialization: the shortest argument encoding and the shortest float that
+// keeps the value.
+type EncOptions struct {
+	// Deterministic follows the core deterministic encoding of RFC 8949
+	// section 4.2.1, which also sorts the map keys by their encoded bytes.
+	Deterministic bool
+	// IndefiniteLength encodes arrays and maps with an indefinite length,
+	// closed by a break, so that they can be produced as a stream.
+	IndefiniteLength bool
+}
+
+// Marshal encodes v using the preferred serialization.
+func Marshal(v any) ([]byte, error) {
+	return EncOptions{}.Marshal(v)
+}
+
+// Marshal encodes nil, bool, integers, floats, string, []byte, []any,
+// map[string]any, map[any]any, time.Time, *big.Int, Tag, Simple and Undefined.
+func (o EncOptions) Marshal(v any) ([]byte, error) {
+	if o.Deterministic && o.IndefiniteLength {
+		return nil, errors.New("deterministic encoding requires definite lengths")
+	}
+	e := &encoder{opts: o}
+	if err := e.encode(v); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+type encoder struct {
+	buf  []byte
+	opts EncOptions
+}
+
+// head writes the initial byte and the shortest encoding of the argument.
+func (e *encoder) head(major byte, n uint64) {
+	major <<= 5
+	switch {
+	case n < 24:
+		e.buf = append(e.buf, major|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, major|26), uint32(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, major|27), n)
+	}
+}
+
+func (e *encoder) encode(v any) error {
+	switch value := v.(type) {
+	case nil:
+		e.buf = append(e.buf, 0xf6)
+	case Undefined:
+		e.buf = append(e.buf, 0xf7)
+	case bool:
+		if value {
+			e.buf = append(e.buf, 0xf5)
+		} else {
+			e.buf = append(e.buf, 0xf4)
+		}
+	case Simple:
+		if value >= 20 && value < 32 {
+			return fmt.Errorf("invalid simple value %d", value)
+		}
+		e.head(majorSimple, uint64(value))
+	case int:
+		e.int(int64(value))
+	case int8:
+		e.int(int64(value))
+	case int16:
+		e.int(int64(value))
+	case int32:
+		e.int(int64(value))
+	case int64:
+		e.int(value)
+	case uint:
+		e.head(majorUnsigned, uint64(value))
+	case uint8:
+		e.head(majorUnsigned, uint64(value))
+	case uint16:
+		e.head(majorUnsigned, uint64(value))
+	case uint32:
+		e.head(majorUnsigned, uint64(value))
+	case uint64:
+		e.head(majorUnsigned, value)
+	case float32:
+		e.float(float64(value))
+	case float64:
+		e.float(value)
+	case *big.Int:
+		e.bigInt(value)
+	case string:
+		e.head(majorText, uint64(len(value)))
+		e.buf = append(e.buf, value...)
+	case []byte:
+		e.head(majorBytes, uint64(len(value)))
+		e.buf = append(e.buf, value...)
+	case time.Time:
+		e.head(majorTag, TagEpochDateTime)
+		if value.Nanosecond() == 0 {
+			e.int(value.Unix())
+		} else {
+			e.float(float64(value.UnixNano()) / 1e9)
+		}
+	case Tag:
+		e.head(majorTag, value.Number)
+		return e.encode(value.Content)
+	case []any:
+		e.length(majorArray, len(value))
+		for _, item := range value {
+			if err := e.encode(item); err != nil {
+				return err
+			}
+		}
+		e.end()
+	case map[string]any:
+		keys := make([]any, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		return e.encodeMap(keys, func(k any) any { return value[k.(string)] })
+	case map[any]any:
+		keys := make([]any, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		return e.encodeMap(keys, func(k any) any { return value[k] })
+	default:
+		return fmt.Errorf("cbor: unsupported type %s", reflect.TypeOf(v))
+	}
+	return nil
+}
+
+func (e *encoder) length(major byte, n int) {
+	if e.opts.IndefiniteLength {
+		e.buf = append(e.buf, major<<5|infoIndefinite)
+	} else {
+		e.head(major, uint64(n))
+	}
+}
+
+func (e *encoder) end() {
+	if e.opts.IndefiniteLength {
+		e.buf = append(e.buf, breakByte)
+	}
+}
+
+func (e *encoder) encodeMap(keys []any, valueOf func(any) any) error {
+	e.length(majorMap, len(keys))
+	if !e.opts.Deterministic {
+		for _, k := range keys {
+			if err := e.encode(k); err != nil {
+				return err
+			}
+			if err := e.encode(valueOf(k)); err != nil {
+				return err
+			}
+		}
+		e.end()
+		return nil
+	}
+
+	type entry struct {
+		key     any
+		encoded []byte
+	}
+	entries := make([]entry, 0, len(keys))
+	for _, k := range keys {
+		encoded, err := e.opts.Marshal(k)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{key: k, encoded: encoded})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].encoded, entries[j].encoded) < 0
+	})
+	for _, entry := range entries {
+		e.buf = append(e.buf, entry.encoded...)
+		if err := e.encode(valueOf(entry.key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encoder) int(n int64) {
+	if n < 0 {
+		e.head(majorNegative, uint64(-1-n))
+	} else {
+		e.head(majorUnsigned, uint64(n))
+	}
+}
+
+// bigInt uses a plain integer when the value fits in 64 bits.
+func (e *encoder) bigInt(n *big.Int) {
+	if n.Sign() >= 0 {
+		if n.IsUint64() {
+			e.head(majorUnsigned, n.Uint64())
+			return
+		}
+		e.head(majorTag, TagPositiveBignum)
+		b := n.Bytes()
+		e.head(majorBytes, uint64(len(b)))
+		e.buf = append(e.buf, b...)
+		return
+	}
+	m := new(big.Int).Neg(n)
+	m.Sub(m, big.NewInt(1))
+	if m.IsUint64() {
+		e.head(majorNegative, m.Uint64())
+		return
+	}
+	e.head(majorTag, TagNegativeBignum)
+	b := m.Bytes()
+	e.head(majorBytes, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// float writes the shortest of half, single and double precision that
+// represents f exactly. NaN is always written as the half precision 0x7e00.
+func (e *encoder) float(f float64) {
+	if math.IsNaN(f) {
+		e.buf = append(e.buf, 0xf9, 0x7e, 0x00)
+		return
+	}
+	if half, ok := toFloat16(f); ok {
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xf9), half)
+		return
+	}
+	if float64(float32(f)) == f {
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xfa), math.Float32bits(float32(f)))
+		return
+	}
+	e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xfb), math.Float64bits(f))
+}
+
+// toFloat16 converts f to half precision when no precision is lost.
+func toFloat16(f float64) (uint16, bool) {
+	f32 := float32(f)
+	if float64(f32) != f {
+		return 0, false
+	}
+	bits := math.Float32bits(f32)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int(bits>>23&0xff) - 127
+	mant := bits & 0x7fffff
+
+	switch {
+	case bits&0x7fffffff == 0:
+		return sign, true
+	case exp == 128:
+		return sign | 0x7c00, true
+	case exp >= -14 && exp <= 15:
+		if mant&0x1fff != 0 {
+			return 0, false
+		}
+		return sign | uint16(exp+15)<<10 | uint16(mant>>13), true
+	case exp >= -24 && exp < -14:
+		shift := uint(13 + -14 - exp)
+		full := mant | 0x800000
+		if full&(1<<shift-1) != 0 {
+			return 0, false
+		}
+		return sign | uint16(full>>shift), true
+	}
+	return 0, false
+}
+
+func fromFloat16(half uint16) float64 {
+	sign := 1.0
+	if half&0x8000 != 0 {
+		sign = -1
+	}
+	exp := int(half >> 10 & 0x1f)
+	mant := float64(half & 0x3ff)
+	switch exp {
+	case 0:
+		return sign * math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant != 0 {
+			return math.NaN()
+		}
+		return sign * math.Inf(1)
+	}
+	return sign * math.Ldexp(mant+1024, exp-25)
+}
@@ -0,0 +1,236 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/json"
+)
+
+func bigInt(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+// Examples from RFC 8949 appendix A.
+var examples = []struct {
+	Value any
+	Hex   string
+}{
+	{int64(0), "00"},
+	{int64(1), "01"},
+	{int64(10), "0a"},
+	{int64(23), "17"},
+	{int64(24), "1818"},
+	{int64(25), "1819"},
+	{int64(100), "1864"},
+	{int64(1000), "1903e8"},
+	{int64(1000000), "1a000f4240"},
+	{int64(1000000000000), "1b000000e8d4a51000"},
+	{uint64(18446744073709551615), "1bffffffffffffffff"},
+	{bigInt("18446744073709551616"), "c249010000000000000000"},
+	{bigInt("-18446744073709551616"), "3bffffffffffffffff"},
+	{bigInt("-18446744073709551617"), "c349010000000000000000"},
+	{int64(-1), "20"},
+	{int64(-10), "29"},
+	{int64(-100), "3863"},
+	{int64(-1000), "3903e7"},
+	{0.0, "f90000"},
+	{math.Copysign(0, -1), "f98000"},
+	{1.0, "f93c00"},
+	{1.1, "fb3ff199999999999a"},
+	{1.5, "f93e00"},
+	{65504.0, "f97bff"},
+	{100000.0, "fa47c35000"},
+	{3.4028234663852886e+38, "fa7f7fffff"},
+	{1.0e+300, "fb7e37e43c8800759c"},
+	{5.960464477539063e-8, "f90001"},
+	{0.00006103515625, "f90400"},
+	{-4.0, "f9c400"},
+	{-4.1, "fbc010666666666666"},
+	{math.Inf(1), "f97c00"},
+	{math.Inf(-1), "f9fc00"},
+	{false, "f4"},
+	{true, "f5"},
+	{nil, "f6"},
+	{Undefined{}, "f7"},
+	{Simple(16), "f0"},
+	{Simple(255), "f8ff"},
+	{time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC), "c11a514b67b0"},
+	{time.Date(2013, 3, 21, 20, 4, 0, 500000000, time.UTC), "c1fb41d452d9ec200000"},
+	{Tag{Number: 23, Content: []byte{1, 2, 3, 4}}, "d74401020304"},
+	{Tag{Number: 24, Content: []byte{0x64, 0x49, 0x45, 0x54, 0x46}}, "d818456449455446"},
+	{Tag{Number: 32, Content: "http://www.example.com"}, "d82076687474703a2f2f7777772e6578616d706c652e636f6d"},
+	{[]byte{}, "40"},
+	{[]byte{1, 2, 3, 4}, "4401020304"},
+	{"", "60"},
+	{"a", "6161"},
+	{"IETF", "6449455446"},
+	{"\"\\", "62225c"},
+	{"ü", "62c3bc"},
+	{"水", "63e6b0b4"},
+	{"\U00010151", "64f0908591"},
+	{[]any{}, "80"},
+	{[]any{int64(1), int64(2), int64(3)}, "83010203"},
+	{[]any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}}, "8301820203820405"},
+	{map[string]any{}, "a0"},
+	{map[any]any{int64(1): int64(2), int64(3): int64(4)}, "a201020304"},
+	{map[string]any{"a": int64(1), "b": []any{int64(2), int64(3)}}, "a26161016162820203"},
+	{[]any{"a", map[string]any{"b": "c"}}, "826161a161626163"},
+	{map[string]any{"a": "A", "b": "B", "c": "C", "d": "D", "e": "E"}, "a56161614161626142616361436164614461656145"},
+}
+
+func TestMarshal_Examples(t *testing.T) {
+	for _, example := range examples {
+		out, err := EncOptions{Deterministic: true}.Marshal(example.Value)
+		if err != nil {
+			t.Errorf("FAIL: %#v error: %s", example.Value, err)
+			continue
+		}
+		if hex.EncodeToString(out) != example.Hex {
+			t.Errorf("FAIL: %#v expected %s but got %x", example.Value, example.Hex, out)
+		}
+	}
+}
+
+func TestUnmarshal_Examples(t *testing.T) {
+	for _, example := range examples {
+		data, _ := hex.DecodeString(example.Hex)
+		value, err := Unmarshal(data)
+		if err != nil {
+			t.Errorf("FAIL: %s error: %s", example.Hex, err)
+			continue
+		}
+		if !equal(example.Value, value) {
+			t.Errorf("FAIL: %s expected %#v but got %#v", example.Hex, example.Value, value)
+		}
+	}
+}
+
+func TestUnmarshal_Indefinite(t *testing.T) {
+	cases := map[string]any{
+		"5f42010243030405ff":                           []byte{1, 2, 3, 4, 5},
+		"7f657374726561646d696e67ff":                   "streaming",
+		"9fff":                                         []any{},
+		"9f018202039f0405ffff":                         []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}},
+		"9f01820203820405ff":                           []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}},
+		"83018202039f0405ff":                           []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}},
+		"bf61610161629f0203ffff":                       map[string]any{"a": int64(1), "b": []any{int64(2), int64(3)}},
+		"826161bf61626163ff":                           []any{"a", map[string]any{"b": "c"}},
+		"bf6346756ef563416d7421ff":                     map[string]any{"Fun": true, "Amt": int64(-2)},
+		"c074323031332d30332d32315432303a30343a30305a": time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC),
+	}
+	for h, expected := range cases {
+		data, _ := hex.DecodeString(h)
+		value, err := Unmarshal(data)
+		if err != nil {
+			t.Errorf("FAIL: %s error: %s", h, err)
+			continue
+		}
+		if !equal(expected, value) {
+			t.Errorf("FAIL: %s expected %#v but got %#v", h, expected, value)
+		}
+	}
+
+	out, err := EncOptions{IndefiniteLength: true}.Marshal([]any{int64(1), map[string]any{"a": []any{}}})
+	if err != nil || hex.EncodeToString(out) != "9f01bf61619fffffff" {
+		t.Errorf("FAIL: unexpected indefinite length encoding %x (%v)", out, err)
+	}
+	if _, err := (EncOptions{IndefiniteLength: true, Deterministic: true}).Marshal(1); err == nil {
+		t.Errorf("FAIL: expected an error for deterministic indefinite length encoding")
+	}
+}
+
+func TestUnmarshal_Invalid(t *testing.T) {
+	for _, h := range []string{"", "18", "1c", "62c3", "5f01ff", "7f4100ff", "ff", "9f", "a1", "a16161", "8201", "0000", "62ff00", "a18080f6", "f818", "c06161"} {
+		data, _ := hex.DecodeString(h)
+		if value, err := Unmarshal(data); err == nil {
+			t.Errorf("FAIL: %s expected an error but got %#v", h, value)
+		}
+	}
+}
+
+func TestDeterministic_MapKeyOrder(t *testing.T) {
+	m := map[any]any{"aa": 1, "b": 2, int64(100): 3, int64(-1): 4, false: 5}
+	out, err := EncOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 100, -1, "b", "aa", false
+	if expected := "a5186403200461620262616101f405"; hex.EncodeToString(out) != expected {
+		t.Errorf("FAIL: expected %s but got %x", expected, out)
+	}
+}
+
+func TestJSONConversion(t *testing.T) {
+	tree, err := json.ParseJson(`{"a": [1, -2.5, 1e300, 18446744073709551615], "b": "text", "c": null, "d": {"e": true}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := EncOptions{Deterministic: true}.Marshal(FromJSON(tree))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Unmarshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := decoded.(map[string]any)["a"].([]any)[0]; first != int64(1) {
+		t.Errorf("FAIL: expected integers to be encoded as integers, got %#v", first)
+	}
+	back, err := ToJSON(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tree, back) {
+		t.Errorf("FAIL: expected %#v but got %#v", tree, back)
+	}
+
+	cases := []struct {
+		Hex string
+		Out any
+	}{
+		{"4401020304", "AQIDBA"},
+		{"d6820102", []any{1.0, 2.0}},
+		{"d64401020304", "AQIDBA=="},
+		{"d74401020304", "01020304"},
+		{"d7a161614401020304", map[string]any{"a": "01020304"}},
+		{"f97e00", nil},
+		{"f7", nil},
+		{"c249010000000000000000", "AQAAAAAAAAAA"},
+		{"c349010000000000000000", "~AQAAAAAAAAAA"},
+		{"a201020304", map[string]any{"1": 2.0, "3": 4.0}},
+		{"c11a514b67b0", "2013-03-21T20:04:00Z"},
+	}
+	for _, testCase := range cases {
+		data, _ := hex.DecodeString(testCase.Hex)
+		decoded, err := Unmarshal(data)
+		if err != nil {
+			t.Errorf("FAIL: %s error: %s", testCase.Hex, err)
+			continue
+		}
+		value, err := ToJSON(decoded)
+		if err != nil || !reflect.DeepEqual(value, testCase.Out) {
+			t.Errorf("FAIL: %s expected %#v but got %#v (%v)", testCase.Hex, testCase.Out, value, err)
+		}
+	}
+}
+
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case *big.Int:
+		b, ok := b.(*big.Int)
+		return ok && a.Cmp(b) == 0
+	case time.Time:
+		b, ok := b.(time.Time)
+		return ok && a.Equal(b)
+	case float64:
+		b, ok := b.(float64)
+		return ok && math.Float64bits(a) == math.Float64bits(b)
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,386 @@
+package cbor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"time"
+	"unicode/utf8"
+)
+
+// maxDepth bounds the nesting of arrays, maps and tags, so that a crafted
+// input cannot exhaust the stack.
+const maxDepth = 10000
+
+var errBreak = errors.New("cbor: unexpected break")
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// Decoder reads a stream of CBOR data items.
+type Decoder struct {
+	r byteReader
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	if br, ok := r.(byteReader); ok {
+		return &Decoder{r: br}
+	}
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Unmarshal decodes a single data item. Integers decode to int64, or uint64
+// and *big.Int when they do not fit, tags 0 and 1 to time.Time, tags 2 and 3
+// to *big.Int and the other tags to Tag. Maps decode to map[string]any when
+// every key is a text string and to map[any]any otherwise.
+func Unmarshal(data []byte) (any, error) {
+	r := bytes.NewReader(data)
+	value, err := NewDecoder(r).Decode()
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("cbor: %d bytes of trailing data", r.Len())
+	}
+	return value, nil
+}
+
+// Decode reads the next data item. It returns io.EOF when the stream ends
+// before a new item.
+func (d *Decoder) Decode() (any, error) {
+	value, err := d.item(0)
+	if err == errBreak {
+		return nil, err
+	}
+	return value, err
+}
+
+func (d *Decoder) item(depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errors.New("cbor: maximum nesting depth exceeded")
+	}
+	initial, err := d.r.ReadByte()
+	if err != nil {
+		if depth > 0 && err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if initial == breakByte {
+		return nil, errBreak
+	}
+	major, info := initial>>5, initial&0x1f
+
+	if major == majorSimple {
+		return d.simple(info)
+	}
+	if info == infoIndefinite {
+		return d.indefinite(major, depth)
+	}
+	n, err := d.argument(info)
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case majorUnsigned:
+		if n > math.MaxInt64 {
+			return n, nil
+		}
+		return int64(n), nil
+	case majorNegative:
+		if n > math.MaxInt64 {
+			return new(big.Int).Sub(big.NewInt(-1), new(big.Int).SetUint64(n)), nil
+		}
+		return -1 - int64(n), nil
+	case majorBytes:
+		return d.read(n)
+	case majorText:
+		b, err := d.read(n)
+		if err != nil {
+			return nil, err
+		}
+		if !utf8.Valid(b) {
+			return nil, errors.New("cbor: invalid UTF-8 in text string")
+		}
+		return string(b), nil
+	case majorArray:
+		arr := make([]any, 0, min(n, 1024))
+		for i := uint64(0); i < n; i++ {
+			value, err := d.nested(depth)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		return arr, nil
+	case majorMap:
+		m := newMapBuilder()
+		for i := uint64(0); i < n; i++ {
+			if err := d.entry(m, depth); err != nil {
+				return nil, err
+			}
+		}
+		return m.result(), nil
+	default:
+		content, err := d.nested(depth)
+		if err != nil {
+			return nil, err
+		}
+		return decodeTag(n, content)
+	}
+}
+
+// nested reads an item inside a container, where a break is not allowed.
+func (d *Decoder) nested(depth int) (any, error) {
+	value, err := d.item(depth + 1)
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return value, err
+}
+
+func (d *Decoder) entry(m *mapBuilder, depth int) error {
+	key, err := d.nested(depth)
+	if err != nil {
+		return err
+	}
+	value, err := d.nested(depth)
+	if err != nil {
+		return err
+	}
+	return m.add(key, value)
+}
+
+func (d *Decoder) indefinite(major byte, depth int) (any, error) {
+	switch major {
+	case majorBytes, majorText:
+		var buf []byte
+		for {
+			chunk, err := d.nested(depth)
+			if err == errBreak {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			switch chunk := chunk.(type) {
+			case []byte:
+				if major != majorBytes {
+					return nil, errors.New("cbor: invalid chunk in indefinite length text string")
+				}
+				buf = append(buf, chunk...)
+			case string:
+				if major != majorText {
+					return nil, errors.New("cbor: invalid chunk in indefinite length byte string")
+				}
+				buf = append(buf, chunk...)
+			default:
+				return nil, errors.New("cbor: invalid chunk in indefinite length string")
+			}
+		}
+		if major == majorText {
+			return string(buf), nil
+		}
+		if buf == nil {
+			buf = []byte{}
+		}
+		return buf, nil
+	case majorArray:
+		arr := make([]any, 0)
+		for {
+			value, err := d.nested(depth)
+			if err == errBreak {
+				return arr, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+	case majorMap:
+		m := newMapBuilder()
+		for {
+			key, err := d.nested(depth)
+			if err == errBreak {
+				return m.result(), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			value, err := d.nested(depth)
+			if err != nil {
+				return nil, err
+			}
+			if err := m.add(key, value); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("cbor: indefinite length not allowed for major type %d", major)
+	}
+}
+
+func (d *Decoder) argument(info byte) (uint64, error) {
+	if info < 24 {
+		return uint64(info), nil
+	}
+	var size int
+	switch info {
+	case 24:
+		size = 1
+	case 25:
+		size = 2
+	case 26:
+		size = 4
+	case 27:
+		size = 8
+	default:
+		return 0, fmt.Errorf("cbor: invalid additional information %d", info)
+	}
+	var buf [8]byte
+	if _, err := io.ReadFull(d.r, buf[8-size:]); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+func (d *Decoder) simple(info byte) (any, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22:
+		return nil, nil
+	case 23:
+		return Undefined{}, nil
+	case 24:
+		b, err := d.r.ReadByte()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if b < 32 {
+			return nil, fmt.Errorf("cbor: invalid simple value %d", b)
+		}
+		return Simple(b), nil
+	case 25:
+		n, err := d.argument(info)
+		return fromFloat16(uint16(n)), err
+	case 26:
+		n, err := d.argument(info)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 27:
+		n, err := d.argument(info)
+		return math.Float64frombits(n), err
+	}
+	if info < 20 {
+		return Simple(info), nil
+	}
+	return nil, fmt.Errorf("cbor: invalid additional information %d", info)
+}
+
+// read reads n bytes without trusting n for the allocation.
+func (d *Decoder) read(n uint64) ([]byte, error) {
+	if n > math.MaxInt64 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if n <= 4096 {
+		buf := make([]byte, n)
+		_, err := io.ReadFull(d.r, buf)
+		return buf, unexpectedEOF(err)
+	}
+	var buf bytes.Buffer
+	if read, err := io.CopyN(&buf, d.r, int64(n)); err != nil || uint64(read) != n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return buf.Bytes(), nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func decodeTag(number uint64, content any) (any, error) {
+	switch number {
+	case TagDateTimeString:
+		s, ok := content.(string)
+		if !ok {
+			return nil, errors.New("cbor: tag 0 expects a text string")
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	case TagEpochDateTime:
+		switch value := content.(type) {
+		case int64:
+			return time.Unix(value, 0).UTC(), nil
+		case float64:
+			seconds, fraction := math.Modf(value)
+			return time.Unix(int64(seconds), int64(fraction*1e9)).UTC(), nil
+		}
+		return nil, errors.New("cbor: tag 1 expects a number")
+	case TagPositiveBignum, TagNegativeBignum:
+		b, ok := content.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("cbor: tag %d expects a byte string", number)
+		}
+		n := new(big.Int).SetBytes(b)
+		if number == TagNegativeBignum {
+			n.Neg(n).Sub(n, big.NewInt(1))
+		}
+		return n, nil
+	}
+	return Tag{Number: number, Content: content}, nil
+}
+
+// mapBuilder keeps map[string]any until a key of another type shows up.
+type mapBuilder struct {
+	strings map[string]any
+	any     map[any]any
+}
+
+func newMapBuilder() *mapBuilder {
+	return &mapBuilder{strings: make(map[string]any)}
+}
+
+func (m *mapBuilder) add(key, value any) error {
+	if k, ok := key.(string); ok && m.any == nil {
+		m.strings[k] = value
+		return nil
+	}
+	if !hashable(key) {
+		return fmt.Errorf("cbor: unsupported map key type %T", key)
+	}
+	if m.any == nil {
+		m.any = make(map[any]any, len(m.strings)+1)
+		for k, v := range m.strings {
+			m.any[k] = v
+		}
+	}
+	m.any[key] = value
+	return nil
+}
+
+func (m *mapBuilder) result() any {
+	if m.any != nil {
+		return m.any
+	}
+	return m.strings
+}
+
+func hashable(key any) bool {
+	if tag, ok := key.(Tag); ok {
+		return hashable(tag.Content)
+	}
+	return key == nil || reflect.TypeOf(key).Comparable()
+}
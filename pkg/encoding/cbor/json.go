@@ -0,0 +1,151 @@
+package cbor
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+// FromJSON prepares a value produced by json.ParseJson for encoding, following
+// the JSON to CBOR rules of RFC 8949 section 6.2: numbers without a fractional
+// part become integers, the other numbers stay floating point.
+func FromJSON(v any) any {
+	switch value := v.(type) {
+	case float64:
+		if value == math.Trunc(value) && value >= math.MinInt64 && value < math.MaxInt64 {
+			return int64(value)
+		}
+		if value == math.Trunc(value) && value >= 0 && value < math.MaxUint64 {
+			return uint64(value)
+		}
+		return value
+	case []any:
+		arr := make([]any, len(value))
+		for i, item := range value {
+			arr[i] = FromJSON(item)
+		}
+		return arr
+	case map[string]any:
+		obj := make(map[string]any, len(value))
+		for k, item := range value {
+			obj[k] = FromJSON(item)
+		}
+		return obj
+	}
+	return v
+}
+
+// ToJSON converts a decoded data item into the value model of json.ParseJson,
+// following the CBOR to JSON rules of RFC 8949 section 6.1:
+//
+//   - integers and finite floats become float64, NaN and infinities become nil
+//   - byte strings become base64url strings without padding; tags 21, 22 and
+//     23 select base64url, base64 or base16 for the byte strings they enclose
+//   - bignums become the base64url string of their bytes, prefixed with `~`
+//     when negative
+//   - undefined and the other simple values become nil
+//   - map keys that are not text strings use their JSON form
+//   - the other tags are dropped and their content is converted
+//
+// Times decoded from tags 0 and 1 become RFC 3339 strings.
+func ToJSON(v any) (any, error) {
+	return toJSON(v, base64.RawURLEncoding.EncodeToString)
+}
+
+func toJSON(v any, encodeBytes func([]byte) string) (any, error) {
+	switch value := v.(type) {
+	case nil, bool, string:
+		return value, nil
+	case int64:
+		return float64(value), nil
+	case uint64:
+		return float64(value), nil
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, nil
+		}
+		return value, nil
+	case []byte:
+		return encodeBytes(value), nil
+	case *big.Int:
+		if value.Sign() >= 0 {
+			return base64.RawURLEncoding.EncodeToString(value.Bytes()), nil
+		}
+		m := new(big.Int).Neg(value)
+		m.Sub(m, big.NewInt(1))
+		return "~" + base64.RawURLEncoding.EncodeToString(m.Bytes()), nil
+	case time.Time:
+		return value.Format(time.RFC3339Nano), nil
+	case Undefined, Simple:
+		return nil, nil
+	case Tag:
+		switch value.Number {
+		case TagBase64URL:
+			encodeBytes = base64.RawURLEncoding.EncodeToString
+		case TagBase64:
+			encodeBytes = base64.StdEncoding.EncodeToString
+		case TagBase16:
+			encodeBytes = hex.EncodeToString
+		}
+		return toJSON(value.Content, encodeBytes)
+	case []any:
+		arr := make([]any, len(value))
+		for i, item := range value {
+			converted, err := toJSON(item, encodeBytes)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = converted
+		}
+		return arr, nil
+	case map[string]any:
+		obj := make(map[string]any, len(value))
+		for k, item := range value {
+			converted, err := toJSON(item, encodeBytes)
+			if err != nil {
+				return nil, err
+			}
+			obj[k] = converted
+		}
+		return obj, nil
+	case map[any]any:
+		obj := make(map[string]any, len(value))
+		for k, item := range value {
+			key, err := jsonKey(k, encodeBytes)
+			if err != nil {
+				return nil, err
+			}
+			converted, err := toJSON(item, encodeBytes)
+			if err != nil {
+				return nil, err
+			}
+			obj[key] = converted
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("cbor: cannot convert type %T to JSON", v)
+}
+
+// jsonKey returns the text of a map key: strings are kept, numbers,
+// booleans and null use their JSON text.
+func jsonKey(k any, encodeBytes func([]byte) string) (string, error) {
+	key, err := toJSON(k, encodeBytes)
+	if err != nil {
+		return "", err
+	}
+	switch key := key.(type) {
+	case string:
+		return key, nil
+	case float64:
+		return strconv.FormatFloat(key, 'g', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(key), nil
+	case nil:
+		return "null", nil
+	}
+	return "", fmt.Errorf("cbor: unsupported map key type %T", k)
+}
@@ -0,0 +1,163 @@
+// Package structfields lists the fields of a struct type as encoding/json
+// sees them, for the encoders of pkg/encoding.
+package structfields
+
+import (
+	"cmp"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Field is a member of the encoded object.
+type Field struct {
+	// Name comes from the `json` tag, or is the Go name of the field.
+	Name string
+	// Index leads to the field through the embedded structs, as in
+	// reflect.Value.FieldByIndex.
+	Index     []int
+	OmitEmpty bool
+
+	tagged bool
+}
+
+var cache sync.Map
+
+// Of returns the fields of the struct type t in declaration order. Like
+// encoding/json, the fields of embedded structs without a tagged name are
+// promoted: a name used at several depths belongs to the shallowest field,
+// and a name used twice at that depth to the only tagged one, otherwise it is
+// dropped.
+func Of(t reflect.Type) []Field {
+	if cached, ok := cache.Load(t); ok {
+		return cached.([]Field)
+	}
+
+	type embedded struct {
+		t     reflect.Type
+		index []int
+	}
+	var all []Field
+	visited := map[reflect.Type]bool{}
+	for current := []embedded{{t: t}}; len(current) > 0; {
+		var next []embedded
+		for _, s := range current {
+			// a type embedded twice at a depth is ambiguous and already
+			// hidden by the first one at a lower depth
+			if visited[s.t] {
+				continue
+			}
+			visited[s.t] = true
+			for i := 0; i < s.t.NumField(); i++ {
+				f := s.t.Field(i)
+				ft := f.Type
+				if f.Anonymous && ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if !f.IsExported() && !(f.Anonymous && ft.Kind() == reflect.Struct) {
+					continue
+				}
+				tag := f.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, options, _ := strings.Cut(tag, ",")
+				index := append(slices.Clip(s.index), i)
+				if name == "" && f.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{t: ft, index: index})
+					continue
+				}
+				if !f.IsExported() {
+					// unlike encoding/json, an unexported embedded struct
+					// with a name is left out, reflection can't read it
+					continue
+				}
+				field := Field{Name: name, Index: index, tagged: name != ""}
+				if name == "" {
+					field.Name = f.Name
+				}
+				field.OmitEmpty = strings.Contains(","+options+",", ",omitempty,")
+				all = append(all, field)
+			}
+		}
+		current = next
+	}
+
+	// group by name, the dominant field first
+	slices.SortStableFunc(all, func(a, b Field) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(len(a.Index), len(b.Index)); c != 0 {
+			return c
+		}
+		if a.tagged != b.tagged {
+			if a.tagged {
+				return -1
+			}
+			return 1
+		}
+		return 0
+	})
+	fields := make([]Field, 0, len(all))
+	for i := 0; i < len(all); {
+		j := i + 1
+		for j < len(all) && all[j].Name == all[i].Name {
+			j++
+		}
+		first := all[i]
+		if j == i+1 || len(all[i+1].Index) > len(first.Index) || first.tagged && !all[i+1].tagged {
+			fields = append(fields, first)
+		}
+		i = j
+	}
+	slices.SortFunc(fields, func(a, b Field) int {
+		return slices.Compare(a.Index, b.Index)
+	})
+
+	cache.Store(t, fields)
+	return fields
+}
+
+// Lookup finds the field named name, preferring an exact match over a
+// case-insensitive one.
+func Lookup(fields []Field, name string) (Field, bool) {
+	for _, f := range fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.Name, name) {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// Get returns the field in the struct v. It returns false when an embedded
+// pointer on the way is nil, the field is then left out.
+func (f Field) Get(v reflect.Value) (reflect.Value, bool) {
+	field, err := v.FieldByIndexErr(f.Index)
+	return field, err == nil
+}
+
+// Set returns the field in the struct v to decode into, allocating the nil
+// embedded pointers on the way. It returns false when one of them points to
+// an unexported type and cannot be allocated.
+func (f Field) Set(v reflect.Value) (reflect.Value, bool) {
+	for i, x := range f.Index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
@@ -0,0 +1,51 @@
+package structfields
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOf(t *testing.T) {
+	type Inner struct {
+		A int `json:"a,omitempty"`
+		B int
+		C int
+	}
+	type Other struct {
+		B int
+		C int `json:"C"`
+	}
+	type Outer struct {
+		*Inner
+		Other
+		D int `json:"-"`
+		E int `json:"e"`
+		B string
+	}
+	var names []string
+	for _, f := range Of(reflect.TypeOf(Outer{})) {
+		names = append(names, f.Name)
+	}
+	// B is hidden by Outer.B and C belongs to the tagged Other.C
+	if expected := []string{"a", "C", "e", "B"}; !reflect.DeepEqual(expected, names) {
+		t.Errorf("FAIL: expected %v but got %v", expected, names)
+	}
+
+	fields := Of(reflect.TypeOf(Outer{}))
+	a, ok := Lookup(fields, "A")
+	if !ok || !a.OmitEmpty || !reflect.DeepEqual(a.Index, []int{0, 0}) {
+		t.Fatalf("FAIL: expected a case insensitive match but got %+v %v", a, ok)
+	}
+	var outer Outer
+	if _, ok := a.Get(reflect.ValueOf(outer)); ok {
+		t.Errorf("FAIL: expected a nil embedded pointer to hide the field")
+	}
+	field, ok := a.Set(reflect.ValueOf(&outer).Elem())
+	if !ok || outer.Inner == nil {
+		t.Fatalf("FAIL: expected the embedded pointer to be allocated")
+	}
+	field.SetInt(3)
+	if outer.A != 3 {
+		t.Errorf("FAIL: expected 3 but got %d", outer.A)
+	}
+}
@@ -0,0 +1,355 @@
+package json
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// An array index is a sidecar file holding the byte offsets of the elements of
+// a top-level array, so that single elements of a huge file can be read
+// without lexing the rest. Its layout, in little endian:
+//
+//	magic     8 bytes  "JSONAIX1"
+//	size      uint64   size of the indexed file
+//	modTime   int64    modification time of the indexed file, in nanoseconds
+//	checksum  uint64   CRC-64 of the first and last indexSampleSize bytes
+//	count     uint64   number of elements
+//	offsets   count × (start, end uint64)
+const (
+	indexMagic       = "JSONAIX1"
+	indexHeaderSize  = 40
+	indexEntrySize   = 16
+	indexSampleSize  = 64 << 10
+	indexPathSuffix  = ".idx"
+	indexBufferSize  = 1 << 20
+	indexMaxReadSize = 1 << 30
+)
+
+// ErrStaleIndex is returned when the indexed file changed after its index was
+// built.
+var ErrStaleIndex = errors.New("stale array index: the file changed since it was indexed")
+
+var indexCRCTable = crc64.MakeTable(crc64.ECMA)
+
+// BuildArrayIndex reads the top-level array stored at path once, checking
+// that it is valid JSON, and writes the offsets of its elements to indexPath.
+// An empty indexPath stands for path + ".idx". The index is written to a
+// temporary file first, so a failed build leaves the previous one in place.
+func BuildArrayIndex(path, indexPath string) error {
+	if indexPath == "" {
+		indexPath = path + indexPathSuffix
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	header, err := indexHeaderOf(f)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(indexPath), ".arrayindex-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	out := bufio.NewWriterSize(tmp, indexBufferSize)
+	// the header is rewritten with the count at the end
+	if _, err := out.Write(make([]byte, indexHeaderSize)); err != nil {
+		return err
+	}
+	b := &arrayIndexBuilder{out: out}
+	parser := &PushParser{OnEvent: b.event}
+	if _, err := io.CopyBuffer(parser, f, make([]byte, indexBufferSize)); err != nil {
+		return err
+	}
+	if err := parser.Close(); err != nil {
+		return err
+	}
+	if !b.done {
+		return errors.New("EOF: end of file")
+	}
+	if err := out.Flush(); err != nil {
+		return err
+	}
+
+	header.count = b.count
+	if _, err := tmp.WriteAt(header.encode(), 0); err != nil {
+		return err
+	}
+	// CreateTemp makes the file readable by its owner only
+	if err := tmp.Chmod(0o644); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), indexPath)
+}
+
+// arrayIndexBuilder writes the offsets of the elements of the top-level array
+// from the events of a PushParser.
+type arrayIndexBuilder struct {
+	out   *bufio.Writer
+	depth int
+	start int64
+	count uint64
+	done  bool
+	entry [indexEntrySize]byte
+}
+
+func (b *arrayIndexBuilder) event(e Event) error {
+	if b.done {
+		return fmt.Errorf("invalid character `%s` after top-level value at byte %d", e.Value, e.Offset)
+	}
+	switch e.Kind {
+	case TokenKindBracketOpen, TokenKindBraceOpen:
+		if b.depth == 0 && e.Kind != TokenKindBracketOpen {
+			return errors.New("cannot index: the top-level value is not an array")
+		}
+		if b.depth == 1 {
+			b.start = e.Offset
+		}
+		b.depth++
+	case TokenKindBracketClose, TokenKindBraceClose:
+		b.depth--
+		switch b.depth {
+		case 0:
+			b.done = true
+		case 1:
+			return b.element(e.Offset + 1)
+		}
+	case TokenKindComma, TokenKindColon:
+	default:
+		if b.depth == 0 {
+			return errors.New("cannot index: the top-level value is not an array")
+		}
+		if b.depth == 1 {
+			b.start = e.Offset
+			return b.element(e.Offset + int64(len(e.Value)))
+		}
+	}
+	return nil
+}
+
+func (b *arrayIndexBuilder) element(end int64) error {
+	binary.LittleEndian.PutUint64(b.entry[:8], uint64(b.start))
+	binary.LittleEndian.PutUint64(b.entry[8:], uint64(end))
+	b.count++
+	_, err := b.out.Write(b.entry[:])
+	return err
+}
+
+type indexHeader struct {
+	size     uint64
+	modTime  int64
+	checksum uint64
+	count    uint64
+}
+
+// indexHeaderOf describes the current state of f.
+func indexHeaderOf(f *os.File) (indexHeader, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return indexHeader{}, err
+	}
+	size := info.Size()
+	h := crc64.New(indexCRCTable)
+	if _, err := io.Copy(h, io.NewSectionReader(f, 0, min(size, indexSampleSize))); err != nil {
+		return indexHeader{}, err
+	}
+	if tail := max(indexSampleSize, size-indexSampleSize); tail < size {
+		if _, err := io.Copy(h, io.NewSectionReader(f, tail, size-tail)); err != nil {
+			return indexHeader{}, err
+		}
+	}
+	return indexHeader{size: uint64(size), modTime: info.ModTime().UnixNano(), checksum: h.Sum64()}, nil
+}
+
+func (h indexHeader) encode() []byte {
+	buf := make([]byte, indexHeaderSize)
+	copy(buf, indexMagic)
+	binary.LittleEndian.PutUint64(buf[8:], h.size)
+	binary.LittleEndian.PutUint64(buf[16:], uint64(h.modTime))
+	binary.LittleEndian.PutUint64(buf[24:], h.checksum)
+	binary.LittleEndian.PutUint64(buf[32:], h.count)
+	return buf
+}
+
+func decodeIndexHeader(buf []byte) (indexHeader, error) {
+	if len(buf) < indexHeaderSize || string(buf[:8]) != indexMagic {
+		return indexHeader{}, errors.New("invalid array index")
+	}
+	return indexHeader{
+		size:     binary.LittleEndian.Uint64(buf[8:]),
+		modTime:  int64(binary.LittleEndian.Uint64(buf[16:])),
+		checksum: binary.LittleEndian.Uint64(buf[24:]),
+		count:    binary.LittleEndian.Uint64(buf[32:]),
+	}, nil
+}
+
+// IndexedArray reads single elements of a top-level array through its index.
+// Only the bytes of the requested elements are read and parsed. It is safe for
+// concurrent use.
+type IndexedArray struct {
+	data   *os.File
+	index  *os.File
+	header indexHeader
+}
+
+// OpenIndexedArray opens the array stored at path with the index built by
+// BuildArrayIndex. An empty indexPath stands for path + ".idx". It returns
+// ErrStaleIndex when the size, the modification time or the first and last
+// bytes of the file differ from the ones recorded in the index.
+func OpenIndexedArray(path, indexPath string) (*IndexedArray, error) {
+	if indexPath == "" {
+		indexPath = path + indexPathSuffix
+	}
+	data, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	index, err := os.Open(indexPath)
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+	a := &IndexedArray{data: data, index: index}
+	if err := a.open(); err != nil {
+		a.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *IndexedArray) open() error {
+	buf := make([]byte, indexHeaderSize)
+	if _, err := a.index.ReadAt(buf, 0); err != nil {
+		return errors.New("invalid array index")
+	}
+	header, err := decodeIndexHeader(buf)
+	if err != nil {
+		return err
+	}
+	info, err := a.index.Stat()
+	if err != nil {
+		return err
+	}
+	if uint64(info.Size()) != indexHeaderSize+header.count*indexEntrySize {
+		return errors.New("invalid array index: truncated offsets")
+	}
+	a.header = header
+	return a.Check()
+}
+
+// Check returns ErrStaleIndex when the file changed since it was indexed.
+func (a *IndexedArray) Check() error {
+	current, err := indexHeaderOf(a.data)
+	if err != nil {
+		return err
+	}
+	current.count = a.header.count
+	if current != a.header {
+		return ErrStaleIndex
+	}
+	return nil
+}
+
+// Len returns the number of elements.
+func (a *IndexedArray) Len() int {
+	return int(a.header.count)
+}
+
+// offsets returns the spans of the elements from to to, excluded.
+func (a *IndexedArray) offsets(from, to int) ([]span, error) {
+	if from < 0 || to > a.Len() || from > to {
+		return nil, fmt.Errorf("index out of range: [%d:%d] (slice length: %d)", from, to, a.Len())
+	}
+	buf := make([]byte, (to-from)*indexEntrySize)
+	if _, err := a.index.ReadAt(buf, indexHeaderSize+int64(from)*indexEntrySize); err != nil {
+		return nil, err
+	}
+	spans := make([]span, to-from)
+	for i := range spans {
+		entry := buf[i*indexEntrySize:]
+		spans[i] = span{
+			start: int(binary.LittleEndian.Uint64(entry)),
+			end:   int(binary.LittleEndian.Uint64(entry[8:])),
+		}
+		if spans[i].start > spans[i].end || uint64(spans[i].end) > a.header.size {
+			return nil, errors.New("invalid array index: offsets out of the file")
+		}
+	}
+	return spans, nil
+}
+
+// read returns the bytes of the file between start and end.
+func (a *IndexedArray) read(start, end int) ([]byte, error) {
+	if end-start > indexMaxReadSize {
+		return nil, fmt.Errorf("range of %d bytes is too large to be read at once", end-start)
+	}
+	buf := make([]byte, end-start)
+	if _, err := a.data.ReadAt(buf, int64(start)); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Raw returns the original bytes of the i-th element.
+func (a *IndexedArray) Raw(i int) (RawValue, error) {
+	if i < 0 || i >= a.Len() {
+		return nil, fmt.Errorf("index out of range: %d (slice length: %d)", i, a.Len())
+	}
+	spans, err := a.offsets(i, i+1)
+	if err != nil {
+		return nil, err
+	}
+	return a.read(spans[0].start, spans[0].end)
+}
+
+// Get parses the i-th element.
+func (a *IndexedArray) Get(i int) (any, error) {
+	raw, err := a.Raw(i)
+	if err != nil {
+		return nil, err
+	}
+	return raw.Parse()
+}
+
+// Range parses the elements from from to to, excluded, reading their bytes
+// from the file at once.
+func (a *IndexedArray) Range(from, to int) ([]any, error) {
+	spans, err := a.offsets(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(spans) == 0 {
+		return make([]any, 0), nil
+	}
+	first := spans[0].start
+	buf, err := a.read(first, spans[len(spans)-1].end)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, len(spans))
+	for i, s := range spans {
+		if values[i], err = ParseJson(string(buf[s.start-first : s.end-first])); err != nil {
+			return nil, fmt.Errorf("array element %d: %w", from+i, err)
+		}
+	}
+	return values, nil
+}
+
+// Close closes the file and its index.
+func (a *IndexedArray) Close() error {
+	return errors.Join(a.data.Close(), a.index.Close())
+}
@@ -0,0 +1,136 @@
+package json
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeArrayFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "records.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIndexedArray(t *testing.T) {
+	content := " [\n  {\"id\": 0, \"tags\": [\"a,b\", \"]\"]},\n  \"x\",\n  12.5e1 ,\n  null,\n  [[]],\n  {\"id\": 5, \"s\": \"\\\"}\"}\n]\n"
+	path := writeArrayFile(t, content)
+	if err := BuildArrayIndex(path, ""); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path + ".idx"); err != nil || info.Mode().Perm() != 0o644 {
+		t.Errorf("FAIL: expected an index readable by everyone but got %v", info)
+	}
+	a, err := OpenIndexedArray(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	expected, _ := ParseJson(content)
+	elements := expected.([]any)
+	if a.Len() != len(elements) {
+		t.Fatalf("FAIL: expected %d elements but got %d", len(elements), a.Len())
+	}
+	for i := range elements {
+		value, err := a.Get(i)
+		if err != nil || !isEqual(value, elements[i]) {
+			t.Errorf("FAIL: element %d: expected %v but got %v %v", i, elements[i], value, err)
+		}
+	}
+	if raw, _ := a.Raw(2); string(raw) != "12.5e1" {
+		t.Errorf("FAIL: expected the original bytes but got %q", raw)
+	}
+
+	values, err := a.Range(1, 5)
+	if err != nil || !isEqual(values, elements[1:5]) {
+		t.Errorf("FAIL: expected %v but got %v %v", elements[1:5], values, err)
+	}
+	if values, err := a.Range(3, 3); err != nil || len(values) != 0 {
+		t.Errorf("FAIL: expected an empty range but got %v %v", values, err)
+	}
+	for _, bounds := range [][2]int{{-1, 2}, {2, 7}, {4, 3}} {
+		if _, err := a.Range(bounds[0], bounds[1]); err == nil {
+			t.Errorf("FAIL: expected an error for %v", bounds)
+		}
+	}
+	if _, err := a.Get(6); err == nil {
+		t.Errorf("FAIL: expected an error for an out of range index")
+	}
+}
+
+func TestIndexedArray_Empty(t *testing.T) {
+	path := writeArrayFile(t, `[ ]`)
+	indexPath := filepath.Join(t.TempDir(), "custom.idx")
+	if err := BuildArrayIndex(path, indexPath); err != nil {
+		t.Fatal(err)
+	}
+	a, err := OpenIndexedArray(path, indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if a.Len() != 0 {
+		t.Errorf("FAIL: expected no element but got %d", a.Len())
+	}
+}
+
+func TestBuildArrayIndex_Invalid(t *testing.T) {
+	for _, content := range []string{`{"a": 1}`, `1`, `[1, 2`, `[1, 2,]`, `[1] [2]`, ``, `[1]]`} {
+		path := writeArrayFile(t, content)
+		if err := BuildArrayIndex(path, ""); err == nil {
+			t.Errorf("FAIL: expected an error for %q", content)
+		}
+		if _, err := os.Stat(path + ".idx"); !os.IsNotExist(err) {
+			t.Errorf("FAIL: expected no index for %q", content)
+		}
+	}
+}
+
+func TestIndexedArray_Stale(t *testing.T) {
+	content := `[{"n": 1}, {"n": 2}, {"n": 3}]`
+	path := writeArrayFile(t, content)
+	if err := BuildArrayIndex(path, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// same size, other bytes, same modification time
+	info, _ := os.Stat(path)
+	if err := os.WriteFile(path, []byte(strings.Replace(content, "2", "7", 1)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenIndexedArray(path, ""); !errors.Is(err, ErrStaleIndex) {
+		t.Errorf("FAIL: expected ErrStaleIndex for changed bytes but got %v", err)
+	}
+
+	if err := BuildArrayIndex(path, ""); err != nil {
+		t.Fatal(err)
+	}
+	a, err := OpenIndexedArray(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if err := os.Chtimes(path, time.Now(), info.ModTime().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Check(); !errors.Is(err, ErrStaleIndex) {
+		t.Errorf("FAIL: expected ErrStaleIndex for a touched file but got %v", err)
+	}
+
+	if err := os.WriteFile(path+".idx", []byte("JSONAIX1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenIndexedArray(path, ""); err == nil || errors.Is(err, ErrStaleIndex) {
+		t.Errorf("FAIL: expected an invalid index error but got %v", err)
+	}
+}
@@ -2,6 +2,8 @@ package json
 
 import (
 	"encoding/json"
+	"path/filepath"
+	"sync"
 	"testing"
 )
 
@@ -76,3 +78,120 @@ func BenchmarkJSONModuleMany(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkParseJsonParallel(b *testing.B) {
+	body := readLocalFile("large-file.json")
+	b.ResetTimer()
+
+	_, err := ParseJsonParallel(body, 0)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func BenchmarkBuildIndex(b *testing.B) {
+	body := readLocalFile("large-file.json")
+	b.ResetTimer()
+
+	idx, err := BuildIndex(body)
+	if err != nil {
+		panic(err)
+	}
+	if _, err := idx.Parse(); err != nil {
+		panic(err)
+	}
+}
+
+func BenchmarkParseFile(b *testing.B) {
+	f, err := ParseFile(filepath.Join("tests", "large-file.json"))
+	if err != nil {
+		panic(err)
+	}
+	if err := f.Close(); err != nil {
+		panic(err)
+	}
+}
+
+// The Parser benchmarks parse the same small document over and over, like a
+// service handling requests. ParseJson is the baseline.
+//
+// BenchmarkParseJson_Requests-8   	   23779	     44187 ns/op	   22248 B/op	     272 allocs/op
+func BenchmarkParseJson_Requests(b *testing.B) {
+	body := readLocalFile("something.json")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseJson(body); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParser_Requests-8   	   49315	     23929 ns/op	    4800 B/op	      56 allocs/op
+func BenchmarkParser_Requests(b *testing.B) {
+	body := readLocalFile("something.json")
+	p := NewParser()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.ParseJson(body); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkIndexParse_Requests-8   	   70501	     17044 ns/op	   10672 B/op	      66 allocs/op
+func BenchmarkIndexParse_Requests(b *testing.B) {
+	body := readLocalFile("something.json")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx, err := BuildIndex(body)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := idx.Parse(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParseDocument_Requests-8   	   66374	     19584 ns/op	   12336 B/op	      22 allocs/op
+func BenchmarkParseDocument_Requests(b *testing.B) {
+	body := readLocalFile("something.json")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseDocument(body); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParser_RequestsInternKeys(b *testing.B) {
+	body := readLocalFile("something.json")
+	p := &Parser{InternKeys: true}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.ParseJson(body); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParser_RequestsPool(b *testing.B) {
+	body := readLocalFile("something.json")
+	pool := sync.Pool{New: func() any { return &Parser{InternKeys: true} }}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			p := pool.Get().(*Parser)
+			if _, err := p.ParseJson(body); err != nil {
+				b.Error(err)
+			}
+			pool.Put(p)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package json
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Marshaler is implemented by types that encode themselves as JSON.
+type Marshaler interface {
+	MarshalJSON() ([]byte, error)
+}
+
+// Unmarshaler is implemented by types that decode themselves from JSON. data
+// is a single valid JSON value and must be copied to be kept.
+type Unmarshaler interface {
+	UnmarshalJSON(data []byte) error
+}
+
+var (
+	marshalerType       = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	unmarshalerType     = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+type codec struct {
+	encode func(rv reflect.Value) (any, error)
+	decode func(value any, rv reflect.Value) error
+}
+
+var codecs sync.Map
+
+// RegisterCodec makes Marshal and Unmarshal convert values of type T with
+// encode and decode, for types whose JSON form cannot be changed through
+// methods. encode returns any value Marshal accepts, and decode receives the
+// value as ParseJson would return it. Codecs take precedence over the
+// Marshaler, Unmarshaler and encoding.Text* methods of T. Registering a type
+// again replaces its codec.
+func RegisterCodec[T any](encode func(T) (any, error), decode func(any) (T, error)) {
+	codecs.Store(reflect.TypeOf((*T)(nil)).Elem(), codec{
+		encode: func(rv reflect.Value) (any, error) {
+			return encode(rv.Interface().(T))
+		},
+		decode: func(value any, rv reflect.Value) error {
+			decoded, err := decode(value)
+			if err != nil {
+				return err
+			}
+			rv.Set(reflect.ValueOf(&decoded).Elem())
+			return nil
+		},
+	})
+}
+
+// UnregisterCodec removes the codec of T.
+func UnregisterCodec[T any]() {
+	codecs.Delete(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+func lookupCodec(t reflect.Type) (codec, bool) {
+	c, ok := codecs.Load(t)
+	if !ok {
+		return codec{}, false
+	}
+	return c.(codec), true
+}
+
+// EncodeDuration and DecodeDuration are a codec for time.Duration using its
+// string form, such as "1h30m":
+//
+//	json.RegisterCodec(json.EncodeDuration, json.DecodeDuration)
+//
+// DecodeDuration also accepts a number of nanoseconds.
+func EncodeDuration(d time.Duration) (any, error) {
+	return d.String(), nil
+}
+
+func DecodeDuration(value any) (time.Duration, error) {
+	switch value := value.(type) {
+	case string:
+		return time.ParseDuration(value)
+	case float64:
+		return time.Duration(value), nil
+	}
+	return 0, fmt.Errorf("cannot convert type %T to time.Duration", value)
+}
+
+// withMethod returns rv, or its address when only the pointer type implements
+// iface.
+func withMethod(rv reflect.Value, iface reflect.Type) (reflect.Value, bool) {
+	if rv.Type().Implements(iface) {
+		return rv, true
+	}
+	if rv.Kind() != reflect.Pointer && rv.CanAddr() && rv.Addr().Type().Implements(iface) {
+		return rv.Addr(), true
+	}
+	return reflect.Value{}, false
+}
@@ -0,0 +1,105 @@
+package json
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// contextCheckInterval is the number of tokens between two checks of the
+// context, and two progress reports.
+const contextCheckInterval = 4096
+
+// contextScanner wraps a scanner to stop at the first token after the context
+// is done. The scanner works on bytes, so its position is the progress.
+type contextScanner struct {
+	*scanner
+	ctx      context.Context
+	progress func(consumed, total int64)
+	total    int64
+	tokens   int
+	err      error
+	// replaced holds the offsets in the scanned text of the U+FFFD written
+	// for each invalid byte of the input, in order.
+	replaced []int
+}
+
+func (s *contextScanner) NextToken() Token {
+	if s.err != nil {
+		return s.current()
+	}
+	s.tokens++
+	if s.tokens%contextCheckInterval == 0 {
+		if s.err = s.ctx.Err(); s.err != nil {
+			s.currentToken = NewToken(TokenKindInvalid, s.err.Error())
+			return s.currentToken
+		}
+		if s.progress != nil {
+			s.progress(min(s.consumed(), s.total), s.total)
+		}
+	}
+	return s.scanner.NextToken()
+}
+
+// consumed returns the position of the scanner in the input: every U+FFFD
+// before it is three bytes long but stands for a single invalid byte.
+func (s *contextScanner) consumed() int64 {
+	return int64(s.pos - 2*sort.SearchInts(s.replaced, s.pos))
+}
+
+// replaceInvalidUTF8 replaces every invalid byte of json with U+FFFD, like
+// string([]rune(json)), and returns where the replacements are.
+func replaceInvalidUTF8(json string) (string, []int) {
+	var sb strings.Builder
+	sb.Grow(len(json))
+	var replaced []int
+	for i := 0; i < len(json); {
+		r, size := utf8.DecodeRuneInString(json[i:])
+		if r == utf8.RuneError && size == 1 {
+			replaced = append(replaced, sb.Len())
+			sb.WriteRune(utf8.RuneError)
+		} else {
+			sb.WriteString(json[i : i+size])
+		}
+		i += size
+	}
+	return sb.String(), replaced
+}
+
+// ParseContext is ParseJson for inputs large enough to need cancellation or
+// progress. It checks ctx every few thousand tokens and returns ctx.Err() once
+// it is done. progress, when not nil, is called with the bytes of json
+// consumed so far and len(json): first with 0, then as the parsing goes, and
+// last with len(json) when it succeeds. ProgressBar.Set fits it.
+func ParseContext(ctx context.Context, json string, progress func(consumed, total int64)) (any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	total := int64(len(json))
+	if progress != nil {
+		progress(0, total)
+	}
+	var replaced []int
+	if !utf8.ValidString(json) {
+		// like ParseJson, replace every invalid byte with U+FFFD, the
+		// progress is still reported in bytes of the input
+		json, replaced = replaceInvalidUTF8(json)
+	}
+	lexer := &contextScanner{scanner: newScanner(json), ctx: ctx, progress: progress, total: total, replaced: replaced}
+	parser := newJsonParser(lexer)
+	result, err := parser.parseValue()
+	if err == nil && parser.lexer.NextToken().Kind != TokenKindEOF {
+		err = parser.invalidTokenError()
+	}
+	if lexer.err != nil {
+		return nil, lexer.err
+	}
+	if err != nil {
+		return nil, err
+	}
+	if progress != nil {
+		progress(total, total)
+	}
+	return result, nil
+}
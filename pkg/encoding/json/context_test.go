@@ -0,0 +1,117 @@
+package json
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/progressbar"
+)
+
+func largeArray(n int) string {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(`{"name": "żółw", "n": 1}`)
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+func TestParseContext(t *testing.T) {
+	input := largeArray(5000)
+	type report struct{ consumed, total int64 }
+	reports := make([]report, 0)
+	value, err := ParseContext(context.Background(), input, func(consumed, total int64) {
+		reports = append(reports, report{consumed, total})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := ParseJson(input)
+	if !isEqual(value, expected) {
+		t.Errorf("FAIL: expected the same value as ParseJson")
+	}
+
+	total := int64(len(input))
+	if len(reports) < 3 || reports[0] != (report{0, total}) || reports[len(reports)-1] != (report{total, total}) {
+		t.Fatalf("FAIL: got %v", reports)
+	}
+	for i := 1; i < len(reports); i++ {
+		if reports[i].consumed <= reports[i-1].consumed || reports[i].total != total {
+			t.Errorf("FAIL: expected growing progress but got %v then %v", reports[i-1], reports[i])
+		}
+	}
+
+	invalid := "[\"a\xff\xfeb\", 1]"
+	value, err = ParseContext(context.Background(), invalid, nil)
+	expected, _ = ParseJson(invalid)
+	if err != nil || !isEqual(value, expected) {
+		t.Errorf("FAIL: invalid UTF-8: expected %v but got %v %v", expected, value, err)
+	}
+
+	// the progress counts bytes of the input, not of the repaired text: the
+	// same document with seven invalid bytes for "żółw" reports the same
+	invalid = strings.ReplaceAll(input, "żółw", "\xff\xfe\xfd\xfc\xfb\xfa\xf9")
+	valid := reports
+	reports = make([]report, 0)
+	if _, err := ParseContext(context.Background(), invalid, func(consumed, total int64) {
+		reports = append(reports, report{consumed, total})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != len(valid) {
+		t.Fatalf("FAIL: expected %v but got %v", valid, reports)
+	}
+	for i := range reports {
+		if reports[i] != valid[i] {
+			t.Errorf("FAIL: expected %v but got %v", valid[i], reports[i])
+		}
+	}
+
+	for _, input := range conformanceReject {
+		if _, err := ParseContext(context.Background(), input, nil); err == nil {
+			t.Errorf("FAIL: expected an error for %q", input)
+		}
+	}
+}
+
+func TestParseContext_Cancel(t *testing.T) {
+	input := largeArray(5000)
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	_, err := ParseContext(ctx, input, func(consumed, total int64) {
+		calls++
+		if consumed > total/2 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("FAIL: expected context.Canceled but got %v", err)
+	}
+	if calls < 2 {
+		t.Errorf("FAIL: expected progress before the cancellation but got %d calls", calls)
+	}
+
+	if _, err := ParseContext(ctx, `{}`, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("FAIL: expected context.Canceled for a done context but got %v", err)
+	}
+}
+
+func TestParseContext_ProgressBar(t *testing.T) {
+	var out bytes.Buffer
+	bar := progressbar.NewProgressBar(1)
+	bar.Writer = &out
+	bar.Label = "parsing"
+	if _, err := ParseContext(context.Background(), largeArray(1000), bar.Set); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "parsing: 100%\t DONE!") {
+		t.Errorf("FAIL: expected a finished bar but got %q", out.String())
+	}
+}
@@ -0,0 +1,253 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// UTF8Mode selects how bytes that are not valid UTF-8 are handled.
+type UTF8Mode int8
+
+const (
+	// UTF8Replace decodes every invalid byte to U+FFFD, like ParseJson.
+	UTF8Replace UTF8Mode = iota
+	// UTF8Reject fails with the byte offset of the first invalid sequence.
+	UTF8Reject
+	// UTF8PassThrough keeps invalid bytes in strings unchanged.
+	UTF8PassThrough
+)
+
+// Encoding is a Unicode encoding form allowed by RFC 8259 §8.1 for JSON
+// exchanged outside a closed ecosystem.
+type Encoding int8
+
+const (
+	EncodingUTF8 Encoding = iota
+	EncodingUTF16BE
+	EncodingUTF16LE
+	EncodingUTF32BE
+	EncodingUTF32LE
+)
+
+func (e Encoding) String() string {
+	return [...]string{"UTF-8", "UTF-16BE", "UTF-16LE", "UTF-32BE", "UTF-32LE"}[e]
+}
+
+const utf8BOM = "\xef\xbb\xbf"
+
+// ParseOptions configures Parse. The zero value behaves like ParseJson.
+type ParseOptions struct {
+	UTF8 UTF8Mode
+	// SkipBOM ignores a leading UTF-8 byte order mark.
+	SkipBOM bool
+	// DetectEncoding recognizes UTF-16 and UTF-32 input, with or without a
+	// byte order mark, and transcodes it to UTF-8 before parsing.
+	DetectEncoding bool
+}
+
+// Parse parses json according to the options. The Offset of a *SyntaxError
+// points into json, before the byte order mark is skipped or the text is
+// transcoded; Line and Column locate the error in the UTF-8 text, without the
+// byte order mark.
+func (o ParseOptions) Parse(json string) (any, error) {
+	input, enc, bom := json, EncodingUTF8, 0
+	if o.DetectEncoding {
+		enc, bom = DetectEncoding(json)
+		if enc != EncodingUTF8 {
+			decoded, err := o.transcode(json[bom:], enc, bom)
+			if err != nil {
+				return nil, err
+			}
+			json = decoded
+		} else {
+			bom = 0
+		}
+	}
+	skipped := 0
+	if o.SkipBOM && strings.HasPrefix(json, utf8BOM) {
+		json, skipped = json[len(utf8BOM):], len(utf8BOM)
+	}
+
+	var value any
+	var err error
+	switch o.UTF8 {
+	case UTF8Reject:
+		// transcoded text is valid UTF-8, invalid code units are reported by transcode
+		if offset := invalidUTF8(json); offset >= 0 {
+			msg := fmt.Sprintf("invalid UTF-8 at position %d", skipped+offset)
+			err = newSyntaxError(json, offset, Token{Kind: TokenKindInvalid, Value: msg}, msg)
+			break
+		}
+		value, err = parseInPlace(json)
+	case UTF8PassThrough:
+		value, err = parseInPlace(json)
+	default:
+		value, err = ParseJson(json)
+	}
+
+	if e := (*SyntaxError)(nil); errors.As(err, &e) {
+		e.Offset += skipped
+		if enc != EncodingUTF8 {
+			e.Offset = bom + sourceOffset(input[bom:], enc, e.Offset)
+		}
+	}
+	return value, err
+}
+
+// DetectEncoding guesses the encoding of json from its byte order mark or,
+// without one, from the pattern of zero bytes: the first two characters of a
+// JSON text are ASCII, so their position among the first four bytes gives the
+// encoding away. It returns the length of the byte order mark, if any.
+func DetectEncoding(json string) (enc Encoding, bom int) {
+	switch {
+	case strings.HasPrefix(json, "\x00\x00\xfe\xff"):
+		return EncodingUTF32BE, 4
+	case strings.HasPrefix(json, "\xff\xfe\x00\x00"):
+		return EncodingUTF32LE, 4
+	case strings.HasPrefix(json, "\xfe\xff"):
+		return EncodingUTF16BE, 2
+	case strings.HasPrefix(json, "\xff\xfe"):
+		return EncodingUTF16LE, 2
+	case strings.HasPrefix(json, utf8BOM):
+		return EncodingUTF8, 3
+	}
+
+	if len(json) >= 4 {
+		switch {
+		case json[0] == 0 && json[1] == 0 && json[2] == 0 && json[3] != 0:
+			return EncodingUTF32BE, 0
+		case json[0] != 0 && json[1] == 0 && json[2] == 0 && json[3] == 0:
+			return EncodingUTF32LE, 0
+		}
+	}
+	if len(json) >= 2 {
+		switch {
+		case json[0] == 0 && json[1] != 0:
+			return EncodingUTF16BE, 0
+		case json[0] != 0 && json[1] == 0:
+			return EncodingUTF16LE, 0
+		}
+	}
+	return EncodingUTF8, 0
+}
+
+// transcode converts s from enc to UTF-8. Invalid code units are replaced
+// with U+FFFD unless o rejects invalid input; offset is added to the reported
+// positions so they point into the original input.
+func (o ParseOptions) transcode(s string, enc Encoding, offset int) (string, error) {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	var err error
+	decodeUnits(s, enc, func(pos int, r rune, ok bool) bool {
+		if !ok && o.UTF8 == UTF8Reject {
+			msg := fmt.Sprintf("invalid %s at position %d", enc, offset+pos)
+			e := newSyntaxError(sb.String(), sb.Len(), Token{Kind: TokenKindInvalid, Value: msg}, msg)
+			e.Offset = offset + pos
+			err = e
+			return false
+		}
+		sb.WriteRune(r)
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// sourceOffset maps an offset in the UTF-8 text transcoded from s back to the
+// offset of the code unit it came from in s.
+func sourceOffset(s string, enc Encoding, offset int) int {
+	source, n := len(s), 0
+	decodeUnits(s, enc, func(pos int, r rune, _ bool) bool {
+		if n >= offset {
+			source = pos
+			return false
+		}
+		n += utf8.RuneLen(r)
+		return true
+	})
+	return source
+}
+
+// decodeUnits calls yield with the offset and the rune of every character of
+// s in enc, until yield returns false. Invalid code units are passed as
+// U+FFFD with ok false.
+func decodeUnits(s string, enc Encoding, yield func(pos int, r rune, ok bool) bool) {
+	switch enc {
+	case EncodingUTF16BE, EncodingUTF16LE:
+		unit := func(i int) rune {
+			if enc == EncodingUTF16BE {
+				return rune(s[i])<<8 | rune(s[i+1])
+			}
+			return rune(s[i+1])<<8 | rune(s[i])
+		}
+		i := 0
+		for ; i+1 < len(s); i += 2 {
+			r := unit(i)
+			if utf16.IsSurrogate(r) {
+				if i+3 < len(s) {
+					if decoded := utf16.DecodeRune(r, unit(i+2)); decoded != utf8.RuneError {
+						if !yield(i, decoded, true) {
+							return
+						}
+						i += 2
+						continue
+					}
+				}
+				if !yield(i, utf8.RuneError, false) {
+					return
+				}
+				continue
+			}
+			if !yield(i, r, true) {
+				return
+			}
+		}
+		if i < len(s) {
+			yield(i, utf8.RuneError, false)
+		}
+	case EncodingUTF32BE, EncodingUTF32LE:
+		i := 0
+		for ; i+3 < len(s); i += 4 {
+			var r rune
+			if enc == EncodingUTF32BE {
+				r = rune(s[i])<<24 | rune(s[i+1])<<16 | rune(s[i+2])<<8 | rune(s[i+3])
+			} else {
+				r = rune(s[i+3])<<24 | rune(s[i+2])<<16 | rune(s[i+1])<<8 | rune(s[i])
+			}
+			if !utf8.ValidRune(r) {
+				if !yield(i, utf8.RuneError, false) {
+					return
+				}
+				continue
+			}
+			if !yield(i, r, true) {
+				return
+			}
+		}
+		if i < len(s) {
+			yield(i, utf8.RuneError, false)
+		}
+	}
+}
+
+// invalidUTF8 returns the offset of the first invalid UTF-8 sequence in s, or
+// -1 if s is valid.
+func invalidUTF8(s string) int {
+	for i := 0; i < len(s); {
+		if s[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
@@ -0,0 +1,164 @@
+package json
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+	"unicode/utf16"
+)
+
+func encodeUTF16(s string, order binary.ByteOrder) string {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(units))
+	for i, u := range units {
+		order.PutUint16(b[2*i:], u)
+	}
+	return string(b)
+}
+
+func encodeUTF32(s string, order binary.ByteOrder) string {
+	runes := []rune(s)
+	b := make([]byte, 4*len(runes))
+	for i, r := range runes {
+		order.PutUint32(b[4*i:], uint32(r))
+	}
+	return string(b)
+}
+
+func TestParseOptions_DetectEncoding(t *testing.T) {
+	const bom = "\uFEFF"
+	cases := []struct {
+		Doc    string
+		Encode func(string) string
+		Enc    Encoding
+		BOM    int
+	}{
+		{`{"é": ["😀", 1]}`, func(s string) string { return s }, EncodingUTF8, 0},
+		{"1", func(s string) string { return s }, EncodingUTF8, 0},
+		{`{"é": ["😀", 1]}`, func(s string) string { return bom + s }, EncodingUTF8, 3},
+		{`{"é": ["😀", 1]}`, func(s string) string { return encodeUTF16(s, binary.BigEndian) }, EncodingUTF16BE, 0},
+		{`{"é": ["😀", 1]}`, func(s string) string { return encodeUTF16(s, binary.LittleEndian) }, EncodingUTF16LE, 0},
+		{`{"é": ["😀", 1]}`, func(s string) string { return encodeUTF16(bom+s, binary.BigEndian) }, EncodingUTF16BE, 2},
+		{`{"é": ["😀", 1]}`, func(s string) string { return encodeUTF16(bom+s, binary.LittleEndian) }, EncodingUTF16LE, 2},
+		{"1", func(s string) string { return encodeUTF16(s, binary.BigEndian) }, EncodingUTF16BE, 0},
+		{"1", func(s string) string { return encodeUTF16(s, binary.LittleEndian) }, EncodingUTF16LE, 0},
+		{`{"é": ["😀", 1]}`, func(s string) string { return encodeUTF32(s, binary.BigEndian) }, EncodingUTF32BE, 0},
+		{`{"é": ["😀", 1]}`, func(s string) string { return encodeUTF32(s, binary.LittleEndian) }, EncodingUTF32LE, 0},
+		{`{"é": ["😀", 1]}`, func(s string) string { return encodeUTF32(bom+s, binary.BigEndian) }, EncodingUTF32BE, 4},
+		{`{"é": ["😀", 1]}`, func(s string) string { return encodeUTF32(bom+s, binary.LittleEndian) }, EncodingUTF32LE, 4},
+	}
+	for _, testCase := range cases {
+		in := testCase.Encode(testCase.Doc)
+		enc, n := DetectEncoding(in)
+		if enc != testCase.Enc || n != testCase.BOM {
+			t.Errorf("FAIL: %q expected %s/%d but got %s/%d", in, testCase.Enc, testCase.BOM, enc, n)
+			continue
+		}
+
+		expected, _ := ParseJson(testCase.Doc)
+		for _, mode := range []UTF8Mode{UTF8Replace, UTF8Reject, UTF8PassThrough} {
+			value, err := ParseOptions{UTF8: mode, SkipBOM: true, DetectEncoding: true}.Parse(in)
+			if err != nil || !isEqual(expected, value) {
+				t.Errorf("FAIL: %q mode %d expected %#v but got %#v (%v)", in, mode, expected, value, err)
+			}
+		}
+	}
+}
+
+func TestParseOptions_InvalidUTF16(t *testing.T) {
+	// ["<lone high surrogate>"]
+	in := "\x00[\x00\"\xd8\x00\x00\"\x00]"
+	value, err := ParseOptions{DetectEncoding: true}.Parse(in)
+	if expected := []any{"�"}; err != nil || !isEqual(expected, value) {
+		t.Errorf("FAIL: expected %#v but got %#v (%v)", expected, value, err)
+	}
+
+	_, err = ParseOptions{UTF8: UTF8Reject, DetectEncoding: true}.Parse(in)
+	if err == nil || err.Error() != "invalid UTF-16BE at position 4" {
+		t.Errorf("FAIL: expected a positioned error but got %v", err)
+	}
+	if _, err := (ParseOptions{UTF8: UTF8Reject, DetectEncoding: true}).Parse(in + "\x00"); err == nil {
+		t.Errorf("FAIL: expected an error for a truncated code unit")
+	}
+}
+
+func TestParseOptions_Offsets(t *testing.T) {
+	cases := []struct {
+		Options ParseOptions
+		In      string
+		Offset  int
+		Line    int
+		Column  int
+	}{
+		{ParseOptions{UTF8: UTF8Reject, SkipBOM: true}, utf8BOM + "[\"\xff\"]", 5, 1, 3},
+		{ParseOptions{UTF8: UTF8Reject, SkipBOM: true, DetectEncoding: true}, utf8BOM + "[\"\xff\"]", 5, 1, 3},
+		{ParseOptions{SkipBOM: true}, utf8BOM + "[1,\n]", 7, 2, 1},
+		{ParseOptions{UTF8: UTF8PassThrough, SkipBOM: true}, utf8BOM + "[1,]", 6, 1, 4},
+		{ParseOptions{DetectEncoding: true}, encodeUTF16("\uFEFF[\"é\",]", binary.LittleEndian), 12, 1, 6},
+		{ParseOptions{DetectEncoding: true}, encodeUTF16("[\"😀\",\n]", binary.BigEndian), 14, 2, 1},
+		{ParseOptions{UTF8: UTF8Reject, DetectEncoding: true}, encodeUTF32("[\"é\",]", binary.BigEndian), 20, 1, 6},
+		{ParseOptions{UTF8: UTF8Reject, DetectEncoding: true}, "\x00[\x00\"\xd8\x00\x00\"\x00]", 4, 1, 3},
+	}
+	for _, testCase := range cases {
+		_, err := testCase.Options.Parse(testCase.In)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("FAIL: %q expected a *SyntaxError but got %v", testCase.In, err)
+			continue
+		}
+		if syntaxErr.Offset != testCase.Offset || syntaxErr.Line != testCase.Line || syntaxErr.Column != testCase.Column {
+			t.Errorf("FAIL: %q expected %d at %d:%d but got %d at %d:%d (%v)", testCase.In,
+				testCase.Offset, testCase.Line, testCase.Column, syntaxErr.Offset, syntaxErr.Line, syntaxErr.Column, err)
+		}
+	}
+
+	_, err := ParseOptions{UTF8: UTF8Reject, SkipBOM: true}.Parse(utf8BOM + "[\"\xff\"]")
+	if expected := "invalid UTF-8 at position 5"; err == nil || err.Error() != expected {
+		t.Errorf("FAIL: expected %q but got %v", expected, err)
+	}
+}
+
+func TestParseOptions_UTF8(t *testing.T) {
+	in := "[\"ok\xfa\", \"\xe6\x97\xa5\"]"
+	cases := []struct {
+		Mode     UTF8Mode
+		Expected any
+		Err      string
+	}{
+		{UTF8Replace, []any{"ok�", "日"}, ""},
+		{UTF8PassThrough, []any{"ok\xfa", "日"}, ""},
+		{UTF8Reject, nil, "invalid UTF-8 at position 4"},
+	}
+	for _, testCase := range cases {
+		value, err := ParseOptions{UTF8: testCase.Mode}.Parse(in)
+		if testCase.Err != "" {
+			if err == nil || err.Error() != testCase.Err {
+				t.Errorf("FAIL: mode %d expected error %q but got %v", testCase.Mode, testCase.Err, err)
+			}
+			continue
+		}
+		if err != nil || !isEqual(testCase.Expected, value) {
+			t.Errorf("FAIL: mode %d expected %#v but got %#v (%v)", testCase.Mode, testCase.Expected, value, err)
+		}
+	}
+}
+
+func TestParseOptions_BOM(t *testing.T) {
+	in := conformanceImplementationDefined["i_structure_UTF-8_BOM_empty_object"].In
+	if _, err := (ParseOptions{}).Parse(in); err == nil {
+		t.Errorf("FAIL: expected a BOM to be rejected by default")
+	}
+	value, err := ParseOptions{SkipBOM: true}.Parse(in)
+	if err != nil || !isEqual(map[string]any{}, value) {
+		t.Errorf("FAIL: expected an empty object but got %#v (%v)", value, err)
+	}
+	if _, err := (ParseOptions{SkipBOM: true}).Parse(utf8BOM + utf8BOM + "{}"); err == nil {
+		t.Errorf("FAIL: expected only one BOM to be skipped")
+	}
+
+	in = conformanceImplementationDefined["i_string_UTF-16LE_with_BOM"].In
+	value, err = ParseOptions{DetectEncoding: true}.Parse(in)
+	if expected := []any{"é"}; err != nil || !isEqual(expected, value) {
+		t.Errorf("FAIL: expected %#v but got %#v (%v)", expected, value, err)
+	}
+}
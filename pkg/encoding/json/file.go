@@ -0,0 +1,203 @@
+package json
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unsafe"
+)
+
+// File is a document parsed by ParseFile. On Linux the file is memory-mapped
+// and lexed in place: strings without escapes, object keys included, point
+// into the mapping and are only copied when an escape has to be decoded. The
+// value must not be used after Close; use strings.Clone to keep a string.
+type File struct {
+	data   []byte
+	value  any
+	unmap  func([]byte) error
+	closed bool
+}
+
+// mmapFile maps size bytes of f read-only. It is a variable so tests can force
+// the fallback.
+var mmapFile = mmap
+
+// ParseFile parses the JSON document stored at path. If the file cannot be
+// mapped (empty files, pipes, special filesystems or platforms without mmap)
+// it is read into memory instead.
+func ParseFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &File{}
+	if data, err := mmapFile(f, info.Size()); err == nil {
+		doc.data, doc.unmap = data, munmap
+	} else if doc.data, err = io.ReadAll(f); err != nil {
+		return nil, err
+	}
+
+	doc.value, err = parseInPlace(unsafe.String(unsafe.SliceData(doc.data), len(doc.data)))
+	if err != nil {
+		doc.Close()
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Value returns the parsed document.
+func (f *File) Value() any {
+	return f.value
+}
+
+// Mapped reports whether the document is backed by a memory mapping.
+func (f *File) Mapped() bool {
+	return f.unmap != nil
+}
+
+// Close releases the mapping. It is safe to call more than once.
+func (f *File) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	f.value = nil
+	data := f.data
+	f.data = nil
+	if f.unmap != nil && data != nil {
+		return f.unmap(data)
+	}
+	return nil
+}
+
+func parseInPlace(json string) (any, error) {
+	parser := newJsonParser(newScanner(json))
+	result, err := parser.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if parser.lexer.NextToken().Kind != TokenKindEOF {
+		return nil, parser.invalidTokenError()
+	}
+	return result, nil
+}
+
+// scanner is a byte oriented lexer. Unlike Lexer it does not convert the input
+// to runes, so every token value is a substring of the input.
+type scanner struct {
+	input        string
+	pos          int
+	currentToken Token
+	// start is the offset of the current token.
+	start int
+}
+
+func newScanner(input string) *scanner {
+	return &scanner{input: input}
+}
+
+func (s *scanner) NextToken() Token {
+	s.currentToken = s.scan()
+	return s.currentToken
+}
+
+func (s *scanner) current() Token {
+	return s.currentToken
+}
+
+func (s *scanner) location() (string, int) {
+	return s.input, s.start
+}
+
+func (s *scanner) scan() Token {
+	for s.pos < len(s.input) && isWhitespace(rune(s.input[s.pos])) {
+		s.pos++
+	}
+	s.start = s.pos
+	if s.pos >= len(s.input) {
+		return NewToken(TokenKindEOF, "EOF")
+	}
+
+	start := s.pos
+	switch c := s.input[start]; {
+	case isObjectStart(rune(c)):
+		return s.single(TokenKindBraceOpen)
+	case isObjectEnd(rune(c)):
+		return s.single(TokenKindBraceClose)
+	case isArrayStart(rune(c)):
+		return s.single(TokenKindBracketOpen)
+	case isArrayEnd(rune(c)):
+		return s.single(TokenKindBracketClose)
+	case isColon(rune(c)):
+		return s.single(TokenKindColon)
+	case isComma(rune(c)):
+		return s.single(TokenKindComma)
+	case isQuote(rune(c)):
+		end, err := s.stringEnd(start)
+		if err != nil {
+			return NewToken(TokenKindInvalid, err.Error())
+		}
+		s.pos = end
+		value := s.input[start:end]
+		if !isValidString(value) {
+			return NewToken(TokenKindInvalid, fmt.Sprintf("invalid string at position %d", start))
+		}
+		return NewToken(TokenKindString, value)
+	}
+
+	for s.pos < len(s.input) && !isDelimiter(s.input[s.pos]) {
+		s.pos++
+	}
+	value := s.input[start:s.pos]
+	switch {
+	case value == "true" || value == "false":
+		return NewToken(TokenKindBoolean, value)
+	case value == "null":
+		return NewToken(TokenKindNull, value)
+	case isValidNumber(value):
+		return NewToken(TokenKindNumber, value)
+	default:
+		return NewToken(TokenKindInvalid, fmt.Sprintf("Invalid token `%s` at position %d", value, start))
+	}
+}
+
+func (s *scanner) single(kind TokenKind) Token {
+	s.pos++
+	return NewToken(kind, s.input[s.pos-1:s.pos])
+}
+
+// stringEnd returns the offset just past the quote closing the string that
+// starts at start.
+func (s *scanner) stringEnd(start int) (int, error) {
+	for i := start + 1; ; {
+		j := strings.IndexByte(s.input[i:], '"')
+		if j < 0 {
+			return 0, fmt.Errorf("unterminated string at position %d", start)
+		}
+		i += j
+		backslashes := 0
+		for k := i - 1; k > start && s.input[k] == '\\'; k-- {
+			backslashes++
+		}
+		i++
+		if backslashes%2 == 0 {
+			return i, nil
+		}
+	}
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '{', '}', '[', ']', ':', ',', '"', ' ', '\t', '\n', '\r':
+		return true
+	}
+	return false
+}
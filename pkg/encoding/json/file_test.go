@@ -0,0 +1,104 @@
+package json
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+)
+
+func writeTempFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "doc.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseFile(t *testing.T) {
+	path := writeTempFile(t, ` {"plain": "value", "escaped": "a\nb", "list": [1, -2.5e3, true, null]} `)
+	f, err := ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	expected := map[string]any{"plain": "value", "escaped": "a\nb", "list": []any{1.0, -2500.0, true, nil}}
+	if !isEqual(expected, f.Value()) {
+		t.Errorf("FAIL: expected %#v but got %#v", expected, f.Value())
+	}
+	if !f.Mapped() {
+		t.Skip("mmap is not available")
+	}
+
+	inMapping := func(s string) bool {
+		p := uintptr(unsafe.Pointer(unsafe.StringData(s)))
+		start := uintptr(unsafe.Pointer(unsafe.SliceData(f.data)))
+		return start <= p && p < start+uintptr(len(f.data))
+	}
+	obj := f.Value().(map[string]any)
+	if !inMapping(obj["plain"].(string)) {
+		t.Errorf("FAIL: expected a string without escapes to point into the mapping")
+	}
+	if inMapping(obj["escaped"].(string)) {
+		t.Errorf("FAIL: expected a string with escapes to be copied")
+	}
+	if err := f.Close(); err != nil || f.Value() != nil {
+		t.Errorf("FAIL: expected Close to release the document (%v)", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Errorf("FAIL: expected a second Close to be a no-op, got %v", err)
+	}
+}
+
+func TestParseFile_Fallback(t *testing.T) {
+	defer func(original func(*os.File, int64) ([]byte, error)) { mmapFile = original }(mmapFile)
+	mmapFile = func(*os.File, int64) ([]byte, error) {
+		return nil, errors.New("mmap failed")
+	}
+
+	f, err := ParseFile(writeTempFile(t, `["a", {"b": 1}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if f.Mapped() {
+		t.Errorf("FAIL: expected the file to be read into memory")
+	}
+	if expected := []any{"a", map[string]any{"b": 1.0}}; !isEqual(expected, f.Value()) {
+		t.Errorf("FAIL: expected %#v but got %#v", expected, f.Value())
+	}
+}
+
+func TestParseFile_Errors(t *testing.T) {
+	if _, err := ParseFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("FAIL: expected an error for a missing file")
+	}
+	for _, content := range []string{"", "[1,]", `{"a" 1}`, `"unterminated`, "[1] [2]"} {
+		if f, err := ParseFile(writeTempFile(t, content)); err == nil {
+			t.Errorf("FAIL: %q expected an error but got %#v", content, f.Value())
+		}
+	}
+}
+
+func TestParseInPlace_Conformance(t *testing.T) {
+	for name, input := range conformanceAccept {
+		expected, _ := ParseJson(input)
+		if value, err := parseInPlace(input); err != nil || !isEqual(expected, value) {
+			t.Errorf("FAIL: %s: expected %#v but got %#v (%v)", name, expected, value, err)
+		}
+	}
+	for name, input := range conformanceReject {
+		if value, err := parseInPlace(input); err == nil {
+			t.Errorf("FAIL: %s: expected the document to be rejected, got %#v", name, value)
+		}
+	}
+	for name, testCase := range conformanceImplementationDefined {
+		_, expected := ParseJson(testCase.In)
+		if _, err := parseInPlace(testCase.In); (err == nil) != (expected == nil) {
+			t.Errorf("FAIL: %s: expected the same outcome as ParseJson, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package json
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+const (
+	swarLow  = 0x0101010101010101
+	swarHigh = 0x8080808080808080
+	swarLow7 = 0x7f7f7f7f7f7f7f7f
+)
+
+// StructuralIndex is the result of a stage 1 scan in the style of simdjson.
+// It records, in document order, the byte offset of every structural
+// character ({ } [ ] : ,) outside of strings, of every opening quote and of
+// the first byte of every other scalar. Every entry is the start of a token,
+// so the parser can lex a token by looking only at the bytes between two
+// entries, and a whole object or array can be skipped with a single jump.
+type StructuralIndex struct {
+	input     string
+	positions []uint32
+	// closing holds, for every { and [ entry, the entry of the matching bracket.
+	closing []uint32
+}
+
+// BuildIndex scans json 64 bytes at a time, using word-at-a-time (SWAR)
+// arithmetic to classify 8 bytes per operation. It only checks that strings
+// are terminated and that brackets are balanced; everything else is validated
+// when the tokens are read.
+func BuildIndex(json string) (*StructuralIndex, error) {
+	idx, err := scanStructurals(json)
+	if err != nil {
+		return nil, err
+	}
+	if err := idx.matchBrackets(); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+func scanStructurals(json string) (*StructuralIndex, error) {
+	if len(json) > math.MaxUint32 {
+		return nil, errors.New("input too large for a structural index")
+	}
+	idx := &StructuralIndex{input: json, positions: make([]uint32, 0, len(json)/4+1)}
+
+	var block [64]byte
+	var inString, escapeNext uint64
+	prevBoundary := uint64(1)
+	for offset := 0; offset < len(json); offset += 64 {
+		n := copy(block[:], json[offset:])
+		for i := n; i < 64; i++ {
+			block[i] = ' '
+		}
+
+		var quote, backslash, op, whitespace uint64
+		for w := 0; w < 8; w++ {
+			word := binary.LittleEndian.Uint64(block[w*8:])
+			shift := uint(w * 8)
+			quote |= movemask(matchByte(word, '"')) << shift
+			backslash |= movemask(matchByte(word, '\\')) << shift
+			op |= movemask(matchByte(word, '{')|matchByte(word, '}')|matchByte(word, '[')|
+				matchByte(word, ']')|matchByte(word, ':')|matchByte(word, ',')) << shift
+			whitespace |= movemask(matchByte(word, ' ')|matchByte(word, '\t')|
+				matchByte(word, '\n')|matchByte(word, '\r')) << shift
+		}
+
+		var escaped uint64
+		escaped, escapeNext = escapedBytes(backslash, escapeNext)
+		quote &^= escaped
+
+		// every bit between an opening quote and its closing quote is set
+		stringMask := prefixXor(quote) ^ inString
+		inString = uint64(int64(stringMask) >> 63)
+
+		boundary := whitespace | op | quote
+		scalar := ^(boundary | stringMask) & (boundary<<1 | prevBoundary)
+		prevBoundary = boundary >> 63
+
+		idx.appendPositions(offset, (op&^stringMask)|(quote&stringMask)|scalar)
+	}
+	if inString != 0 {
+		return nil, errors.New("EOF: unterminated string")
+	}
+	return idx, nil
+}
+
+// matchByte sets the high bit of every byte of word equal to c.
+func matchByte(word uint64, c byte) uint64 {
+	x := word ^ (swarLow * uint64(c))
+	return ^((x&swarLow7 + swarLow7) | x | swarLow7)
+}
+
+// movemask gathers the high bit of every byte into the low 8 bits.
+func movemask(mask uint64) uint64 {
+	return (mask & swarHigh >> 7) * 0x0102040810204080 >> 56
+}
+
+// prefixXor sets bit i to the parity of the bits 0..i of mask.
+func prefixXor(mask uint64) uint64 {
+	mask ^= mask << 1
+	mask ^= mask << 2
+	mask ^= mask << 4
+	mask ^= mask << 8
+	mask ^= mask << 16
+	mask ^= mask << 32
+	return mask
+}
+
+// escapedBytes returns the bytes escaped by a backslash and whether the
+// first byte of the next block is escaped.
+func escapedBytes(backslash, escapeFirst uint64) (escaped uint64, escapeNext uint64) {
+	escaped = escapeFirst
+	for backslash != 0 {
+		bit := backslash & -backslash
+		backslash &^= bit
+		if escaped&bit != 0 {
+			continue
+		}
+		if bit == 1<<63 {
+			escapeNext = 1
+		} else {
+			escaped |= bit << 1
+		}
+	}
+	return escaped, escapeNext
+}
+
+func (idx *StructuralIndex) appendPositions(offset int, mask uint64) {
+	for mask != 0 {
+		i := bits.TrailingZeros64(mask)
+		mask &= mask - 1
+		if offset+i < len(idx.input) {
+			idx.positions = append(idx.positions, uint32(offset+i))
+		}
+	}
+}
+
+func (idx *StructuralIndex) matchBrackets() error {
+	idx.closing = make([]uint32, len(idx.positions))
+	stack := make([]uint32, 0)
+	for i, pos := range idx.positions {
+		switch c := idx.input[pos]; c {
+		case '{', '[':
+			stack = append(stack, uint32(i))
+		case '}', ']':
+			if len(stack) == 0 {
+				return fmt.Errorf("unexpected `%c` at position %d", c, pos)
+			}
+			open := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if expected := idx.input[idx.positions[open]] + 2; c != expected {
+				return fmt.Errorf("unexpected `%c` at position %d, expected `%c`", c, pos, expected)
+			}
+			idx.closing[open] = uint32(i)
+		}
+	}
+	if len(stack) != 0 {
+		return errors.New("EOF: unclosed object or array")
+	}
+	return nil
+}
+
+// Len returns the number of tokens in the index.
+func (idx *StructuralIndex) Len() int {
+	return len(idx.positions)
+}
+
+// Parse builds the value of the whole document, like ParseJson.
+func (idx *StructuralIndex) Parse() (any, error) {
+	parser := newJsonParser(idx.tokens())
+	result, err := parser.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if parser.lexer.NextToken().Kind != TokenKindEOF {
+		return nil, parser.invalidTokenError()
+	}
+	return result, nil
+}
+
+// Get parses only the value found by following keys (strings for object
+// members and ints for array elements) from the root. Every sibling on the way
+// is skipped through the index without being tokenized, so the skipped parts
+// are not validated.
+func (idx *StructuralIndex) Get(keys ...any) (any, error) {
+	tokens := idx.tokens()
+	for _, key := range keys {
+		if err := tokens.seek(key); err != nil {
+			return nil, err
+		}
+	}
+	return newJsonParser(tokens).parseValue()
+}
+
+func (idx *StructuralIndex) tokens() *indexLexer {
+	return &indexLexer{idx: idx}
+}
+
+// indexLexer produces the tokens of a StructuralIndex.
+type indexLexer struct {
+	idx          *StructuralIndex
+	i            int
+	currentToken Token
+}
+
+func (l *indexLexer) NextToken() Token {
+	l.currentToken = l.token(l.i)
+	l.i++
+	return l.currentToken
+}
+
+func (l *indexLexer) current() Token {
+	return l.currentToken
+}
+
+func (l *indexLexer) location() (string, int) {
+	if l.i > 0 && l.i <= len(l.idx.positions) {
+		return l.idx.input, int(l.idx.positions[l.i-1])
+	}
+	return l.idx.input, len(l.idx.input)
+}
+
+func (l *indexLexer) token(i int) Token {
+	if i >= len(l.idx.positions) {
+		return NewToken(TokenKindEOF, "EOF")
+	}
+	start := int(l.idx.positions[i])
+	end := len(l.idx.input)
+	if i+1 < len(l.idx.positions) {
+		end = int(l.idx.positions[i+1])
+	}
+	for end > start && isWhitespace(rune(l.idx.input[end-1])) {
+		end--
+	}
+	value := l.idx.input[start:end]
+
+	switch c := value[0]; {
+	case isObjectStart(rune(c)):
+		return NewToken(TokenKindBraceOpen, value)
+	case isObjectEnd(rune(c)):
+		return NewToken(TokenKindBraceClose, value)
+	case isArrayStart(rune(c)):
+		return NewToken(TokenKindBracketOpen, value)
+	case isArrayEnd(rune(c)):
+		return NewToken(TokenKindBracketClose, value)
+	case isColon(rune(c)):
+		return NewToken(TokenKindColon, value)
+	case isComma(rune(c)):
+		return NewToken(TokenKindComma, value)
+	case isQuote(rune(c)):
+		if !isValidString(value) {
+			return NewToken(TokenKindInvalid, fmt.Sprintf("invalid string at position %d", start))
+		}
+		return NewToken(TokenKindString, value)
+	case value == "true" || value == "false":
+		return NewToken(TokenKindBoolean, value)
+	case value == "null":
+		return NewToken(TokenKindNull, value)
+	case isValidNumber(value):
+		return NewToken(TokenKindNumber, value)
+	default:
+		return NewToken(TokenKindInvalid, fmt.Sprintf("Invalid token `%s` at position %d", value, start))
+	}
+}
+
+// skipValue moves past the next value. Objects and arrays are skipped in one
+// step using the matching bracket.
+func (l *indexLexer) skipValue() {
+	if l.i < len(l.idx.positions) {
+		switch l.idx.input[l.idx.positions[l.i]] {
+		case '{', '[':
+			l.i = int(l.idx.closing[l.i])
+		}
+	}
+	l.i++
+}
+
+// seek positions the lexer on the value of key, an object member name or an
+// array index of the next value.
+func (l *indexLexer) seek(key any) error {
+	switch key := key.(type) {
+	case string:
+		if l.NextToken().Kind != TokenKindBraceOpen {
+			return fmt.Errorf("key %s not found in type: %s", key, l.currentToken.Kind.toString())
+		}
+		for l.NextToken().Kind == TokenKindString {
+			name := unquoteString(l.currentToken.Value)
+			if l.NextToken().Kind != TokenKindColon {
+				break
+			}
+			if name == key {
+				return nil
+			}
+			l.skipValue()
+			if l.NextToken().Kind != TokenKindComma {
+				break
+			}
+		}
+		if l.currentToken.Kind == TokenKindBraceClose {
+			return fmt.Errorf("key %s not found in object", key)
+		}
+		return l.unexpected()
+	case int:
+		if l.NextToken().Kind != TokenKindBracketOpen {
+			return fmt.Errorf("cannot index into type= %s", l.currentToken.Kind.toString())
+		}
+		if key < 0 {
+			return fmt.Errorf("invalid index %d: index must be non-negative", key)
+		}
+		for i := 0; i < key; i++ {
+			l.skipValue()
+			if l.NextToken().Kind != TokenKindComma {
+				return fmt.Errorf("index out of range: %d (slice length: %d)", key, i+1)
+			}
+		}
+		if l.token(l.i).Kind == TokenKindBracketClose {
+			return fmt.Errorf("index out of range: %d (slice length: 0)", key)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid type for key: %v", key)
+	}
+}
+
+// valueEnd returns the byte offset right after the value starting at entry i
+// and the entry that follows the value.
+func (idx *StructuralIndex) valueEnd(i int) (end int, next int) {
+	switch idx.input[idx.positions[i]] {
+	case '{', '[':
+		closing := int(idx.closing[i])
+		return int(idx.positions[closing]) + 1, closing + 1
+	}
+	end = len(idx.input)
+	if i+1 < len(idx.positions) {
+		end = int(idx.positions[i+1])
+	}
+	for end > int(idx.positions[i]) && isWhitespace(rune(idx.input[end-1])) {
+		end--
+	}
+	return end, i + 1
+}
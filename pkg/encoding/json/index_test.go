@@ -0,0 +1,139 @@
+package json
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// naivePositions is a byte by byte version of the stage 1 scan. Like the
+// stage 1 scan, a backslash escapes the next byte even outside of strings,
+// which only matters for invalid documents.
+func naivePositions(json string) (positions []uint32, unterminated bool) {
+	positions = make([]uint32, 0)
+	inString, escaped, boundary := false, false, true
+	for i := 0; i < len(json); i++ {
+		c := json[i]
+		quote := c == '"' && !escaped
+		escaped = c == '\\' && !escaped
+		switch {
+		case inString:
+			if quote {
+				inString = false
+				boundary = true
+			}
+		case quote:
+			inString = true
+			positions = append(positions, uint32(i))
+		case strings.IndexByte("{}[]:,", c) >= 0:
+			positions = append(positions, uint32(i))
+			boundary = true
+		case isWhitespace(rune(c)):
+			boundary = true
+		default:
+			if boundary {
+				positions = append(positions, uint32(i))
+			}
+			boundary = false
+		}
+	}
+	return positions, inString
+}
+
+func TestBuildIndex_Positions(t *testing.T) {
+	inputs := []string{
+		readLocalFile("small-file.json"),
+		readLocalFile("something.json"),
+		`[1,2,  true ,"a\"b" , {"k\\":null}]`,
+	}
+	random := rand.New(rand.NewSource(1))
+	pieces := []string{`"`, `\`, `\\`, `\"`, `a`, `1`, ` `, `{`, `}`, `[`, `]`, `:`, `,`, "\n", `é`}
+	for i := 0; i < 1000; i++ {
+		var sb strings.Builder
+		for j := random.Intn(300); j > 0; j-- {
+			sb.WriteString(pieces[random.Intn(len(pieces))])
+		}
+		inputs = append(inputs, sb.String())
+	}
+
+	for _, input := range inputs {
+		expected, unterminated := naivePositions(input)
+		idx, err := scanStructurals(input)
+		if unterminated {
+			if err == nil {
+				t.Errorf("FAIL: input %q expected an unterminated string error", input)
+			}
+			continue
+		}
+		if idx == nil {
+			t.Errorf("FAIL: input %q error: %s", input, err)
+		} else if !reflect.DeepEqual(expected, idx.positions) {
+			t.Errorf("FAIL: input %q expected positions %v but got %v", input, expected, idx.positions)
+		}
+	}
+}
+
+func TestStructuralIndex_Parse(t *testing.T) {
+	parse := func(input string) (any, error) {
+		idx, err := BuildIndex(input)
+		if err != nil {
+			return nil, err
+		}
+		return idx.Parse()
+	}
+	for name, input := range conformanceAccept {
+		expected, _ := ParseJson(input)
+		value, err := parse(input)
+		if err != nil {
+			t.Errorf("FAIL: %s: expected the document to be accepted, got %s", name, err)
+		}
+		if !isEqual(expected, value) {
+			t.Errorf("FAIL: %s: expected %#v but got %#v", name, expected, value)
+		}
+	}
+	for name, input := range conformanceReject {
+		if value, err := parse(input); err == nil {
+			t.Errorf("FAIL: %s: expected the document to be rejected, got %#v", name, value)
+		}
+	}
+
+	body := readLocalFile("small-file.json")
+	expected, _ := ParseJson(body)
+	if value, err := parse(body); err != nil || !isEqual(expected, value) {
+		t.Errorf("FAIL: failed to parse small-file.json: %v", err)
+	}
+}
+
+func TestStructuralIndex_Get(t *testing.T) {
+	idx, err := BuildIndex(`{"a": [1, {"b": [true, "x"]}, []], "c": {"d": null}, "e": "f"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type TestCase struct {
+		Keys []any
+		Out  any
+	}
+	cases := []TestCase{
+		{Keys: []any{"e"}, Out: "f"},
+		{Keys: []any{"c", "d"}, Out: nil},
+		{Keys: []any{"a", 0}, Out: 1.0},
+		{Keys: []any{"a", 1, "b", 1}, Out: "x"},
+		{Keys: []any{"a", 2}, Out: []any{}},
+	}
+	for _, testCase := range cases {
+		value, err := idx.Get(testCase.Keys...)
+		if err != nil {
+			t.Errorf("FAIL: keys %v error: %s", testCase.Keys, err)
+		}
+		if !isEqual(value, testCase.Out) {
+			t.Errorf("FAIL: keys %v expected %#v but got %#v", testCase.Keys, testCase.Out, value)
+		}
+	}
+
+	for _, keys := range [][]any{{"x"}, {"a", 3}, {"a", "b"}, {"e", 0}, {"a", 2, 0}, {"a", -1}, {1.5}} {
+		if value, err := idx.Get(keys...); err == nil {
+			t.Errorf("FAIL: keys %v expected an error but got %#v", keys, value)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package json
 
 import (
 	"fmt"
-	"unicode"
 )
 
 func isDigit(r rune) bool {
@@ -10,11 +9,14 @@ func isDigit(r rune) bool {
 }
 func isWhitespace(r rune) bool {
 	switch r {
-	case '\t', '\n', '\v', '\f', '\r', ' ', 0x85, 0xA0:
+	case '\t', '\n', '\r', ' ':
 		return true
 	}
 	return false
 }
+func isHexDigit(r rune) bool {
+	return isDigit(r) || 'a' <= r && r <= 'f' || 'A' <= r && r <= 'F'
+}
 func isNumberStart(r rune) bool {
 	return r == '-' || isDigit(r)
 }
@@ -30,9 +32,6 @@ func isArrayStart(r rune) bool {
 func isArrayEnd(r rune) bool {
 	return r == ']'
 }
-func isBoolStart(r rune) bool {
-	return r == 'f' || r == 't'
-}
 func isNullStart(r rune) bool {
 	return r == 'n'
 }
@@ -48,6 +47,13 @@ func isColon(r rune) bool {
 func isComma(r rune) bool {
 	return r == ','
 }
+func isEscapable(r rune) bool {
+	switch r {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't', 'u':
+		return true
+	}
+	return false
+}
 
 type Lexer struct {
 	input        []rune
@@ -55,6 +61,8 @@ type Lexer struct {
 	c            rune
 	done         bool
 	currentToken Token
+	// start is the rune offset of the current token.
+	start int
 }
 
 func NewLexer(s string) *Lexer {
@@ -69,8 +77,20 @@ func (l *Lexer) NextToken() Token {
 	return l.currentToken
 }
 
+func (l *Lexer) current() Token {
+	return l.currentToken
+}
+
+func (l *Lexer) location() (string, int) {
+	return string(l.input), len(string(l.input[:l.start]))
+}
+
 func (l *Lexer) _getNextToken() Token {
 	l.skipWhiteSpace()
+	l.start = l.pos - 1
+	if l.done {
+		l.start = len(l.input)
+	}
 	switch {
 	case l.done:
 		return NewToken(TokenKindEOF, "EOF")
@@ -83,24 +103,30 @@ func (l *Lexer) _getNextToken() Token {
 	case isArrayEnd(l.c):
 		return NewTokenFromRune(TokenKindBracketClose, l.c)
 	case isQuote(l.c):
-		return NewToken(TokenKindString, l.readString())
+		return l.readString()
 	case isColon(l.c):
 		return NewTokenFromRune(TokenKindColon, l.c)
 	case isComma(l.c):
 		return NewTokenFromRune(TokenKindComma, l.c)
-	case isBoolStart(l.c):
-		return NewToken(TokenKindBoolean, l.readWord())
+	case l.c == 't':
+		return l.readLiteral(TokenKindBoolean, "true")
+	case l.c == 'f':
+		return l.readLiteral(TokenKindBoolean, "false")
 	case isNullStart(l.c):
-		return NewToken(TokenKindNull, l.readWord())
+		return l.readLiteral(TokenKindNull, "null")
 	case isNumberStart(l.c):
-		return NewToken(TokenKindNumber, l.readNumber())
+		return l.readNumber()
 	default:
-		return NewToken(TokenKindInvalid, fmt.Sprintf("Invalid token `%c` at position %d", l.c, l.pos))
+		return l.invalid("Invalid token `%c` at position %d", l.c, l.pos)
 	}
 }
 
+func (l *Lexer) invalid(format string, a ...any) Token {
+	return NewToken(TokenKindInvalid, fmt.Sprintf(format, a...))
+}
+
 func (l *Lexer) skipWhiteSpace() {
-	for !l.done && unicode.IsSpace(l.c) {
+	for !l.done && isWhitespace(l.c) {
 		l.advance()
 	}
 }
@@ -118,48 +144,93 @@ func (l *Lexer) prev() {
 		l.pos -= 1
 	}
 }
-func (l *Lexer) readString() string {
+
+// accept advances past the current rune if it satisfies valid.
+func (l *Lexer) accept(valid func(rune) bool) bool {
+	if l.done || !valid(l.c) {
+		return false
+	}
+	l.advance()
+	return true
+}
+
+// readString reads a string token, quotes included. The escapes are validated
+// here and decoded later by unquoteString.
+func (l *Lexer) readString() Token {
 	var position = l.pos
 	l.advance()
 
-	escaped := false
-	for !l.done && !(isQuote(l.c) && !escaped) {
-		if isEscape(l.c) {
-			escaped = !escaped
-		} else {
-			escaped = false
+	for !l.done && !isQuote(l.c) {
+		switch {
+		case l.c < 0x20:
+			return l.invalid("unescaped control character %U in string at position %d", l.c, l.pos)
+		case isEscape(l.c):
+			l.advance()
+			if l.done || !isEscapable(l.c) {
+				return l.invalid("invalid escape sequence in string at position %d", l.pos)
+			}
+			if l.c == 'u' {
+				for i := 0; i < 4; i++ {
+					l.advance()
+					if l.done || !isHexDigit(l.c) {
+						return l.invalid("invalid unicode escape in string at position %d", l.pos)
+					}
+				}
+			}
 		}
 		l.advance()
 	}
+	if l.done {
+		return l.invalid("unterminated string starting at position %d", position)
+	}
 
-	return string(l.input[position-1 : l.pos])
+	return NewToken(TokenKindString, string(l.input[position-1:l.pos]))
 }
 
-func (l *Lexer) readWord() string {
+// readLiteral expects the exact word (true, false or null) at the current position.
+func (l *Lexer) readLiteral(kind TokenKind, word string) Token {
 	var position = l.pos
-
-WORD:
-	for !l.done && !isWhitespace(l.c) {
-		switch l.c {
-		case ',', '{', '}', '[', ']':
-			break WORD
+	for i, r := range word {
+		if i > 0 {
+			l.advance()
+		}
+		if l.done || l.c != r {
+			return l.invalid("invalid literal at position %d, expected `%s`", position, word)
 		}
-		l.advance()
-	}
-	if !l.done {
-		l.prev()
 	}
-	return string(l.input[position-1 : l.pos])
+	return NewToken(kind, word)
 }
 
-func (l *Lexer) readNumber() string {
+// readNumber reads a number following the RFC 8259 grammar:
+// -? (0 | [1-9][0-9]*) (.[0-9]+)? ([eE][+-]?[0-9]+)?
+func (l *Lexer) readNumber() Token {
 	var position = l.pos
 
-	for !l.done && (isDigit(l.c) || l.c == '.' || l.c == 'E' || l.c == 'e' || l.c == '-') {
-		l.advance()
+	l.accept(func(r rune) bool { return r == '-' })
+	if !l.accept(func(r rune) bool { return r == '0' }) && !l.readDigits() {
+		return l.invalid("invalid number at position %d", position)
+	}
+	if l.accept(func(r rune) bool { return r == '.' }) && !l.readDigits() {
+		return l.invalid("invalid number at position %d: expected digit after `.`", position)
 	}
+	if l.accept(func(r rune) bool { return r == 'e' || r == 'E' }) {
+		l.accept(func(r rune) bool { return r == '+' || r == '-' })
+		if !l.readDigits() {
+			return l.invalid("invalid number at position %d: expected digit in exponent", position)
+		}
+	}
+
 	if !l.done {
 		l.prev()
 	}
-	return string(l.input[position-1 : l.pos])
+	return NewToken(TokenKindNumber, string(l.input[position-1:l.pos]))
+}
+
+func (l *Lexer) readDigits() bool {
+	if !l.accept(isDigit) {
+		return false
+	}
+	for l.accept(isDigit) {
+	}
+	return true
 }
@@ -9,7 +9,7 @@ import (
 )
 
 func TestLexer_NextToken(t *testing.T) {
-	const input = `  123  "456" null   true false "false" "true"  "trf`
+	const input = `  123  "456" null   true false "false" "true"  "trf"`
 	tks := strings.Fields(input)
 	lexer := NewLexer(input)
 	cmpTokens(t, lexer, tks)
@@ -38,9 +38,9 @@ func TestLexer_NextTokenUsingJson(t *testing.T) {
 }
 
 func TestLexer_NextTokenSpecialCases(t *testing.T) {
-	const input = `  {"kk":-0.e-23} {"kind": [true, false, 3.4e2, -1, {"key": "value"}]}`
+	const input = `  {"kk":-0.0e-23} {"kind": [true, false, 3.4e2, -1, {"key": "value"}]}`
 	lexer := NewLexer(input)
-	tokens := []string{"{", "\"kk\"", ":", "-0.e-23", "}",
+	tokens := []string{"{", "\"kk\"", ":", "-0.0e-23", "}",
 		"{", "\"kind\"", ":", "[", "true", ",", "false", ",", "3.4e2", ",", "-1", ",",
 		"{", "\"key\"", ":", "\"value\"", "}", "]", "}", "EOF"}
 	cmpTokens(t, lexer, tokens)
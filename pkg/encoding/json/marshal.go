@@ -0,0 +1,301 @@
+package json
+
+import (
+	"encoding"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/internal/structfields"
+)
+
+// Marshal encodes v as JSON. Registered codecs come first, then MarshalJSON
+// and MarshalText methods. Otherwise values are encoded like encoding/json
+// does: structs as objects named after their `json` struct tags, maps as
+// objects with sorted keys, []byte as base64 strings and nil pointers, slices
+// and maps as null.
+func Marshal(v any) ([]byte, error) {
+	e := &encoder{}
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+// maxMarshalDepth bounds the nesting of the encoded value, so that a value
+// that references itself fails instead of overflowing the stack.
+const maxMarshalDepth = 10000
+
+type encoder struct {
+	buf   []byte
+	depth int
+}
+
+func (e *encoder) encode(rv reflect.Value) error {
+	if e.depth >= maxMarshalDepth {
+		return fmt.Errorf("maximum nesting depth of %d exceeded, the value may reference itself", maxMarshalDepth)
+	}
+	e.depth++
+	err := e.value(rv)
+	e.depth--
+	return err
+}
+
+func (e *encoder) value(rv reflect.Value) error {
+	if !rv.IsValid() {
+		e.buf = append(e.buf, "null"...)
+		return nil
+	}
+	if c, ok := lookupCodec(rv.Type()); ok {
+		value, err := c.encode(rv)
+		if err != nil {
+			return err
+		}
+		return e.encode(reflect.ValueOf(value))
+	}
+	if m, ok := withMethod(rv, marshalerType); ok {
+		if isNilPointer(m) {
+			e.buf = append(e.buf, "null"...)
+			return nil
+		}
+		out, err := m.Interface().(Marshaler).MarshalJSON()
+		if err != nil {
+			return err
+		}
+		if _, err := parseInPlace(string(out)); err != nil {
+			return fmt.Errorf("invalid MarshalJSON output for type %s: %w", rv.Type(), err)
+		}
+		e.buf = append(e.buf, out...)
+		return nil
+	}
+	if m, ok := withMethod(rv, textMarshalerType); ok {
+		if isNilPointer(m) {
+			e.buf = append(e.buf, "null"...)
+			return nil
+		}
+		text, err := m.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		e.buf = appendQuoted(e.buf, string(text))
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		e.buf = strconv.AppendBool(e.buf, rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.buf = strconv.AppendInt(e.buf, rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.buf = strconv.AppendUint(e.buf, rv.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return e.float(rv.Float(), rv.Type().Bits())
+	case reflect.String:
+		e.buf = appendQuoted(e.buf, rv.String())
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			e.buf = append(e.buf, "null"...)
+			return nil
+		}
+		return e.encode(rv.Elem())
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			e.buf = append(e.buf, "null"...)
+			return nil
+		}
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			e.buf = append(e.buf, '"')
+			e.buf = base64.StdEncoding.AppendEncode(e.buf, rv.Bytes())
+			e.buf = append(e.buf, '"')
+			return nil
+		}
+		e.buf = append(e.buf, '[')
+		for i := 0; i < rv.Len(); i++ {
+			if i > 0 {
+				e.buf = append(e.buf, ',')
+			}
+			if err := e.encode(rv.Index(i)); err != nil {
+				return err
+			}
+		}
+		e.buf = append(e.buf, ']')
+	case reflect.Map:
+		return e.mapValue(rv)
+	case reflect.Struct:
+		e.buf = append(e.buf, '{')
+		first := true
+		for _, f := range structfields.Of(rv.Type()) {
+			value, ok := f.Get(rv)
+			if !ok || f.OmitEmpty && isEmptyValue(value) {
+				continue
+			}
+			if !first {
+				e.buf = append(e.buf, ',')
+			}
+			first = false
+			e.buf = append(appendQuoted(e.buf, f.Name), ':')
+			if err := e.encode(value); err != nil {
+				return err
+			}
+		}
+		e.buf = append(e.buf, '}')
+	default:
+		return fmt.Errorf("unsupported type %s", rv.Type())
+	}
+	return nil
+}
+
+func (e *encoder) mapValue(rv reflect.Value) error {
+	if rv.IsNil() {
+		e.buf = append(e.buf, "null"...)
+		return nil
+	}
+	type member struct {
+		key   string
+		value reflect.Value
+	}
+	members := make([]member, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		key, err := encodeKey(iter.Key())
+		if err != nil {
+			return err
+		}
+		members = append(members, member{key, iter.Value()})
+	}
+	slices.SortFunc(members, func(a, b member) int {
+		return strings.Compare(a.key, b.key)
+	})
+
+	e.buf = append(e.buf, '{')
+	for i, m := range members {
+		if i > 0 {
+			e.buf = append(e.buf, ',')
+		}
+		e.buf = append(appendQuoted(e.buf, m.key), ':')
+		if err := e.encode(m.value); err != nil {
+			return err
+		}
+	}
+	e.buf = append(e.buf, '}')
+	return nil
+}
+
+// encodeKey converts a map key to an object member name. Strings, integers
+// and encoding.TextMarshaler keys are supported.
+func encodeKey(rv reflect.Value) (string, error) {
+	if rv.Kind() == reflect.String {
+		return rv.String(), nil
+	}
+	if m, ok := withMethod(rv, textMarshalerType); ok && !isNilPointer(m) {
+		text, err := m.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("unsupported map key type %s", rv.Type())
+}
+
+// float formats f like encoding/json: without an exponent unless the value is
+// very small or very large.
+func (e *encoder) float(f float64, bits int) error {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return fmt.Errorf("unsupported value: %s", strconv.FormatFloat(f, 'g', -1, bits))
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 32 {
+			abs = float64(float32(abs))
+		}
+		if abs < 1e-6 || abs >= 1e21 {
+			format = 'e'
+		}
+	}
+	e.buf = strconv.AppendFloat(e.buf, f, format, -1, bits)
+	if format == 'e' {
+		// e-09 becomes e-9
+		if n := len(e.buf); n >= 4 && e.buf[n-4] == 'e' && e.buf[n-3] == '-' && e.buf[n-2] == '0' {
+			e.buf[n-2] = e.buf[n-1]
+			e.buf = e.buf[:n-1]
+		}
+	}
+	return nil
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendQuoted appends s as a JSON string. Invalid UTF-8 is replaced with
+// U+FFFD.
+func appendQuoted(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, `\ufffd`...)
+			i++
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
+
+func isNilPointer(rv reflect.Value) bool {
+	return (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) && rv.IsNil()
+}
+
+// isEmptyValue reports whether rv is empty in the sense of omitempty: false,
+// 0, nil, or an empty string, slice, map or array.
+func isEmptyValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Pointer, reflect.Interface:
+		return rv.IsZero()
+	}
+	return false
+}
@@ -0,0 +1,363 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// money is an amount in cents written as a decimal number.
+type money int64
+
+func (m money) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d.%02d", m/100, m%100)), nil
+}
+
+func (m *money) UnmarshalJSON(data []byte) error {
+	units, cents, _ := strings.Cut(string(data), ".")
+	n, err := strconv.ParseInt(units+cents+strings.Repeat("0", 2-len(cents)), 10, 64)
+	if err != nil {
+		return err
+	}
+	*m = money(n)
+	return nil
+}
+
+// id is written as a prefixed string.
+type id uint32
+
+func (i id) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("id-%d", i)), nil
+}
+
+func (i *id) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "id-%d", (*uint32)(i))
+	return err
+}
+
+type order struct {
+	ID       id               `json:"id"`
+	Total    money            `json:"total"`
+	Discount *money           `json:"discount,omitempty"`
+	Items    []string         `json:"items"`
+	Tags     map[id]bool      `json:"tags,omitempty"`
+	Counts   map[int]string   `json:"counts,omitempty"`
+	Created  time.Time        `json:"created"`
+	Extra    any              `json:"extra"`
+	Raw      RawValue         `json:"raw,omitempty"`
+	Nested   *order           `json:"nested,omitempty"`
+	Ignored  string           `json:"-"`
+	Fixed    [2]int           `json:"fixed"`
+	Data     []byte           `json:"data,omitempty"`
+	Any      map[string]any   `json:"any,omitempty"`
+	Matrix   [][]float32      `json:"matrix,omitempty"`
+	Options  map[string]*bool `json:"options,omitempty"`
+	Plain    int
+}
+
+func TestMarshal_Hooks(t *testing.T) {
+	discount := money(50)
+	o := order{
+		ID:       7,
+		Total:    1999,
+		Discount: &discount,
+		Items:    []string{"a", "b"},
+		Tags:     map[id]bool{2: true, 1: false},
+		Counts:   map[int]string{10: "ten", -1: "minus one"},
+		Created:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Extra:    map[string]any{"k": []any{1.5, nil}},
+		Raw:      RawValue(`{"kept": [1, 2]}`),
+		Nested:   &order{ID: 8, Items: []string{}},
+		Ignored:  "no",
+		Fixed:    [2]int{1, 2},
+		Data:     []byte("hi"),
+		Plain:    3,
+	}
+	out, err := Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"id":"id-7","total":19.99,"discount":0.50,"items":["a","b"],"tags":{"id-1":false,"id-2":true},` +
+		`"counts":{"-1":"minus one","10":"ten"},"created":"2024-01-02T03:04:05Z","extra":{"k":[1.5,null]},` +
+		`"raw":{"kept": [1, 2]},"nested":{"id":"id-8","total":0.00,"items":[],"created":"0001-01-01T00:00:00Z",` +
+		`"extra":null,"fixed":[0,0],"Plain":0},"fixed":[1,2],"data":"aGk=","Plain":3}`
+	if string(out) != expected {
+		t.Errorf("FAIL: expected\n%s\nbut got\n%s", expected, out)
+	}
+
+	var decoded order
+	if err := Unmarshal(out, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	o.Ignored = ""
+	o.Extra = map[string]any{"k": []any{1.5, nil}}
+	if !reflect.DeepEqual(o, decoded) {
+		t.Errorf("FAIL: expected\n%#v\nbut got\n%#v", o, decoded)
+	}
+}
+
+func TestMarshal_MatchesEncodingJson(t *testing.T) {
+	values := []any{
+		nil, true, 0, -12, uint8(200), 1.5, -0.0, 1e21, 1e-7, 123456789.0, float32(0.1), float32(1e-7), math.MaxFloat64,
+		"", "quote \" slash \\ newline \n tab \t bell \a del \x7f é 😀", "<html>&",
+		[]int{}, []int(nil), map[string]int(nil), [3]bool{true},
+		map[string]any{"b": 1, "a": []any{"x", map[string]any{}}},
+		map[uint]int{3: 1, 20: 2}, []byte{0, 1, 2, 250},
+		struct {
+			A int `json:"a,omitempty"`
+			B string
+			c int
+			D *int           `json:"d"`
+			E []int          `json:",omitempty"`
+			F map[string]int `json:"f,omitempty"`
+			G struct{}       `json:"g,omitempty"`
+		}{B: "b"},
+		time.Date(2024, 2, 29, 12, 0, 0, 1, time.FixedZone("x", 3600)),
+	}
+	for _, value := range values {
+		out, err := Marshal(value)
+		var expected bytes.Buffer
+		encoder := json.NewEncoder(&expected)
+		encoder.SetEscapeHTML(false)
+		_ = encoder.Encode(value)
+		if err != nil || string(out) != strings.TrimSuffix(expected.String(), "\n") {
+			t.Errorf("FAIL: %#v expected %s but got %s (%v)", value, expected.String(), out, err)
+		}
+	}
+
+	for _, value := range []any{math.NaN(), math.Inf(1), make(chan int), map[[2]int]int{{1, 2}: 3}, func() {}} {
+		if out, err := Marshal(value); err == nil {
+			t.Errorf("FAIL: %#v expected an error but got %s", value, out)
+		}
+	}
+}
+
+type (
+	embeddedBase struct {
+		ID   int `json:"id"`
+		Name string
+	}
+	embeddedOther struct {
+		Name  string
+		Extra bool `json:",omitempty"`
+	}
+	embeddedTagged struct {
+		Name string `json:"Name"`
+	}
+	embeddedHidden struct {
+		Secret  int
+		private int
+	}
+	// EmbeddedPointer is exported so that Unmarshal can allocate it.
+	EmbeddedPointer struct {
+		Deep int
+	}
+)
+
+func TestMarshal_Embedded(t *testing.T) {
+	values := []any{
+		// Name is ambiguous at depth 1 and dropped
+		struct {
+			embeddedBase
+			embeddedOther
+			Own int
+		}{embeddedBase{1, "b"}, embeddedOther{"o", true}, 2},
+		// a shallower field hides the embedded one
+		struct {
+			*embeddedBase
+			Name string
+		}{&embeddedBase{1, "b"}, "own"},
+		struct{ *embeddedBase }{},
+		struct {
+			embeddedBase    `json:"-"`
+			EmbeddedPointer `json:"base"`
+		}{embeddedBase{1, "b"}, EmbeddedPointer{1}},
+		// the tagged field wins at the same depth
+		struct {
+			embeddedBase
+			embeddedTagged
+		}{embeddedBase{1, "b"}, embeddedTagged{"t"}},
+		struct{ embeddedHidden }{embeddedHidden{1, 2}},
+		struct {
+			embeddedHidden `json:"-"`
+			A              int
+		}{embeddedHidden{1, 2}, 3},
+	}
+	for _, value := range values {
+		out, err := Marshal(value)
+		expected, _ := json.Marshal(value)
+		if err != nil || string(out) != string(expected) {
+			t.Errorf("FAIL: %#v expected %s but got %s (%v)", value, expected, out, err)
+		}
+	}
+
+	type target struct {
+		*EmbeddedPointer
+		embeddedOther
+		embeddedBase
+	}
+	var got target
+	if err := Unmarshal([]byte(`{"id": 3, "Extra": true, "Deep": 4, "Name": "dropped"}`), &got); err != nil {
+		t.Fatal(err)
+	}
+	expected := target{EmbeddedPointer: &EmbeddedPointer{4}, embeddedOther: embeddedOther{Extra: true}, embeddedBase: embeddedBase{ID: 3}}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("FAIL: expected %#v but got %#v", expected, got)
+	}
+
+	// like encoding/json, a pointer to an unexported struct cannot be allocated
+	var unexported struct{ *embeddedBase }
+	if err := Unmarshal([]byte(`{"id": 1}`), &unexported); err == nil {
+		t.Errorf("FAIL: expected an error but got %#v", unexported.embeddedBase)
+	}
+}
+
+func TestMarshal_Cycle(t *testing.T) {
+	type node struct{ Next *node }
+	n := &node{}
+	n.Next = n
+	m := map[string]any{}
+	m["m"] = m
+	for _, value := range []any{n, m} {
+		if _, err := Marshal(value); err == nil || !strings.Contains(err.Error(), "maximum nesting depth") {
+			t.Errorf("FAIL: expected a depth error but got %v", err)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	type inner struct {
+		Name string `json:"name"`
+	}
+	type target struct {
+		Int     int8            `json:"int"`
+		Uint    uint16          `json:"uint"`
+		Float   float32         `json:"float"`
+		Bool    bool            `json:"bool"`
+		Ptr     *inner          `json:"ptr"`
+		Slice   []inner         `json:"slice"`
+		Array   [2]int          `json:"array"`
+		Map     map[string]int  `json:"map"`
+		Any     any             `json:"any"`
+		Keep    string          `json:"keep"`
+		Null    *int            `json:"null"`
+		Bytes   []byte          `json:"bytes"`
+		Keys    map[int64]bool  `json:"keys"`
+		Message json.RawMessage `json:"message"`
+	}
+	input := `{"INT": -5, "uint": 65535, "float": 0.5, "bool": true, "ptr": {"name": "a"},
+		"slice": [{"name": "b"}, {"Name": "c"}], "array": [1, 2, 3], "map": {"x": 1},
+		"any": [1, {"y": null}], "unknown": {"deep": [1, 2, {}]}, "keep": null, "null": null,
+		"bytes": "aGk=", "keys": {"-3": true}, "message": [ 1, 2 ]}`
+	one := 1
+	got := target{Keep: "kept", Null: &one, Array: [2]int{9, 9}}
+	if err := Unmarshal([]byte(input), &got); err != nil {
+		t.Fatal(err)
+	}
+	expected := target{
+		Int: -5, Uint: 65535, Float: 0.5, Bool: true, Ptr: &inner{"a"},
+		Slice: []inner{{"b"}, {"c"}}, Array: [2]int{1, 2}, Map: map[string]int{"x": 1},
+		Any: []any{1.0, map[string]any{"y": nil}}, Keep: "kept", Bytes: []byte("hi"),
+		Keys: map[int64]bool{-3: true}, Message: json.RawMessage(`[ 1, 2 ]`),
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("FAIL: expected\n%#v\nbut got\n%#v", expected, got)
+	}
+
+	var value any
+	if err := Unmarshal([]byte(`{"a": [1, "b", null, true]}`), &value); err != nil {
+		t.Fatal(err)
+	}
+	if parsed, _ := ParseJson(`{"a": [1, "b", null, true]}`); !isEqual(parsed, value) {
+		t.Errorf("FAIL: expected %#v but got %#v", parsed, value)
+	}
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	var small struct {
+		N int8   `json:"n"`
+		U uint   `json:"u"`
+		S string `json:"s"`
+		M money  `json:"m"`
+	}
+	cases := []string{
+		``, `{`, `{"n": 1,}`, `{"n": 1} {}`, `{"n": 300}`, `{"n": 1.5}`, `{"u": -1}`, `{"s": 1}`,
+		`{"s": [1]}`, `{"n": "1"}`, `{"unknown": [1,]}`, `{"m": [}`, `{"m": "x"}`, `[1]`,
+	}
+	for _, input := range cases {
+		if err := Unmarshal([]byte(input), &small); err == nil {
+			t.Errorf("FAIL: %s expected an error", input)
+		}
+	}
+	if err := Unmarshal([]byte(`1`), small); err == nil {
+		t.Errorf("FAIL: expected an error for a non pointer target")
+	}
+	var stringer fmt.Stringer
+	if err := Unmarshal([]byte(`1`), &stringer); err == nil {
+		t.Errorf("FAIL: expected an error for a non empty interface")
+	}
+}
+
+func TestRegisterCodec(t *testing.T) {
+	RegisterCodec(EncodeDuration, DecodeDuration)
+	defer UnregisterCodec[time.Duration]()
+
+	type config struct {
+		Timeout  time.Duration            `json:"timeout"`
+		Retry    *time.Duration           `json:"retry"`
+		Backoffs []time.Duration          `json:"backoffs"`
+		ByName   map[string]time.Duration `json:"by_name"`
+	}
+	retry := 90 * time.Minute
+	c := config{
+		Timeout:  1500 * time.Millisecond,
+		Retry:    &retry,
+		Backoffs: []time.Duration{time.Second, 2 * time.Second},
+		ByName:   map[string]time.Duration{"a": time.Microsecond},
+	}
+	out, err := Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"timeout":"1.5s","retry":"1h30m0s","backoffs":["1s","2s"],"by_name":{"a":"1µs"}}`
+	if string(out) != expected {
+		t.Errorf("FAIL: expected %s but got %s", expected, out)
+	}
+
+	var decoded config
+	if err := Unmarshal(out, &decoded); err != nil || !reflect.DeepEqual(c, decoded) {
+		t.Errorf("FAIL: expected %#v but got %#v (%v)", c, decoded, err)
+	}
+	if err := Unmarshal([]byte(`{"timeout": 1000}`), &decoded); err != nil || decoded.Timeout != time.Microsecond {
+		t.Errorf("FAIL: expected nanoseconds to be accepted, got %v (%v)", decoded.Timeout, err)
+	}
+	if err := Unmarshal([]byte(`{"timeout": "soon"}`), &decoded); err == nil {
+		t.Errorf("FAIL: expected an invalid duration to be rejected")
+	}
+
+	// codecs take precedence over methods
+	RegisterCodec(func(m money) (any, error) {
+		return int64(m), nil
+	}, func(value any) (money, error) {
+		if f, ok := value.(float64); ok {
+			return money(f), nil
+		}
+		return 0, errors.New("money must be a number of cents")
+	})
+	defer UnregisterCodec[money]()
+	out, err = Marshal([]money{1999})
+	if err != nil || string(out) != `[1999]` {
+		t.Errorf("FAIL: expected the codec to be used, got %s (%v)", out, err)
+	}
+	var amounts []money
+	if err := Unmarshal([]byte(`[1999]`), &amounts); err != nil || !reflect.DeepEqual([]money{1999}, amounts) {
+		t.Errorf("FAIL: expected the codec to be used, got %v (%v)", amounts, err)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build linux
+
+package json
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func mmap(f *os.File, size int64) ([]byte, error) {
+	if size <= 0 || int64(int(size)) != size {
+		return nil, errors.New("file size cannot be mapped")
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	// the document is read front to back exactly once
+	_ = syscall.Madvise(data, syscall.MADV_SEQUENTIAL)
+	return data, nil
+}
+
+func munmap(data []byte) error {
+	return syscall.Munmap(data)
+}
@@ -0,0 +1,121 @@
+package json
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// batchesPerWorker controls how finely the elements are split between the
+// workers. More batches balance uneven elements better at a small scheduling cost.
+const batchesPerWorker = 8
+
+type span struct {
+	start, end int
+}
+
+// ParseJsonParallel parses json like ParseJson, but when the document is a
+// top-level array its elements are parsed on a pool of workers. The elements
+// keep their order. A workers value <= 0 uses runtime.GOMAXPROCS(0) workers.
+//
+// Only the element being parsed is expanded by the lexer, so the peak memory
+// stays close to the one of the resulting value. Invalid documents are parsed
+// again by ParseJson, so that the *SyntaxError locates the offending token in
+// the whole input rather than in its element.
+func ParseJsonParallel(json string, workers int) (any, error) {
+	spans := splitArray(json)
+	if spans == nil {
+		return ParseJson(json)
+	}
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	batchSize := len(spans)/(workers*batchesPerWorker) + 1
+	batches := make(chan span)
+	result := make([]any, len(spans))
+
+	var wg sync.WaitGroup
+	var failed atomic.Bool
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				for j := batch.start; j < batch.end && !failed.Load(); j++ {
+					value, err := ParseJson(json[spans[j].start:spans[j].end])
+					if err != nil {
+						failed.Store(true)
+						break
+					}
+					result[j] = value
+				}
+			}
+		}()
+	}
+	for i := 0; i < len(spans) && !failed.Load(); i += batchSize {
+		batches <- span{start: i, end: min(i+batchSize, len(spans))}
+	}
+	close(batches)
+	wg.Wait()
+
+	if failed.Load() {
+		return ParseJson(json)
+	}
+	return result, nil
+}
+
+// splitArray is a structural pre-scan that finds the byte spans of the
+// elements of a top-level array without tokenizing them. It returns nil when
+// json is not an array, or not a well-formed one. Only the brackets, quotes
+// and escapes are looked at, the elements themselves are validated by the
+// parser.
+func splitArray(json string) []span {
+	i := skipWhitespaceBytes(json, 0)
+	if i == len(json) || json[i] != '[' {
+		return nil
+	}
+
+	spans := make([]span, 0)
+	depth := 0
+	start := i + 1
+	for ; i < len(json); i++ {
+		switch json[i] {
+		case '"':
+			for i++; i < len(json) && json[i] != '"'; i++ {
+				if json[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(json) {
+				return nil
+			}
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+			if depth == 0 {
+				if json[i] != ']' || skipWhitespaceBytes(json, i+1) != len(json) {
+					return nil
+				}
+				if len(spans) > 0 || skipWhitespaceBytes(json, start) < i {
+					spans = append(spans, span{start: start, end: i})
+				}
+				return spans
+			}
+		case ',':
+			if depth == 1 {
+				spans = append(spans, span{start: start, end: i})
+				start = i + 1
+			}
+		}
+	}
+	return nil
+}
+
+func skipWhitespaceBytes(s string, i int) int {
+	for i < len(s) && isWhitespace(rune(s[i])) {
+		i++
+	}
+	return i
+}
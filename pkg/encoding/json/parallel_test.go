@@ -0,0 +1,40 @@
+package json
+
+import (
+	"testing"
+)
+
+func TestParseJsonParallel(t *testing.T) {
+	inputs := []string{
+		readLocalFile("small-file.json"),
+		readLocalFile("something.json"),
+		`[]`,
+		` [ ] `,
+		`[1]`,
+		`[1, "a,b", {"c": [1, 2]}, [], "\"]"]`,
+		`"not an array"`,
+	}
+	for _, input := range inputs {
+		expected, err := ParseJson(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, workers := range []int{0, 1, 3} {
+			value, err := ParseJsonParallel(input, workers)
+			if err != nil {
+				t.Errorf("FAIL: workers=%d error: %s", workers, err)
+			}
+			if !isEqual(expected, value) {
+				t.Errorf("FAIL: workers=%d expected %#v but got %#v", workers, expected, value)
+			}
+		}
+	}
+}
+
+func TestParseJsonParallel_Invalid(t *testing.T) {
+	for _, input := range []string{`[`, `[1,]`, `[,1]`, `[1,,2]`, `[1}`, `[1] x`, `["a]`, `[{"a":}]`, `[1 2]`} {
+		if value, err := ParseJsonParallel(input, 2); err == nil {
+			t.Errorf("FAIL: input %s expected an error but got %#v", input, value)
+		}
+	}
+}
@@ -1,20 +1,43 @@
 package json
 
 import (
-	"errors"
-	"fmt"
 	"strconv"
+	"unicode/utf8"
 )
 
+// tokenizer is a source of tokens for the parser.
+type tokenizer interface {
+	NextToken() Token
+	current() Token
+	// location returns the input and the byte offset of the current token.
+	location() (input string, offset int)
+}
+
 type jsonParser struct {
-	lexer *Lexer
+	lexer tokenizer
 }
 
-func newJsonParser(lexer *Lexer) *jsonParser {
+func newJsonParser(lexer tokenizer) *jsonParser {
 	return &jsonParser{lexer: lexer}
 }
 
+// ParseJson parses a JSON document. Valid UTF-8 is parsed through the
+// structural index, which is faster than the Lexer even when nothing is
+// skipped. Invalid documents are parsed again by the Lexer, which replaces
+// invalid UTF-8 with U+FFFD and reports the error precisely.
 func ParseJson(json string) (interface{}, error) {
+	if utf8.ValidString(json) {
+		if idx, err := BuildIndex(json); err == nil {
+			if result, err := idx.Parse(); err == nil {
+				return result, nil
+			}
+		}
+	}
+	return parseLexer(json)
+}
+
+// parseLexer is ParseJson through the Lexer.
+func parseLexer(json string) (interface{}, error) {
 	parser := newJsonParser(NewLexer(json))
 	result, err := parser.parseValue()
 	if err != nil {
@@ -29,52 +52,44 @@ func ParseJson(json string) (interface{}, error) {
 }
 
 func (p *jsonParser) parseValue() (any, error) {
-	for {
-		token := p.lexer.NextToken()
-		switch token.Kind {
-		case TokenKindEOF:
-			return nil, errors.New("EOF: end of file")
-		case TokenKindInvalid:
-			return nil, p.invalidTokenError()
-		case TokenKindNull:
-			return nil, nil
-		case TokenKindBoolean:
-			switch token.Value {
-			case "true":
-				return true, nil
-			case "false":
-				return false, nil
-			default:
-				return nil, p.invalidTokenError()
-			}
-		case TokenKindNumber:
-			return strconv.ParseFloat(token.Value, 64)
-		case TokenKindString:
-			return unquoteString(token.Value), nil
-		case TokenKindBraceOpen:
-			return p.parseObject()
-		case TokenKindBracketOpen:
-			return p.parseArray()
-		case TokenKindColon:
-			return nil, p.invalidTokenError()
-		case TokenKindComma:
-			return nil, p.invalidTokenError()
-		case TokenKindBraceClose:
-			return nil, p.invalidTokenError()
-		case TokenKindBracketClose:
-			return nil, p.invalidTokenError()
+	return p.parseToken(p.lexer.NextToken())
+}
+
+func (p *jsonParser) parseToken(token Token) (any, error) {
+	switch token.Kind {
+	case TokenKindEOF:
+		return nil, syntaxError(p.lexer, "EOF: end of file")
+	case TokenKindNull:
+		return nil, nil
+	case TokenKindBoolean:
+		return token.Value == "true", nil
+	case TokenKindNumber:
+		number, err := strconv.ParseFloat(token.Value, 64)
+		if err != nil {
+			return nil, numberError(p.lexer, err)
 		}
+		return number, nil
+	case TokenKindString:
+		return unquoteString(token.Value), nil
+	case TokenKindBraceOpen:
+		return p.parseObject()
+	case TokenKindBracketOpen:
+		return p.parseArray()
+	default:
+		return nil, p.invalidTokenError()
 	}
 }
 
 func (p *jsonParser) parseArray() (any, error) {
 	obj := make([]any, 0)
 	for {
-		value, err := p.parseValue()
+		token := p.lexer.NextToken()
+		if len(obj) == 0 && token.Kind == TokenKindBracketClose {
+			return obj, nil
+		}
+		value, err := p.parseToken(token)
 		if err != nil {
-			if p.lexer.currentToken.Kind == TokenKindBracketClose {
-				return obj, nil
-			}
+			return nil, err
 		}
 		obj = append(obj, value)
 
@@ -87,14 +102,17 @@ func (p *jsonParser) parseArray() (any, error) {
 			return nil, p.invalidTokenError()
 		}
 	}
-
 }
+
 func (p *jsonParser) parseObject() (any, error) {
 	obj := make(map[string]any)
-	for {
+	for first := true; ; first = false {
 		keyToken := p.lexer.NextToken()
 		switch keyToken.Kind {
 		case TokenKindBraceClose:
+			if !first {
+				return nil, p.invalidTokenError()
+			}
 			return obj, nil
 		case TokenKindString:
 			key := unquoteString(keyToken.Value)
@@ -122,5 +140,5 @@ func (p *jsonParser) parseObject() (any, error) {
 }
 
 func (p *jsonParser) invalidTokenError() error {
-	return fmt.Errorf("unexpected token: type= %s -> value= `%v`", p.lexer.currentToken.Kind.toString(), p.lexer.currentToken.Value)
+	return unexpected(p.lexer)
 }
@@ -13,7 +13,7 @@ func TestParseJson(t *testing.T) {
 	}
 	cases := []TestCase{
 		{In: "true", Out: true},
-		{In: "-3.e-2", Out: -3.e-2},
+		{In: "-3.0e-2", Out: -3.0e-2},
 		{In: "false", Out: false},
 		{In: "null", Out: nil},
 		{In: "[]", Out: []any{}},
@@ -0,0 +1,188 @@
+package json
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// Theme holds the ANSI SGR sequence written before each kind of token. Empty
+// fields leave that kind uncolored.
+type Theme struct {
+	Key         string
+	String      string
+	Number      string
+	Boolean     string
+	Null        string
+	Punctuation string
+}
+
+const ansiReset = "\x1b[0m"
+
+var (
+	// DefaultTheme works on dark and light backgrounds with the 16 basic colors.
+	DefaultTheme = Theme{
+		Key:         "\x1b[1;34m",
+		String:      "\x1b[32m",
+		Number:      "\x1b[36m",
+		Boolean:     "\x1b[33m",
+		Null:        "\x1b[1;30m",
+		Punctuation: "",
+	}
+	// MonokaiTheme uses 256 color codes close to the Monokai palette.
+	MonokaiTheme = Theme{
+		Key:         "\x1b[38;5;81m",
+		String:      "\x1b[38;5;186m",
+		Number:      "\x1b[38;5;141m",
+		Boolean:     "\x1b[38;5;197m",
+		Null:        "\x1b[38;5;197m",
+		Punctuation: "\x1b[38;5;231m",
+	}
+)
+
+// ColorMode selects when a Printer writes colors.
+type ColorMode int8
+
+const (
+	// ColorAuto colors the output when the writer is a terminal and the
+	// NO_COLOR environment variable is unset or empty.
+	ColorAuto ColorMode = iota
+	ColorAlways
+	ColorNever
+)
+
+// Printer writes JSON documents indented and syntax highlighted, driven by the
+// tokens of a Lexer.
+type Printer struct {
+	Writer io.Writer
+	Indent string
+	Theme  Theme
+	Color  ColorMode
+}
+
+func NewPrinter(w io.Writer) *Printer {
+	return &Printer{Writer: w, Indent: "  ", Theme: DefaultTheme}
+}
+
+// colored reports whether the output should contain escape sequences.
+func (p *Printer) colored() bool {
+	switch p.Color {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(p.Writer)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(interface{ Stat() (os.FileInfo, error) })
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Print writes json followed by a newline, each top-level value on its own
+// lines. Only the tokens and the nesting of brackets are checked; the output
+// stops at the first invalid token.
+func (p *Printer) Print(json string) error {
+	w := bufio.NewWriter(p.Writer)
+	colored := p.colored()
+	write := func(color, s string) {
+		if colored && color != "" {
+			w.WriteString(color)
+			w.WriteString(s)
+			w.WriteString(ansiReset)
+		} else {
+			w.WriteString(s)
+		}
+	}
+	newline := func(depth int) {
+		w.WriteByte('\n')
+		w.WriteString(strings.Repeat(p.Indent, depth))
+	}
+
+	// objects holds, for every open container, whether it is an object
+	objects := make([]bool, 0)
+	lexer := NewLexer(json)
+	var pending *Token
+	next := func() Token {
+		if pending != nil {
+			token := *pending
+			pending = nil
+			return token
+		}
+		return lexer.NextToken()
+	}
+
+	expectKey, printed := false, false
+	for token := next(); token.Kind != TokenKindEOF; token = next() {
+		switch token.Kind {
+		case TokenKindBraceOpen, TokenKindBracketOpen, TokenKindString, TokenKindNumber, TokenKindBoolean, TokenKindNull:
+			if len(objects) == 0 {
+				if printed {
+					w.WriteByte('\n')
+				}
+				printed = true
+			}
+		}
+		switch token.Kind {
+		case TokenKindInvalid:
+			w.Flush()
+			return errors.New(token.Value)
+		case TokenKindBraceOpen, TokenKindBracketOpen:
+			isObject := token.Kind == TokenKindBraceOpen
+			write(p.Theme.Punctuation, token.Value)
+			following := next()
+			if isObject && following.Kind == TokenKindBraceClose || !isObject && following.Kind == TokenKindBracketClose {
+				write(p.Theme.Punctuation, following.Value)
+				continue
+			}
+			objects = append(objects, isObject)
+			newline(len(objects))
+			expectKey = isObject
+			pending = &following
+		case TokenKindBraceClose, TokenKindBracketClose:
+			if len(objects) == 0 || objects[len(objects)-1] != (token.Kind == TokenKindBraceClose) {
+				w.Flush()
+				return errors.New("unexpected " + token.Value)
+			}
+			objects = objects[:len(objects)-1]
+			newline(len(objects))
+			write(p.Theme.Punctuation, token.Value)
+		case TokenKindComma:
+			write(p.Theme.Punctuation, token.Value)
+			newline(len(objects))
+			expectKey = len(objects) > 0 && objects[len(objects)-1]
+		case TokenKindColon:
+			write(p.Theme.Punctuation, token.Value)
+			w.WriteByte(' ')
+		case TokenKindString:
+			if expectKey {
+				write(p.Theme.Key, token.Value)
+				expectKey = false
+			} else {
+				write(p.Theme.String, token.Value)
+			}
+		case TokenKindNumber:
+			write(p.Theme.Number, token.Value)
+		case TokenKindBoolean:
+			write(p.Theme.Boolean, token.Value)
+		case TokenKindNull:
+			write(p.Theme.Null, token.Value)
+		}
+	}
+	if len(objects) > 0 {
+		w.Flush()
+		return errors.New("EOF: end of file")
+	}
+	w.WriteByte('\n')
+	return w.Flush()
+}
@@ -0,0 +1,111 @@
+package json
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPrinter_Print(t *testing.T) {
+	input := `{"a": [1, "two", true, null, {}, []], "b": {"c": {"d": -1.5e3}}, "e": [{"f": false}]}`
+	expected := `{
+  "a": [
+    1,
+    "two",
+    true,
+    null,
+    {},
+    []
+  ],
+  "b": {
+    "c": {
+      "d": -1.5e3
+    }
+  },
+  "e": [
+    {
+      "f": false
+    }
+  ]
+}
+`
+	var out bytes.Buffer
+	p := NewPrinter(&out)
+	p.Color = ColorNever
+	if err := p.Print(input); err != nil || out.String() != expected {
+		t.Errorf("FAIL: expected\n%s\nbut got\n%s (%v)", expected, out.String(), err)
+	}
+
+	out.Reset()
+	expected = "1\n2\n{\n  \"a\": \"b\"\n}\n[]\n\"c\"\n"
+	if err := p.Print("1 2 {\"a\": \"b\"}[] \"c\""); err != nil || out.String() != expected {
+		t.Errorf("FAIL: expected %q but got %q (%v)", expected, out.String(), err)
+	}
+}
+
+func TestPrinter_Colors(t *testing.T) {
+	theme := Theme{Key: "<k>", String: "<s>", Number: "<n>", Boolean: "<b>", Null: "<0>", Punctuation: "<p>"}
+	var out bytes.Buffer
+	p := &Printer{Writer: &out, Theme: theme, Color: ColorAlways}
+	if err := p.Print(`{"k": ["v", 1, true, null]}`); err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.NewReplacer("\\r", ansiReset).Replace(`<p>{\r
+<k>"k"\r<p>:\r <p>[\r
+<s>"v"\r<p>,\r
+<n>1\r<p>,\r
+<b>true\r<p>,\r
+<0>null\r
+<p>]\r
+<p>}\r
+`)
+	if out.String() != expected {
+		t.Errorf("FAIL: expected %q but got %q", expected, out.String())
+	}
+}
+
+func TestPrinter_ColorAuto(t *testing.T) {
+	var out bytes.Buffer
+	p := NewPrinter(&out)
+	if p.colored() {
+		t.Errorf("FAIL: expected no colors for a buffer")
+	}
+
+	f, err := os.Create(t.TempDir() + "/out.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if NewPrinter(f).colored() {
+		t.Errorf("FAIL: expected no colors for a regular file")
+	}
+
+	if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+		defer tty.Close()
+		t.Setenv("NO_COLOR", "")
+		if !NewPrinter(tty).colored() {
+			t.Errorf("FAIL: expected colors for a terminal")
+		}
+		t.Setenv("NO_COLOR", "1")
+		if NewPrinter(tty).colored() {
+			t.Errorf("FAIL: expected NO_COLOR to disable colors")
+		}
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	p.Color = ColorAlways
+	if !p.colored() {
+		t.Errorf("FAIL: expected ColorAlways to override NO_COLOR")
+	}
+}
+
+func TestPrinter_Errors(t *testing.T) {
+	for _, input := range []string{`{"a": tru}`, `[1}`, `]`, `{"a": [1`, `"abc`} {
+		var out bytes.Buffer
+		p := NewPrinter(&out)
+		if err := p.Print(input); err == nil {
+			t.Errorf("FAIL: %s expected an error but got %q", input, out.String())
+		}
+	}
+}
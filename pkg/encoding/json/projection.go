@@ -0,0 +1,174 @@
+package json
+
+import (
+	"strconv"
+	"strings"
+)
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// projection holds the remaining segments of every path that can still match
+// the value being parsed.
+type projection [][]string
+
+func newProjection(paths []string) projection {
+	p := make(projection, 0, len(paths))
+	for _, path := range paths {
+		p = append(p, splitPointer(path))
+	}
+	return p
+}
+
+// splitPointer splits a JSON Pointer (RFC 6901) into its unescaped segments.
+// Only the empty pointer selects the whole value, `/` is the member named "".
+func splitPointer(path string) []string {
+	if path == "" {
+		return nil
+	}
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = pointerUnescaper.Replace(segment)
+	}
+	return segments
+}
+
+// complete reports whether one of the paths selects the whole value.
+func (p projection) complete() bool {
+	for _, path := range p {
+		if len(path) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// child returns the paths that continue into the member named key.
+func (p projection) child(key string) projection {
+	var next projection
+	for _, path := range p {
+		if len(path) > 0 && (path[0] == "*" || path[0] == key) {
+			next = append(next, path[1:])
+		}
+	}
+	return next
+}
+
+type projectionParser struct {
+	*jsonParser
+	tokens *indexLexer
+}
+
+// ParseWithProjection parses only the parts of json selected by paths, JSON
+// Pointers where a `*` segment matches any member or element, for example
+// `/users/*/email`. Objects and arrays on the way to a selected value are
+// kept with only the selected members and elements, in document order; the
+// other values are skipped through the structural index without being
+// tokenized or validated.
+func ParseWithProjection(json string, paths ...string) (any, error) {
+	idx, err := BuildIndex(json)
+	if err != nil {
+		return nil, err
+	}
+	tokens := idx.tokens()
+	p := &projectionParser{jsonParser: newJsonParser(tokens), tokens: tokens}
+	result, _, err := p.parseProjected(newProjection(paths))
+	if err != nil {
+		return nil, err
+	}
+	if tokens.NextToken().Kind != TokenKindEOF {
+		return nil, p.invalidTokenError()
+	}
+	return result, nil
+}
+
+// parseProjected parses the next value and reports whether it was selected.
+func (p *projectionParser) parseProjected(paths projection) (any, bool, error) {
+	if paths.complete() {
+		value, err := p.parseValue()
+		return value, true, err
+	}
+	if !p.tokens.valueAhead() {
+		return nil, false, p.invalidTokenError()
+	}
+	switch p.tokens.token(p.tokens.i).Kind {
+	case TokenKindBraceOpen:
+		p.tokens.NextToken()
+		value, err := p.parseProjectedObject(paths)
+		return value, true, err
+	case TokenKindBracketOpen:
+		p.tokens.NextToken()
+		value, err := p.parseProjectedArray(paths)
+		return value, true, err
+	default:
+		p.tokens.skipValue()
+		return nil, false, nil
+	}
+}
+
+func (p *projectionParser) parseProjectedObject(paths projection) (any, error) {
+	obj := make(map[string]any)
+	for first := true; ; first = false {
+		keyToken := p.tokens.NextToken()
+		if first && keyToken.Kind == TokenKindBraceClose {
+			return obj, nil
+		}
+		if keyToken.Kind != TokenKindString || p.tokens.NextToken().Kind != TokenKindColon {
+			return nil, p.invalidTokenError()
+		}
+		key := unquoteString(keyToken.Value)
+		if next := paths.child(key); len(next) > 0 {
+			value, selected, err := p.parseProjected(next)
+			if err != nil {
+				return nil, err
+			}
+			if selected {
+				obj[key] = value
+			}
+		} else if !p.tokens.valueAhead() {
+			return nil, p.invalidTokenError()
+		} else {
+			p.tokens.skipValue()
+		}
+
+		switch p.tokens.NextToken().Kind {
+		case TokenKindBraceClose:
+			return obj, nil
+		case TokenKindComma:
+			continue
+		default:
+			return nil, p.invalidTokenError()
+		}
+	}
+}
+
+func (p *projectionParser) parseProjectedArray(paths projection) (any, error) {
+	obj := make([]any, 0)
+	for i := 0; ; i++ {
+		if i == 0 && p.tokens.token(p.tokens.i).Kind == TokenKindBracketClose {
+			p.tokens.NextToken()
+			return obj, nil
+		}
+		if next := paths.child(strconv.Itoa(i)); len(next) > 0 {
+			value, selected, err := p.parseProjected(next)
+			if err != nil {
+				return nil, err
+			}
+			if selected {
+				obj = append(obj, value)
+			}
+		} else if !p.tokens.valueAhead() {
+			return nil, p.invalidTokenError()
+		} else {
+			p.tokens.skipValue()
+		}
+
+		switch p.tokens.NextToken().Kind {
+		case TokenKindBracketClose:
+			return obj, nil
+		case TokenKindComma:
+			continue
+		default:
+			return nil, p.invalidTokenError()
+		}
+	}
+}
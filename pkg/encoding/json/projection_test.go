@@ -0,0 +1,77 @@
+package json
+
+import (
+	"testing"
+)
+
+func TestParseWithProjection(t *testing.T) {
+	const input = `{
+		"users": [
+			{"name": "a", "email": "a@example.com", "tags": ["x", "y"]},
+			{"name": "b", "tags": []},
+			{"name": "c", "email": "c@example.com", "nested": {"deep": [1, 2, 3]}}
+		],
+		"meta": {"count": 3, "a/b": true, "t~": 1},
+		"payload": [{"huge": "value"}, 1, 2, 3],
+		"": {"": "empty"}
+	}`
+	type TestCase struct {
+		Paths []string
+		Out   any
+	}
+	cases := []TestCase{
+		{Paths: []string{""}, Out: mustParse(input)},
+		{Paths: []string{"/meta/count"}, Out: map[string]any{"meta": map[string]any{"count": 3.0}}},
+		{Paths: []string{"/users/*/email"}, Out: map[string]any{"users": []any{
+			map[string]any{"email": "a@example.com"},
+			map[string]any{},
+			map[string]any{"email": "c@example.com"},
+		}}},
+		{Paths: []string{"/users/1/name", "/meta/a~1b", "/meta/t~0"}, Out: map[string]any{
+			"users": []any{map[string]any{"name": "b"}},
+			"meta":  map[string]any{"a/b": true, "t~": 1.0},
+		}},
+		{Paths: []string{"/users/*/tags/0"}, Out: map[string]any{"users": []any{
+			map[string]any{"tags": []any{"x"}},
+			map[string]any{"tags": []any{}},
+			map[string]any{},
+		}}},
+		{Paths: []string{"/users/2/nested"}, Out: map[string]any{"users": []any{
+			map[string]any{"nested": map[string]any{"deep": []any{1.0, 2.0, 3.0}}},
+		}}},
+		{Paths: []string{"/users/*/email/x"}, Out: map[string]any{"users": []any{
+			map[string]any{}, map[string]any{}, map[string]any{},
+		}}},
+		{Paths: []string{"/"}, Out: map[string]any{"": map[string]any{"": "empty"}}},
+		{Paths: []string{"//"}, Out: map[string]any{"": map[string]any{"": "empty"}}},
+		{Paths: []string{"/meta/"}, Out: map[string]any{"meta": map[string]any{}}},
+		{Paths: []string{"/missing"}, Out: map[string]any{}},
+		{Paths: nil, Out: map[string]any{}},
+	}
+	for _, testCase := range cases {
+		value, err := ParseWithProjection(input, testCase.Paths...)
+		if err != nil {
+			t.Errorf("FAIL: paths %v error: %s", testCase.Paths, err)
+		}
+		if !isEqual(value, testCase.Out) {
+			t.Errorf("FAIL: paths %v expected %#v but got %#v", testCase.Paths, testCase.Out, value)
+		}
+	}
+}
+
+func TestParseWithProjection_Invalid(t *testing.T) {
+	inputs := []string{`{"a":1,}`, `{"a":}`, `{"a" 1}`, `[1,,2]`, `{"a":1} 2`, `{"b": tru}`, `[`, `{"a": "x}`}
+	for _, input := range inputs {
+		if value, err := ParseWithProjection(input, "/b"); err == nil {
+			t.Errorf("FAIL: input %s expected an error but got %#v", input, value)
+		}
+	}
+}
+
+func mustParse(input string) any {
+	value, err := ParseJson(input)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
@@ -0,0 +1,421 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Event is a token emitted by the PushParser. Value holds the token as it
+// appears in the input, quotes included for strings.
+type Event struct {
+	Token
+	// Offset is the position of the first byte of the token in the stream.
+	Offset int64
+}
+
+// pushWindowSize is the number of bytes a PushParser keeps of the previous
+// chunks, and shows of the current one, to quote the input around a syntax
+// error.
+const pushWindowSize = 4 << 10
+
+type pushState int8
+
+const (
+	pushNone pushState = iota
+	pushString
+	pushNumber
+	pushLiteral
+)
+
+type pushExpect int8
+
+const (
+	expectValue pushExpect = iota
+	expectValueOrEnd
+	expectKey
+	expectKeyOrEnd
+	expectColon
+	expectCommaOrEnd
+)
+
+type pushFrame struct {
+	obj map[string]any
+	arr []any
+	key string
+}
+
+// PushParser parses a stream of JSON values that arrives in chunks. Tokens
+// split between two chunks are kept until they are complete, so the input
+// never needs to be buffered as a whole. The stream may hold any number of
+// values one after the other, like NDJSON. Only a number or a literal followed
+// by another needs whitespace in between, as `1 2`, the others may touch.
+//
+// Syntax errors are *SyntaxError, located in the whole stream. Only the last
+// few KiB of the input are kept to quote the lines around them.
+type PushParser struct {
+	// OnEvent, when set, is called for every token as soon as it is complete.
+	OnEvent func(Event) error
+	// OnValue, when set, is called with every complete top-level value. When
+	// nil no value is built and the memory only grows with the nesting depth.
+	OnValue func(any) error
+
+	offset  int64
+	state   pushState
+	buf     []byte
+	start   int64
+	escape  int
+	literal string
+
+	// line and column locate the next byte, both from 0; startLine and
+	// startColumn locate the byte at start.
+	line, column           int
+	startLine, startColumn int
+	// window holds the end of the previous chunks and chunk the one being
+	// written, to quote them in syntax errors.
+	window []byte
+	chunk  []byte
+
+	expect     pushExpect
+	containers []byte
+	frames     []pushFrame
+	// separate is set after a top-level number or literal until whitespace
+	// follows it, another number or literal would run into it.
+	separate bool
+	err      error
+}
+
+func NewPushParser(onValue func(any) error) *PushParser {
+	return &PushParser{OnValue: onValue}
+}
+
+// Write feeds the next chunk of input. It returns the first syntax error, or
+// the first error returned by OnEvent or OnValue, and keeps returning it.
+func (p *PushParser) Write(chunk []byte) (int, error) {
+	if p.err != nil {
+		return 0, p.err
+	}
+	p.chunk = chunk
+	defer func() { p.chunk = nil }()
+	for i, c := range chunk {
+		if p.err = p.feed(c, p.offset+int64(i)); p.err != nil {
+			return i, p.err
+		}
+		switch {
+		case c == '\n':
+			p.line, p.column = p.line+1, 0
+		case utf8.RuneStart(c):
+			p.column++
+		}
+	}
+	p.offset += int64(len(chunk))
+	p.remember(chunk)
+	return len(chunk), nil
+}
+
+// remember keeps the last pushWindowSize bytes or more of the input. The
+// window grows up to twice that size between two copies.
+func (p *PushParser) remember(chunk []byte) {
+	if len(p.window)+len(chunk) > 2*pushWindowSize {
+		keep := p.window[max(0, len(p.window)-pushWindowSize+len(chunk)):]
+		p.window = p.window[:copy(p.window, keep)]
+		chunk = chunk[max(0, len(chunk)-pushWindowSize):]
+	}
+	p.window = append(p.window, chunk...)
+}
+
+// Close flushes the last token and checks that no value was left incomplete.
+func (p *PushParser) Close() error {
+	if p.err != nil {
+		return p.err
+	}
+	switch p.state {
+	case pushNumber:
+		p.err = p.flushNumber()
+	case pushString:
+		p.err = p.invalid(p.start, fmt.Sprintf("EOF: unterminated token starting at position %d", p.start))
+	case pushLiteral:
+		p.err = p.invalid(p.start, fmt.Sprintf("EOF: invalid literal at position %d, expected `%s`", p.start, p.literal))
+	}
+	if p.err == nil && (len(p.containers) > 0 || p.expect != expectValue) {
+		p.err = p.syntaxError(p.offset, NewToken(TokenKindEOF, "EOF"), "EOF: end of file")
+	}
+	return p.err
+}
+
+func (p *PushParser) feed(c byte, offset int64) error {
+	switch p.state {
+	case pushString:
+		p.buf = append(p.buf, c)
+		switch {
+		case p.escape == 1:
+			if !isEscapable(rune(c)) {
+				return p.invalid(p.start, fmt.Sprintf("invalid escape sequence in string at position %d", offset))
+			}
+			p.escape = 0
+			if c == 'u' {
+				p.escape = 2
+			}
+		case p.escape > 1:
+			if !isHexDigit(rune(c)) {
+				return p.invalid(p.start, fmt.Sprintf("invalid unicode escape in string at position %d", offset))
+			}
+			if p.escape++; p.escape == 6 {
+				p.escape = 0
+			}
+		case isEscape(rune(c)):
+			p.escape = 1
+		case isQuote(rune(c)):
+			p.state = pushNone
+			return p.token(TokenKindString, string(p.buf), p.start)
+		case c < 0x20:
+			return p.invalid(p.start, fmt.Sprintf("unescaped control character %U in string at position %d", c, offset))
+		}
+		return nil
+	case pushLiteral:
+		p.buf = append(p.buf, c)
+		if c != p.literal[len(p.buf)-1] {
+			return p.invalid(p.start, fmt.Sprintf("invalid literal at position %d, expected `%s`", p.start, p.literal))
+		}
+		if len(p.buf) < len(p.literal) {
+			return nil
+		}
+		p.state = pushNone
+		if p.literal == "null" {
+			return p.token(TokenKindNull, p.literal, p.start)
+		}
+		return p.token(TokenKindBoolean, p.literal, p.start)
+	case pushNumber:
+		switch {
+		case isDigit(rune(c)), c == '-', c == '+', c == '.', c == 'e', c == 'E':
+			p.buf = append(p.buf, c)
+			return nil
+		}
+		if err := p.flushNumber(); err != nil {
+			return err
+		}
+	}
+
+	switch r := rune(c); {
+	case isWhitespace(r):
+		p.separate = false
+		return nil
+	case p.separate && (isNumberStart(r) || c == 't' || c == 'f' || isNullStart(r)):
+		err := p.invalid(offset, fmt.Sprintf("missing whitespace between top-level values at position %d", offset))
+		err.Hint = "top-level numbers and literals must be separated by whitespace"
+		return err
+	case isObjectStart(r):
+		return p.token(TokenKindBraceOpen, "{", offset)
+	case isObjectEnd(r):
+		return p.token(TokenKindBraceClose, "}", offset)
+	case isArrayStart(r):
+		return p.token(TokenKindBracketOpen, "[", offset)
+	case isArrayEnd(r):
+		return p.token(TokenKindBracketClose, "]", offset)
+	case isColon(r):
+		return p.token(TokenKindColon, ":", offset)
+	case isComma(r):
+		return p.token(TokenKindComma, ",", offset)
+	case isQuote(r):
+		p.begin(pushString, c, offset)
+	case isNumberStart(r):
+		p.begin(pushNumber, c, offset)
+	case c == 't', c == 'f', isNullStart(r):
+		p.begin(pushLiteral, c, offset)
+		switch c {
+		case 't':
+			p.literal = "true"
+		case 'f':
+			p.literal = "false"
+		default:
+			p.literal = "null"
+		}
+	default:
+		return p.invalid(offset, fmt.Sprintf("Invalid token `%c` at position %d", c, offset))
+	}
+	return nil
+}
+
+func (p *PushParser) begin(state pushState, c byte, offset int64) {
+	p.state = state
+	p.buf = append(p.buf[:0], c)
+	p.start = offset
+	p.startLine, p.startColumn = p.line, p.column
+}
+
+func (p *PushParser) flushNumber() error {
+	p.state = pushNone
+	if !isValidNumber(string(p.buf)) {
+		return p.invalid(p.start, fmt.Sprintf("invalid number `%s` at position %d", p.buf, p.start))
+	}
+	return p.token(TokenKindNumber, string(p.buf), p.start)
+}
+
+// token checks that the token is allowed at this point, emits its event and
+// builds the values.
+func (p *PushParser) token(kind TokenKind, value string, offset int64) error {
+	expect := p.expect
+	switch {
+	case expect == expectColon:
+		if kind != TokenKindColon {
+			return p.unexpected(kind, value, offset)
+		}
+		p.expect = expectValue
+	case expect == expectKey || expect == expectKeyOrEnd:
+		if expect == expectKeyOrEnd && kind == TokenKindBraceClose {
+			break
+		}
+		if kind != TokenKindString {
+			return p.unexpected(kind, value, offset)
+		}
+		p.expect = expectColon
+	case expect == expectCommaOrEnd:
+		if kind == TokenKindComma {
+			if p.containers[len(p.containers)-1] == '{' {
+				p.expect = expectKey
+			} else {
+				p.expect = expectValue
+			}
+			break
+		}
+		if kind != TokenKindBraceClose && kind != TokenKindBracketClose {
+			return p.unexpected(kind, value, offset)
+		}
+	default:
+		if expect == expectValueOrEnd && kind == TokenKindBracketClose {
+			break
+		}
+		switch kind {
+		case TokenKindComma, TokenKindColon, TokenKindBraceClose, TokenKindBracketClose:
+			return p.unexpected(kind, value, offset)
+		}
+	}
+
+	switch kind {
+	case TokenKindBraceClose, TokenKindBracketClose:
+		if open := p.containers[len(p.containers)-1]; byte(value[0]) != open+2 {
+			return p.unexpected(kind, value, offset)
+		}
+	}
+
+	if p.OnEvent != nil {
+		if err := p.OnEvent(Event{Token: NewToken(kind, value), Offset: offset}); err != nil {
+			return err
+		}
+	}
+
+	p.separate = len(p.containers) == 0 && (kind == TokenKindNumber || kind == TokenKindBoolean || kind == TokenKindNull)
+	switch kind {
+	case TokenKindColon, TokenKindComma:
+		return nil
+	case TokenKindString:
+		if p.expect == expectColon {
+			if p.OnValue != nil {
+				p.frames[len(p.frames)-1].key = unquoteString(value)
+			}
+			return nil
+		}
+		return p.valueDone(unquoteString(value))
+	case TokenKindNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			e := p.invalid(offset, fmt.Sprintf("invalid number `%s` at position %d: %v", value, offset, errors.Unwrap(err)))
+			e.Hint = numberRangeHint
+			return e
+		}
+		return p.valueDone(number)
+	case TokenKindBoolean:
+		return p.valueDone(value == "true")
+	case TokenKindNull:
+		return p.valueDone(nil)
+	case TokenKindBraceOpen, TokenKindBracketOpen:
+		p.containers = append(p.containers, value[0])
+		if kind == TokenKindBraceOpen {
+			p.expect = expectKeyOrEnd
+		} else {
+			p.expect = expectValueOrEnd
+		}
+		if p.OnValue != nil {
+			frame := pushFrame{}
+			if kind == TokenKindBraceOpen {
+				frame.obj = make(map[string]any)
+			} else {
+				frame.arr = make([]any, 0)
+			}
+			p.frames = append(p.frames, frame)
+		}
+		return nil
+	default:
+		p.containers = p.containers[:len(p.containers)-1]
+		var container any
+		if p.OnValue != nil {
+			frame := p.frames[len(p.frames)-1]
+			p.frames = p.frames[:len(p.frames)-1]
+			if frame.obj != nil {
+				container = frame.obj
+			} else {
+				container = frame.arr
+			}
+		}
+		return p.valueDone(container)
+	}
+}
+
+func (p *PushParser) valueDone(value any) error {
+	if len(p.containers) == 0 {
+		p.expect = expectValue
+		if p.OnValue != nil {
+			return p.OnValue(value)
+		}
+		return nil
+	}
+	p.expect = expectCommaOrEnd
+	if p.OnValue != nil {
+		frame := &p.frames[len(p.frames)-1]
+		if frame.obj != nil {
+			frame.obj[frame.key] = value
+		} else {
+			frame.arr = append(frame.arr, value)
+		}
+	}
+	return nil
+}
+
+func (p *PushParser) unexpected(kind TokenKind, value string, offset int64) error {
+	msg := fmt.Sprintf("unexpected token at position %d: type= %s -> value= `%v`", offset, kind.toString(), value)
+	return p.syntaxError(offset, NewToken(kind, value), msg)
+}
+
+func (p *PushParser) invalid(offset int64, msg string) *SyntaxError {
+	return p.syntaxError(offset, NewToken(TokenKindInvalid, msg), msg)
+}
+
+// syntaxError returns an error with msg located at offset, which is either
+// the byte being fed or the start of the token in progress.
+func (p *PushParser) syntaxError(offset int64, token Token, msg string) *SyntaxError {
+	line, column := p.line, p.column
+	if offset == p.start {
+		line, column = p.startLine, p.startColumn
+	}
+
+	// quote the window and the start of the chunk, from the first full line
+	from := p.offset - int64(len(p.window))
+	end := min(max(0, int(offset-p.offset)+pushWindowSize/4), len(p.chunk))
+	input := string(p.window) + string(p.chunk[:end])
+	if from > 0 {
+		if i := strings.IndexByte(input[:max(0, int(offset-from))], '\n'); i >= 0 {
+			input, from = input[i+1:], from+int64(i+1)
+		}
+	}
+	if offset < from {
+		// the token started before the window
+		return &SyntaxError{msg: msg, Offset: int(offset), Line: line + 1, Column: column + 1}
+	}
+	e := newSyntaxError(input, int(offset-from), token, msg)
+	e.firstLine += line + 1 - e.Line
+	e.skipped = column + 1 - e.Column
+	e.Offset, e.Line, e.Column = int(offset), line+1, column+1
+	return e
+}
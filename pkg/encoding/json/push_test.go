@@ -0,0 +1,192 @@
+package json
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// pushAll feeds input in chunks of the given size and collects the values.
+func pushAll(input string, chunkSize int) ([]any, error) {
+	values := make([]any, 0)
+	p := NewPushParser(func(value any) error {
+		values = append(values, value)
+		return nil
+	})
+	for i := 0; i < len(input); i += chunkSize {
+		if _, err := p.Write([]byte(input[i:min(i+chunkSize, len(input))])); err != nil {
+			return values, err
+		}
+	}
+	return values, p.Close()
+}
+
+func TestPushParser_Conformance(t *testing.T) {
+	for _, chunkSize := range []int{1, 3, 64} {
+		for name, input := range conformanceAccept {
+			expected, _ := ParseJson(input)
+			values, err := pushAll(input, chunkSize)
+			if err != nil || len(values) != 1 {
+				t.Errorf("FAIL: %s: chunk size %d: expected one value, got %d (%v)", name, chunkSize, len(values), err)
+			} else if !isEqual(expected, values[0]) {
+				t.Errorf("FAIL: %s: chunk size %d: expected %#v but got %#v", name, chunkSize, expected, values[0])
+			}
+		}
+		for name, input := range conformanceReject {
+			if values, err := pushAll(input, chunkSize); err == nil && len(values) == 1 {
+				t.Errorf("FAIL: %s: chunk size %d: expected the document to be rejected, got %#v", name, chunkSize, values)
+			}
+		}
+	}
+}
+
+func TestPushParser_Stream(t *testing.T) {
+	input := readLocalFile("small-file.json") + "\n" + readLocalFile("something.json") + "\n1 \"two\" [3]\t{}\n"
+	expected := []any{
+		mustParse(readLocalFile("small-file.json")),
+		mustParse(readLocalFile("something.json")),
+		1.0, "two", []any{3.0}, map[string]any{},
+	}
+	for _, chunkSize := range []int{1, 7, 4096} {
+		values, err := pushAll(input, chunkSize)
+		if err != nil {
+			t.Errorf("FAIL: chunk size %d error: %s", chunkSize, err)
+		}
+		if !isEqual(expected, values) {
+			t.Errorf("FAIL: chunk size %d: unexpected values", chunkSize)
+		}
+	}
+}
+
+func TestPushParser_Events(t *testing.T) {
+	const input = `{"a": [1.5, true], "b\"": null}`
+	expected := []Event{
+		{NewToken(TokenKindBraceOpen, "{"), 0},
+		{NewToken(TokenKindString, `"a"`), 1},
+		{NewToken(TokenKindColon, ":"), 4},
+		{NewToken(TokenKindBracketOpen, "["), 6},
+		{NewToken(TokenKindNumber, "1.5"), 7},
+		{NewToken(TokenKindComma, ","), 10},
+		{NewToken(TokenKindBoolean, "true"), 12},
+		{NewToken(TokenKindBracketClose, "]"), 16},
+		{NewToken(TokenKindComma, ","), 17},
+		{NewToken(TokenKindString, `"b\""`), 19},
+		{NewToken(TokenKindColon, ":"), 24},
+		{NewToken(TokenKindNull, "null"), 26},
+		{NewToken(TokenKindBraceClose, "}"), 30},
+	}
+	events := make([]Event, 0)
+	p := &PushParser{OnEvent: func(event Event) error {
+		events = append(events, event)
+		return nil
+	}}
+	for i := 0; i < len(input); i += 2 {
+		if _, err := p.Write([]byte(input[i:min(i+2, len(input))])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !isEqual(expected, events) {
+		t.Errorf("FAIL: expected %v but got %v", expected, events)
+	}
+}
+
+func TestPushParser_TopLevelValues(t *testing.T) {
+	accept := map[string]int{
+		"1 2":            2,
+		"{}\n{}":         2,
+		"[1]\r\n[2]\n":   2,
+		"\"a\" \"b\"":    2,
+		"true\tfalse":    2,
+		" null ":         1,
+		"":               0,
+		"12":             1,
+		"{\"a\": [1]}\n": 1,
+		// only numbers and literals run into each other
+		"{\"a\":1}{\"a\":2}": 2,
+		"[1][2]":             2,
+		"{}{}":               2,
+		"\"a\"\"b\"":         2,
+		"1\"a\"":             2,
+		"\"a\"1":             2,
+		"1[2]":               2,
+		"[1]2":               2,
+		"null{}":             2,
+		"{}null":             2,
+	}
+	reject := map[string]string{
+		"truefalse": "missing whitespace between top-level values at position 4",
+		"1true":     "missing whitespace between top-level values at position 1",
+		"null1":     "missing whitespace between top-level values at position 4",
+		"[1]2 3-4":  "invalid number `3-4` at position 5",
+		"-1-2":      "invalid number `-1-2` at position 0",
+		"1,2":       "unexpected token at position 1: type= TokenKindComma -> value= `,`",
+		"{} ,{}":    "unexpected token at position 3: type= TokenKindComma -> value= `,`",
+	}
+	for _, chunkSize := range []int{1, 2, 64} {
+		for input, count := range accept {
+			if values, err := pushAll(input, chunkSize); err != nil || len(values) != count {
+				t.Errorf("FAIL: %q: chunk size %d: expected %d values but got %v %v", input, chunkSize, count, values, err)
+			}
+		}
+		for input, expected := range reject {
+			if values, err := pushAll(input, chunkSize); err == nil || err.Error() != expected {
+				t.Errorf("FAIL: %q: chunk size %d: expected %q but got %v %v", input, chunkSize, expected, values, err)
+			}
+		}
+	}
+}
+
+func TestPushParser_SyntaxError(t *testing.T) {
+	inputs := []string{
+		"{\"a\":\n 1,\n}",
+		"[10, 20, {\"a\" 1}]",
+		"[\n 1,\n 2,\n]",
+		"  {\n\"żółw\": nul}",
+		"{\"a\": \"\\x\"}",
+		"{\"a\": tru",
+		"[1, 2",
+		"[1}",
+		"[+1]",
+		"{\"a\": \"abc\n}",
+	}
+	for _, input := range inputs {
+		_, err := ParseJson(input)
+		expected := err.(*SyntaxError)
+		for _, chunkSize := range []int{1, 3, 64} {
+			var syntaxErr *SyntaxError
+			if _, err := pushAll(input, chunkSize); !errors.As(err, &syntaxErr) {
+				t.Errorf("FAIL: %q: chunk size %d: expected a SyntaxError but got %v", input, chunkSize, err)
+			} else if syntaxErr.Offset != expected.Offset || syntaxErr.Line != expected.Line || syntaxErr.Column != expected.Column {
+				t.Errorf("FAIL: %q: chunk size %d: expected %d:%d but got %d:%d", input, chunkSize, expected.Line, expected.Column, syntaxErr.Line, syntaxErr.Column)
+			} else if chunkSize == 64 && syntaxErr.Hint != expected.Hint {
+				t.Errorf("FAIL: %q: expected the hint %q but got %q", input, expected.Hint, syntaxErr.Hint)
+			}
+		}
+	}
+
+	// only the end of the stream is quoted
+	input := strings.Repeat(`{"a": 1}`+"\n", 2000) + "{\"a\":\n 1,\n}"
+	_, err := pushAll(input, 1000)
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Line != 2003 || syntaxErr.Column != 1 || syntaxErr.Offset != len(input)-1 {
+		t.Fatalf("FAIL: expected an error at 2003:1 but got %v", err)
+	}
+	expected := "2001 | {\"a\":\n2002 |  1,\n2003 | }\n     | ^ trailing comma before '}'\n"
+	if !strings.HasSuffix(syntaxErr.Pretty(), expected) {
+		t.Errorf("FAIL: expected the last lines but got\n%s", syntaxErr.Pretty())
+	}
+
+	// a long line is quoted from the middle
+	input = "[\n" + strings.Repeat(`"żółw",`, 5000) + "}"
+	_, err = pushAll(input, 1000)
+	if !errors.As(err, &syntaxErr) || syntaxErr.Line != 2 || syntaxErr.Column != 35001 {
+		t.Fatalf("FAIL: expected an error at 2:35001 but got %v", err)
+	}
+	lines := strings.Split(syntaxErr.Pretty(), "\n")
+	if !strings.HasPrefix(lines[1], "2 | ...") || strings.Index(lines[2], "^") != len([]rune(lines[1]))-1 {
+		t.Errorf("FAIL: expected a caret under the brace but got\n%s", syntaxErr.Pretty())
+	}
+}
@@ -0,0 +1,257 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RawValue holds the original bytes of a JSON value. Its parsing is deferred
+// until the value, or one of its members, is needed.
+type RawValue []byte
+
+// MarshalJSON returns the original bytes unchanged.
+func (r RawValue) MarshalJSON() ([]byte, error) {
+	if len(r) == 0 {
+		return []byte("null"), nil
+	}
+	return r, nil
+}
+
+// UnmarshalJSON stores a copy of data.
+func (r *RawValue) UnmarshalJSON(data []byte) error {
+	*r = append((*r)[:0], data...)
+	return nil
+}
+
+// Parse parses the whole value.
+func (r RawValue) Parse() (any, error) {
+	return ParseJson(string(r))
+}
+
+// Lazy returns a *LazyObject or a *LazyArray for objects and arrays, and the
+// parsed value for everything else.
+func (r RawValue) Lazy() (any, error) {
+	idx, err := BuildIndex(string(r))
+	if err != nil {
+		return nil, err
+	}
+	if idx.Len() == 0 {
+		return nil, errors.New("EOF: end of file")
+	}
+	switch idx.input[idx.positions[0]] {
+	case '{':
+		return newLazyObject(r, idx)
+	case '[':
+		return newLazyArray(r, idx)
+	default:
+		return idx.Parse()
+	}
+}
+
+// Object returns a lazy view over an object value.
+func (r RawValue) Object() (*LazyObject, error) {
+	value, err := r.Lazy()
+	if err != nil {
+		return nil, err
+	}
+	if obj, ok := value.(*LazyObject); ok {
+		return obj, nil
+	}
+	return nil, fmt.Errorf("cannot convert type %T to object", value)
+}
+
+// Array returns a lazy view over an array value.
+func (r RawValue) Array() (*LazyArray, error) {
+	value, err := r.Lazy()
+	if err != nil {
+		return nil, err
+	}
+	if arr, ok := value.(*LazyArray); ok {
+		return arr, nil
+	}
+	return nil, fmt.Errorf("cannot convert type %T to array", value)
+}
+
+// LazyObject is an object whose member values are kept as RawValue and parsed
+// on first access. Only the keys are decoded when the view is created.
+type LazyObject struct {
+	raw    RawValue
+	keys   []string
+	values []RawValue
+	parsed map[string]any
+}
+
+func newLazyObject(raw RawValue, idx *StructuralIndex) (*LazyObject, error) {
+	obj := &LazyObject{raw: raw, parsed: make(map[string]any)}
+	err := idx.walkMembers(func(key string, start, end int) {
+		obj.keys = append(obj.keys, key)
+		obj.values = append(obj.values, raw[start:end:end])
+	})
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Keys returns the member names in document order, duplicates included.
+func (o *LazyObject) Keys() []string {
+	return o.keys
+}
+
+// Raw returns the original bytes of the member. With duplicated keys the last
+// member wins, like in ParseJson.
+func (o *LazyObject) Raw(key string) (RawValue, bool) {
+	for i := len(o.keys) - 1; i >= 0; i-- {
+		if o.keys[i] == key {
+			return o.values[i], true
+		}
+	}
+	return nil, false
+}
+
+// Get parses the member on first access and caches the result.
+func (o *LazyObject) Get(key string) (any, error) {
+	if value, ok := o.parsed[key]; ok {
+		return value, nil
+	}
+	raw, ok := o.Raw(key)
+	if !ok {
+		return nil, fmt.Errorf("key %s not found in object", key)
+	}
+	value, err := raw.Parse()
+	if err != nil {
+		return nil, err
+	}
+	o.parsed[key] = value
+	return value, nil
+}
+
+func (o *LazyObject) MarshalJSON() ([]byte, error) {
+	return o.raw, nil
+}
+
+// LazyArray is an array whose elements are kept as RawValue and parsed on
+// first access.
+type LazyArray struct {
+	raw    RawValue
+	values []RawValue
+	parsed []any
+	done   []bool
+}
+
+func newLazyArray(raw RawValue, idx *StructuralIndex) (*LazyArray, error) {
+	arr := &LazyArray{raw: raw}
+	err := idx.walkElements(func(start, end int) {
+		arr.values = append(arr.values, raw[start:end:end])
+	})
+	if err != nil {
+		return nil, err
+	}
+	arr.parsed = make([]any, len(arr.values))
+	arr.done = make([]bool, len(arr.values))
+	return arr, nil
+}
+
+func (a *LazyArray) Len() int {
+	return len(a.values)
+}
+
+// Raw returns the original bytes of the element.
+func (a *LazyArray) Raw(i int) (RawValue, error) {
+	if i < 0 || i >= len(a.values) {
+		return nil, fmt.Errorf("index out of range: %d (slice length: %d)", i, len(a.values))
+	}
+	return a.values[i], nil
+}
+
+// Get parses the element on first access and caches the result.
+func (a *LazyArray) Get(i int) (any, error) {
+	raw, err := a.Raw(i)
+	if err != nil {
+		return nil, err
+	}
+	if !a.done[i] {
+		if a.parsed[i], err = raw.Parse(); err != nil {
+			return nil, err
+		}
+		a.done[i] = true
+	}
+	return a.parsed[i], nil
+}
+
+func (a *LazyArray) MarshalJSON() ([]byte, error) {
+	return a.raw, nil
+}
+
+// walkMembers calls fn with the key and the value span of every member of the
+// object starting at the first entry. Values are skipped, not validated.
+func (idx *StructuralIndex) walkMembers(fn func(key string, start, end int)) error {
+	l := idx.tokens()
+	l.NextToken()
+	for first := true; ; first = false {
+		token := l.NextToken()
+		if first && token.Kind == TokenKindBraceClose {
+			break
+		}
+		if token.Kind != TokenKindString || l.NextToken().Kind != TokenKindColon || !l.valueAhead() {
+			return l.unexpected()
+		}
+		end, next := idx.valueEnd(l.i)
+		fn(unquoteString(token.Value), int(idx.positions[l.i]), end)
+		l.i = next
+		if l.NextToken().Kind == TokenKindBraceClose {
+			break
+		}
+		if l.currentToken.Kind != TokenKindComma {
+			return l.unexpected()
+		}
+	}
+	return l.expectEOF()
+}
+
+// walkElements calls fn with the span of every element of the array starting
+// at the first entry. Elements are skipped, not validated.
+func (idx *StructuralIndex) walkElements(fn func(start, end int)) error {
+	l := idx.tokens()
+	l.NextToken()
+	if l.token(l.i).Kind == TokenKindBracketClose {
+		l.i++
+		return l.expectEOF()
+	}
+	for {
+		if !l.valueAhead() {
+			return l.unexpected()
+		}
+		end, next := idx.valueEnd(l.i)
+		fn(int(idx.positions[l.i]), end)
+		l.i = next
+		if l.NextToken().Kind == TokenKindBracketClose {
+			return l.expectEOF()
+		}
+		if l.currentToken.Kind != TokenKindComma {
+			return l.unexpected()
+		}
+	}
+}
+
+// valueAhead reports whether the next entry starts a value. Otherwise the
+// entry is consumed so that it can be reported.
+func (l *indexLexer) valueAhead() bool {
+	switch l.token(l.i).Kind {
+	case TokenKindComma, TokenKindColon, TokenKindBraceClose, TokenKindBracketClose, TokenKindEOF:
+		l.NextToken()
+		return false
+	}
+	return true
+}
+
+func (l *indexLexer) unexpected() error {
+	return unexpected(l)
+}
+
+func (l *indexLexer) expectEOF() error {
+	if l.NextToken().Kind != TokenKindEOF {
+		return l.unexpected()
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package json
+
+import (
+	"testing"
+)
+
+func TestRawValue_Object(t *testing.T) {
+	const input = ` {"a": 1, "b": [true, {"c": null}], "a": "last", "d": {}} `
+	obj, err := RawValue(input).Object()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := obj.Keys(); !isEqual(keys, []string{"a", "b", "a", "d"}) {
+		t.Errorf("FAIL: unexpected keys %v", keys)
+	}
+	if raw, _ := obj.Raw("b"); string(raw) != `[true, {"c": null}]` {
+		t.Errorf("FAIL: unexpected raw value %s", raw)
+	}
+	if value, err := obj.Get("a"); err != nil || value != "last" {
+		t.Errorf("FAIL: expected the last duplicated key to win, got %v (%v)", value, err)
+	}
+	if value, err := obj.Get("d"); err != nil || !isEqual(value, map[string]any{}) {
+		t.Errorf("FAIL: unexpected value %v (%v)", value, err)
+	}
+	if _, err := obj.Get("missing"); err == nil {
+		t.Errorf("FAIL: expected an error for a missing key")
+	}
+	if out, _ := obj.MarshalJSON(); string(out) != input {
+		t.Errorf("FAIL: expected the original bytes but got %s", out)
+	}
+}
+
+func TestRawValue_Array(t *testing.T) {
+	arr, err := RawValue(`[1, "two" , [3], {"four": 4}]`).Array()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arr.Len() != 4 {
+		t.Fatalf("FAIL: expected 4 elements but got %d", arr.Len())
+	}
+	expected := []string{`1`, `"two"`, `[3]`, `{"four": 4}`}
+	for i, e := range expected {
+		if raw, _ := arr.Raw(i); string(raw) != e {
+			t.Errorf("FAIL: element %d expected %s but got %s", i, e, raw)
+		}
+	}
+	if value, err := arr.Get(1); err != nil || value != "two" {
+		t.Errorf("FAIL: unexpected value %v (%v)", value, err)
+	}
+	if _, err := arr.Get(4); err == nil {
+		t.Errorf("FAIL: expected an error for an out of range index")
+	}
+
+	empty, err := RawValue(` [ ] `).Array()
+	if err != nil || empty.Len() != 0 {
+		t.Errorf("FAIL: expected an empty array, got %v", err)
+	}
+}
+
+func TestRawValue_Invalid(t *testing.T) {
+	for _, input := range []string{``, `{"a"}`, `{"a":}`, `{"a":1,}`, `[1,]`, `[,1]`, `[1 2]`, `{"a":1} x`, `[1] [2]`, `{1:2}`} {
+		if value, err := RawValue(input).Lazy(); err == nil {
+			t.Errorf("FAIL: input %s expected an error but got %#v", input, value)
+		}
+	}
+	if _, err := RawValue(`"a"`).Object(); err == nil {
+		t.Errorf("FAIL: expected an error converting a string to an object")
+	}
+	if value, err := RawValue(` 12 `).Lazy(); err != nil || value != 12.0 {
+		t.Errorf("FAIL: expected 12 but got %v (%v)", value, err)
+	}
+}
@@ -0,0 +1,399 @@
+package json
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// RedactAction is what a Redactor does with a value matched by a rule.
+type RedactAction int8
+
+const (
+	// RedactDrop removes the member, or the element, from its parent.
+	RedactDrop RedactAction = iota + 1
+	// RedactMask replaces the value with the string "***".
+	RedactMask
+	// RedactHash replaces the value with "sha256:" and the hex SHA-256 of the
+	// content of a string, or of the compact text of any other value.
+	RedactHash
+	// RedactReplace replaces the value with RedactRule.Value.
+	RedactReplace
+)
+
+// redactMask is the value written by RedactMask.
+const redactMask = `"***"`
+
+// RedactRule selects values either by Key, the name of a member at any depth
+// compared without case, or by Path, a JSON Pointer from the top-level value
+// where a `*` segment matches any member or element, like `/users/*/token`.
+// As in RFC 6901, `/` is the member named "", not the top-level value. The
+// first matching rule applies, and nothing inside a matched value is matched.
+type RedactRule struct {
+	Key    string
+	Path   string
+	Action RedactAction
+	// Value is the replacement written by RedactReplace.
+	Value any
+
+	segments    []string
+	replacement []byte
+}
+
+func (rule *RedactRule) matches(path []string, key string, isKey bool) bool {
+	if rule.Key != "" {
+		return isKey && strings.EqualFold(rule.Key, key)
+	}
+	if len(path) != len(rule.segments) {
+		return false
+	}
+	for i, segment := range rule.segments {
+		if segment != "*" && segment != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type redactFrame struct {
+	object bool
+	// wrote tells whether a member or element was kept. Until then, the comma
+	// after a dropped one is dropped too.
+	wrote bool
+	// key is set between a key and its value.
+	key   string
+	isKey bool
+	// index is the index of the next element.
+	index int
+}
+
+// Redactor rewrites a stream of JSON values, applying its rules and copying
+// everything else byte for byte, whitespace and escapes included. Like the
+// PushParser it reads the input in chunks of any size and never builds the
+// values: its memory grows with the nesting depth and the longest token, not
+// with the size of the input. A dropped member is removed with its key and
+// one of the commas around it.
+//
+//	r, err := json.NewRedactor(os.Stdout,
+//		json.RedactRule{Key: "password", Action: json.RedactDrop},
+//		json.RedactRule{Path: "/users/*/email", Action: json.RedactHash})
+//	_, err = io.Copy(r, logs)
+//	err = r.Close()
+type Redactor struct {
+	// HashKey, when set, makes RedactHash write HMAC-SHA256 digests, so
+	// that short values cannot be recovered by hashing guesses.
+	HashKey []byte
+
+	w      io.Writer
+	rules  []RedactRule
+	parser *PushParser
+	out    []byte
+	frames []redactFrame
+	path   []string
+
+	// pending holds the input from offset base on that is not written yet,
+	// and end is the offset past the last token.
+	pending []byte
+	base    int64
+	end     int64
+	// held is set from the comma or the key before a value until a rule is
+	// looked up for it, since a dropped value takes them along.
+	held bool
+	// trim drops the input up to the next token, after the comma that
+	// followed a dropped value.
+	trim bool
+
+	// skip counts the open containers of a value being replaced or dropped,
+	// rule is the rule applied to it and start is where it starts.
+	skip   int
+	rule   *RedactRule
+	start  int64
+	hasher hash.Hash
+}
+
+func NewRedactor(w io.Writer, rules ...RedactRule) (*Redactor, error) {
+	r := &Redactor{w: w, rules: make([]RedactRule, len(rules))}
+	copy(r.rules, rules)
+	for i := range r.rules {
+		rule := &r.rules[i]
+		switch {
+		case (rule.Key == "") == (rule.Path == ""):
+			return nil, fmt.Errorf("redact rule %d: exactly one of Key and Path must be set", i)
+		case rule.Path != "" && rule.Path[0] != '/':
+			return nil, fmt.Errorf("redact rule %d: invalid JSON Pointer %q", i, rule.Path)
+		case rule.Action < RedactDrop || rule.Action > RedactReplace:
+			return nil, fmt.Errorf("redact rule %d: invalid action %d", i, rule.Action)
+		}
+		rule.segments = splitPointer(rule.Path)
+		if rule.Action == RedactReplace {
+			replacement, err := Marshal(rule.Value)
+			if err != nil {
+				return nil, fmt.Errorf("redact rule %d: %w", i, err)
+			}
+			rule.replacement = replacement
+		}
+	}
+	r.parser = &PushParser{OnEvent: r.event}
+	return r, nil
+}
+
+// Redact copies the JSON values read from src to dst, applying the rules.
+func Redact(dst io.Writer, src io.Reader, rules ...RedactRule) error {
+	r, err := NewRedactor(dst, rules...)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(r, src); err != nil {
+		return err
+	}
+	return r.Close()
+}
+
+// Write feeds the next chunk of the input and writes the output produced so
+// far. It returns the first syntax error and keeps returning it.
+func (r *Redactor) Write(chunk []byte) (int, error) {
+	r.pending = append(r.pending, chunk...)
+	n, err := r.parser.Write(chunk)
+	if err != nil {
+		return n, err
+	}
+	// the rest of the chunk waits for the token that decides its fate
+	switch {
+	case r.skip > 0:
+		r.skipTo(r.end)
+	case !r.held && !r.trim:
+		r.copyTo(r.end)
+	}
+	return n, r.flush()
+}
+
+// Close checks that the last value is complete and writes the rest of the
+// output.
+func (r *Redactor) Close() error {
+	if err := r.parser.Close(); err != nil {
+		return err
+	}
+	end := r.base + int64(len(r.pending))
+	if r.trim {
+		r.skipTo(end)
+	} else {
+		r.copyTo(end)
+	}
+	return r.flush()
+}
+
+// copyTo writes the input up to offset.
+func (r *Redactor) copyTo(offset int64) {
+	n := int(offset - r.base)
+	r.out = append(r.out, r.pending[:n]...)
+	r.skipTo(offset)
+}
+
+// skipTo drops the input up to offset.
+func (r *Redactor) skipTo(offset int64) {
+	n := int(offset - r.base)
+	r.pending = r.pending[:copy(r.pending, r.pending[n:])]
+	r.base = offset
+}
+
+func (r *Redactor) flush() error {
+	if len(r.out) == 0 {
+		return nil
+	}
+	_, err := r.w.Write(r.out)
+	r.out = r.out[:0]
+	return err
+}
+
+func (r *Redactor) event(e Event) error {
+	r.end = e.Offset + int64(len(e.Value))
+	if r.skip > 0 {
+		return r.skipped(e)
+	}
+	if r.trim {
+		r.skipTo(e.Offset)
+		r.trim = false
+	}
+
+	frame := r.current()
+	switch e.Kind {
+	case TokenKindColon:
+		return nil
+	case TokenKindComma:
+		if !frame.wrote {
+			// only dropped values precede it
+			r.skipTo(r.end)
+			r.trim = true
+			return nil
+		}
+		r.copyTo(e.Offset)
+		r.held = true
+		return nil
+	case TokenKindBraceClose, TokenKindBracketClose:
+		r.frames = r.frames[:len(r.frames)-1]
+		if len(r.frames) > 0 {
+			r.path = r.path[:len(r.path)-1]
+		}
+		r.valueDone(true)
+		return nil
+	case TokenKindString:
+		if frame != nil && frame.object && !frame.isKey {
+			frame.key, frame.isKey = unquoteString(e.Value), true
+			if !r.held {
+				r.copyTo(e.Offset)
+				r.held = true
+			}
+			return nil
+		}
+	}
+
+	// a value starts: find the first rule matching it
+	if !r.held {
+		r.copyTo(e.Offset)
+	}
+	key, isKey := frame.member()
+	path, segment := r.path, key
+	if frame != nil {
+		if !isKey {
+			segment = strconv.Itoa(frame.index)
+		}
+		path = append(path, segment)
+	}
+	r.rule = nil
+	for i := range r.rules {
+		if r.rules[i].matches(path, key, isKey) {
+			r.rule = &r.rules[i]
+			break
+		}
+	}
+
+	if r.rule == nil {
+		r.held = false
+		switch e.Kind {
+		case TokenKindBraceOpen, TokenKindBracketOpen:
+			if frame != nil {
+				r.path = append(r.path, segment)
+			}
+			r.frames = append(r.frames, redactFrame{object: e.Kind == TokenKindBraceOpen})
+		default:
+			r.valueDone(true)
+		}
+		return nil
+	}
+
+	r.start = e.Offset
+	if r.rule.Action == RedactHash {
+		r.startHash()
+	}
+	switch e.Kind {
+	case TokenKindBraceOpen, TokenKindBracketOpen:
+		// the container is skipped as it arrives, so settle what precedes it
+		if r.rule.Action == RedactDrop {
+			r.skipTo(r.start)
+		} else {
+			r.copyTo(r.start)
+		}
+		r.skip = 1
+		r.hash(e.Value)
+	case TokenKindString:
+		r.hash(unquoteString(e.Value))
+		r.replace()
+	default:
+		r.hash(e.Value)
+		r.replace()
+	}
+	return nil
+}
+
+// skipped handles the tokens of a container being replaced or dropped.
+func (r *Redactor) skipped(e Event) error {
+	r.hash(e.Value)
+	switch e.Kind {
+	case TokenKindBraceOpen, TokenKindBracketOpen:
+		r.skip++
+	case TokenKindBraceClose, TokenKindBracketClose:
+		if r.skip--; r.skip == 0 {
+			r.replace()
+		}
+	}
+	return nil
+}
+
+// current returns the innermost container, or nil at the top level.
+func (r *Redactor) current() *redactFrame {
+	if len(r.frames) == 0 {
+		return nil
+	}
+	return &r.frames[len(r.frames)-1]
+}
+
+// member returns the key of the value starting in the frame, and false when
+// it is an array element or a top-level value.
+func (f *redactFrame) member() (string, bool) {
+	if f == nil || !f.object {
+		return "", false
+	}
+	return f.key, true
+}
+
+// replace writes the replacement of the value matched by r.rule, which ends
+// at r.end. A dropped value takes the comma or the key held before it.
+func (r *Redactor) replace() {
+	rule := r.rule
+	r.rule, r.held = nil, false
+	if rule.Action == RedactDrop {
+		r.skipTo(r.end)
+		if len(r.frames) == 0 {
+			// the blank after a dropped top-level value goes with it
+			r.trim = true
+		}
+		r.valueDone(false)
+		return
+	}
+	if r.base < r.start {
+		r.copyTo(r.start)
+	}
+	r.skipTo(r.end)
+	switch rule.Action {
+	case RedactMask:
+		r.out = append(r.out, redactMask...)
+	case RedactHash:
+		r.out = append(r.out, `"sha256:`...)
+		r.out = hex.AppendEncode(r.out, r.hasher.Sum(nil))
+		r.out = append(r.out, '"')
+		r.hasher = nil
+	case RedactReplace:
+		r.out = append(r.out, rule.replacement...)
+	}
+	r.valueDone(true)
+}
+
+// valueDone moves past a value, kept or not, of the current container.
+func (r *Redactor) valueDone(written bool) {
+	if len(r.frames) == 0 {
+		return
+	}
+	frame := &r.frames[len(r.frames)-1]
+	frame.wrote = frame.wrote || written
+	frame.key, frame.isKey = "", false
+	frame.index++
+}
+
+func (r *Redactor) startHash() {
+	if r.HashKey != nil {
+		r.hasher = hmac.New(sha256.New, r.HashKey)
+	} else {
+		r.hasher = sha256.New()
+	}
+}
+
+func (r *Redactor) hash(s string) {
+	if r.hasher != nil {
+		r.hasher.Write([]byte(s))
+	}
+}
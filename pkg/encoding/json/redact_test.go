@@ -0,0 +1,125 @@
+package json
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func redactString(t *testing.T, input string, rules ...RedactRule) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := Redact(&out, strings.NewReader(input), rules...); err != nil {
+		t.Fatalf("FAIL: %s: %v", input, err)
+	}
+	return out.String()
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestRedact(t *testing.T) {
+	input := `{"user": "ann", "password": "secret", "Token": {"id": 1, "v": [1, 2]},
+		"sessions": [{"token": "a", "ip": "10.0.0.1"}, {"ip": "10.0.0.2", "token": "b"}],
+		"items": [1, 2, 3], "note": "keep \"me\" é"}`
+	rules := []RedactRule{
+		{Key: "password", Action: RedactDrop},
+		{Key: "token", Action: RedactMask},
+		{Path: "/sessions/*/ip", Action: RedactHash},
+		{Path: "/items/1", Action: RedactDrop},
+		{Path: "/user", Action: RedactReplace, Value: map[string]any{"redacted": true}},
+	}
+	expected := `{"user": {"redacted":true}, "Token": "***",
+		"sessions": [{"token": "***", "ip": "sha256:` + sha256Hex("10.0.0.1") + `"}, {"ip": "sha256:` + sha256Hex("10.0.0.2") + `", "token": "***"}],
+		"items": [1, 3], "note": "keep \"me\" é"}`
+	if actual := redactString(t, input, rules...); actual != expected {
+		t.Errorf("FAIL: expected\n%s\nbut got\n%s", expected, actual)
+	}
+
+	// the output parses to the input once redacted
+	value, err := ParseJson(redactString(t, input, rules...))
+	if err != nil || value.(map[string]any)["note"] != `keep "me" é` {
+		t.Errorf("FAIL: expected valid output but got %v %v", value, err)
+	}
+}
+
+func TestRedact_Edges(t *testing.T) {
+	cases := []struct {
+		In    string
+		Rules []RedactRule
+		Out   string
+	}{
+		{`{"a": 1, "b": 2}`, []RedactRule{{Key: "a", Action: RedactDrop}}, `{"b": 2}`},
+		{`{"a": 1, "b": 2}`, []RedactRule{{Key: "b", Action: RedactDrop}}, `{"a": 1}`},
+		{`{"a": 1, "b": 2, "c": 3}`, []RedactRule{{Key: "a", Action: RedactDrop}, {Key: "b", Action: RedactDrop}}, `{"c": 3}`},
+		{`{"a": {"x": [1, {"y": 2}]}}`, []RedactRule{{Key: "a", Action: RedactDrop}}, `{}`},
+		{`[[1], [2], [3]]`, []RedactRule{{Path: "/0", Action: RedactDrop}, {Path: "/2/0", Action: RedactMask}}, `[[2], ["***"]]`},
+		{`[{"a": 1}, {"a": 2}]`, []RedactRule{{Path: "/*/a", Action: RedactReplace, Value: nil}}, `[{"a": null}, {"a": null}]`},
+		{`{"a/b": 1, "m~n": 2}`, []RedactRule{{Path: "/a~1b", Action: RedactMask}, {Path: "/m~0n", Action: RedactMask}}, `{"a/b": "***", "m~n": "***"}`},
+		{`{"a": [1, 2]}`, []RedactRule{{Key: "a", Action: RedactHash}}, `{"a": "sha256:` + sha256Hex("[1,2]") + `"}`},
+		{`{"a": {"b": true}}`, []RedactRule{{Path: "/a/b", Action: RedactMask}, {Key: "b", Action: RedactDrop}}, `{"a": {"b": "***"}}`},
+		{`{"a": 1} [2] "s" 3`, []RedactRule{{Key: "a", Action: RedactMask}}, `{"a": "***"} [2] "s" 3`},
+		{` { } `, []RedactRule{{Key: "a", Action: RedactMask}}, ` { } `},
+		{`{"": 1, "b": {"": 2}}`, []RedactRule{{Path: "/", Action: RedactDrop}, {Path: "/b/", Action: RedactMask}}, `{"b": {"": "***"}}`},
+		// escapes and indentation are kept
+		{`{"k\u00e9y": "v", "n": "\u0041"}`, []RedactRule{{Key: "x", Action: RedactMask}}, `{"k\u00e9y": "v", "n": "\u0041"}`},
+		{"{\n  \"a\": 1,\n  \"b\": [\n    2\n  ]\n}\n", []RedactRule{{Key: "a", Action: RedactDrop}}, "{\n  \"b\": [\n    2\n  ]\n}\n"},
+		{"{\n  \"a\": 1,\n  \"b\": [\n    2\n  ]\n}\n", []RedactRule{{Key: "b", Action: RedactDrop}}, "{\n  \"a\": 1\n}\n"},
+		{"{\n  \"a\": 1,\n  \"b\": 2\n}\n", []RedactRule{{Key: "b", Action: RedactMask}}, "{\n  \"a\": 1,\n  \"b\": \"***\"\n}\n"},
+		{"{\"a\": 1}\n{\"b\": 2}\n{\"a\": 3}\n", []RedactRule{{Path: "/b", Action: RedactMask}}, "{\"a\": 1}\n{\"b\": \"***\"}\n{\"a\": 3}\n"},
+	}
+	for _, c := range cases {
+		if actual := redactString(t, c.In, c.Rules...); actual != c.Out {
+			t.Errorf("FAIL: %s: expected %q but got %q", c.In, c.Out, actual)
+		}
+	}
+}
+
+func TestRedactor_Chunks(t *testing.T) {
+	input := `{"log": "x", "secret": {"k": "vvvvvvvvvvvvvvvvvv"}, "n": 12345}` + "\n" + `{"secret": 1, "n": 6}`
+	var out bytes.Buffer
+	r, err := NewRedactor(&out, RedactRule{Key: "secret", Action: RedactHash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.HashKey = []byte("key")
+	for i := 0; i < len(input); i++ {
+		if _, err := r.Write([]byte{input[i]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], `{"log": "x", "secret": "sha256:`) || !strings.HasSuffix(lines[0], `", "n": 12345}`) {
+		t.Fatalf("FAIL: got %q", out.String())
+	}
+	if strings.Contains(lines[0], sha256Hex(`{"k":"vvvvvvvvvvvvvvvvvv"}`)) {
+		t.Errorf("FAIL: expected an HMAC rather than a plain hash")
+	}
+}
+
+func TestRedactor_Errors(t *testing.T) {
+	invalid := [][]RedactRule{
+		{{Action: RedactDrop}},
+		{{Key: "a", Path: "/a", Action: RedactDrop}},
+		{{Path: "a", Action: RedactDrop}},
+		{{Key: "a"}},
+		{{Key: "a", Action: RedactReplace, Value: func() {}}},
+	}
+	for _, rules := range invalid {
+		if _, err := NewRedactor(&bytes.Buffer{}, rules...); err == nil {
+			t.Errorf("FAIL: expected an error for %+v", rules)
+		}
+	}
+	for _, input := range []string{`{"a": }`, `[1, 2`, `{"a": "b`} {
+		if err := Redact(&bytes.Buffer{}, strings.NewReader(input), RedactRule{Key: "a", Action: RedactDrop}); err == nil {
+			t.Errorf("FAIL: expected an error for %q", input)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package json
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxInternedKeys bounds the keys a Parser remembers, so documents with
+// unbounded keys (ids used as keys) cannot grow it forever.
+const maxInternedKeys = 1 << 14
+
+// Parser parses documents one after the other while reusing its buffers. Like
+// ParseFile it lexes the input in place, so no rune slice is allocated and
+// strings without escapes are substrings of the input. Array elements and
+// object members are collected on stacks kept across documents, so every
+// container ends up in a single allocation of the right size.
+//
+// The zero value is ready to use. A Parser is not safe for concurrent use;
+// keep one per goroutine or share them through a sync.Pool:
+//
+//	var parsers = sync.Pool{New: func() any { return &json.Parser{InternKeys: true} }}
+//
+//	p := parsers.Get().(*json.Parser)
+//	value, err := p.ParseJson(body)
+//	parsers.Put(p)
+type Parser struct {
+	// InternKeys makes equal object keys share one string, copied out of the
+	// input, across all the documents parsed by this Parser.
+	InternKeys bool
+
+	scanner  scanner
+	values   []any
+	keys     []string
+	interned map[string]string
+	// fallback is set for inputs with invalid UTF-8, which ParseJson replaces
+	// with U+FFFD.
+	fallback bool
+}
+
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// Reset prepares the Parser for json and drops every reference to the
+// previous document, except for the interned keys.
+func (p *Parser) Reset(json string) {
+	p.scanner = scanner{input: json}
+	clear(p.values)
+	clear(p.keys)
+	p.values, p.keys = p.values[:0], p.keys[:0]
+	p.fallback = !utf8.ValidString(json)
+}
+
+// Parse parses the input given to Reset. It returns the same values as
+// ParseJson.
+func (p *Parser) Parse() (any, error) {
+	if p.fallback {
+		return ParseJson(p.scanner.input)
+	}
+	value, err := p.value(p.scanner.NextToken())
+	if err != nil {
+		return nil, err
+	}
+	if p.scanner.NextToken().Kind != TokenKindEOF {
+		return nil, p.unexpected()
+	}
+	return value, nil
+}
+
+// ParseJson is Reset followed by Parse.
+func (p *Parser) ParseJson(json string) (any, error) {
+	p.Reset(json)
+	return p.Parse()
+}
+
+func (p *Parser) value(token Token) (any, error) {
+	switch token.Kind {
+	case TokenKindEOF:
+		return nil, syntaxError(&p.scanner, "EOF: end of file")
+	case TokenKindNull:
+		return nil, nil
+	case TokenKindBoolean:
+		return token.Value == "true", nil
+	case TokenKindNumber:
+		number, err := strconv.ParseFloat(token.Value, 64)
+		if err != nil {
+			return nil, numberError(&p.scanner, err)
+		}
+		return number, nil
+	case TokenKindString:
+		return unquoteString(token.Value), nil
+	case TokenKindBraceOpen:
+		return p.object()
+	case TokenKindBracketOpen:
+		return p.array()
+	default:
+		return nil, p.unexpected()
+	}
+}
+
+func (p *Parser) array() (any, error) {
+	start := len(p.values)
+	token := p.scanner.NextToken()
+	if token.Kind == TokenKindBracketClose {
+		return make([]any, 0), nil
+	}
+	for {
+		value, err := p.value(token)
+		if err != nil {
+			return nil, err
+		}
+		p.values = append(p.values, value)
+
+		switch p.scanner.NextToken().Kind {
+		case TokenKindBracketClose:
+			arr := make([]any, len(p.values)-start)
+			copy(arr, p.values[start:])
+			clear(p.values[start:])
+			p.values = p.values[:start]
+			return arr, nil
+		case TokenKindComma:
+			token = p.scanner.NextToken()
+		default:
+			return nil, p.unexpected()
+		}
+	}
+}
+
+func (p *Parser) object() (any, error) {
+	start := len(p.values)
+	for first := true; ; first = false {
+		token := p.scanner.NextToken()
+		switch token.Kind {
+		case TokenKindBraceClose:
+			if !first {
+				return nil, p.unexpected()
+			}
+			return make(map[string]any), nil
+		case TokenKindString:
+			p.keys = append(p.keys, p.key(token.Value))
+			if p.scanner.NextToken().Kind != TokenKindColon {
+				return nil, p.unexpected()
+			}
+			value, err := p.value(p.scanner.NextToken())
+			if err != nil {
+				return nil, err
+			}
+			p.values = append(p.values, value)
+
+			switch p.scanner.NextToken().Kind {
+			case TokenKindBraceClose:
+				obj := make(map[string]any, len(p.values)-start)
+				keys := p.keys[len(p.keys)-(len(p.values)-start):]
+				for i, key := range keys {
+					obj[key] = p.values[start+i]
+				}
+				clear(keys)
+				clear(p.values[start:])
+				p.keys = p.keys[:len(p.keys)-len(keys)]
+				p.values = p.values[:start]
+				return obj, nil
+			case TokenKindComma:
+				continue
+			default:
+				return nil, p.unexpected()
+			}
+		default:
+			return nil, p.unexpected()
+		}
+	}
+}
+
+func (p *Parser) key(token string) string {
+	key := unquoteString(token)
+	if !p.InternKeys {
+		return key
+	}
+	if interned, ok := p.interned[key]; ok {
+		return interned
+	}
+	if p.interned == nil {
+		p.interned = make(map[string]string)
+	}
+	if len(p.interned) >= maxInternedKeys {
+		return key
+	}
+	interned := strings.Clone(key)
+	p.interned[interned] = interned
+	return interned
+}
+
+func (p *Parser) unexpected() error {
+	return unexpected(&p.scanner)
+}
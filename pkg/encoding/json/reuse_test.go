@@ -0,0 +1,115 @@
+package json
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+func TestParser_Conformance(t *testing.T) {
+	p := NewParser()
+	for name, input := range conformanceAccept {
+		expected, err := ParseJson(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := p.ParseJson(input)
+		if err != nil || !isEqual(actual, expected) {
+			t.Errorf("FAIL: %s: expected %v but got %v %v", name, expected, actual, err)
+		}
+	}
+	for name, input := range conformanceReject {
+		if _, err := p.ParseJson(input); err == nil {
+			t.Errorf("FAIL: %s: expected an error for %q", name, input)
+		}
+	}
+	for name, c := range conformanceImplementationDefined {
+		expected, expectedErr := ParseJson(c.In)
+		actual, err := p.ParseJson(c.In)
+		if (err == nil) != (expectedErr == nil) || err == nil && !isEqual(actual, expected) {
+			t.Errorf("FAIL: %s: expected %v %v but got %v %v", name, expected, expectedErr, actual, err)
+		}
+	}
+}
+
+func TestParser_Reuse(t *testing.T) {
+	p := &Parser{}
+	inputs := []string{
+		`{"a": [1, [2, {"b": []}], {}], "c": {"d": {"e": "f"}}, "a": "dup"}`,
+		`[1, 2, 3`,
+		`{"x": {"y": [true, null]}}`,
+		"\"\xff\"",
+		`[{"k": 1}, {"k": 2}, {"k": 3}]`,
+	}
+	for round := 0; round < 2; round++ {
+		for _, input := range inputs {
+			expected, expectedErr := ParseJson(input)
+			actual, err := p.ParseJson(input)
+			if (err == nil) != (expectedErr == nil) || !isEqual(actual, expected) {
+				t.Errorf("FAIL: %s: expected %v %v but got %v %v", input, expected, expectedErr, actual, err)
+			}
+		}
+	}
+
+	p.Reset(`[1, {"a": 2}]`)
+	if _, err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range p.values[:cap(p.values)] {
+		if v != nil {
+			t.Errorf("FAIL: expected the stacks to be cleared but found %v", v)
+		}
+	}
+}
+
+func TestParser_InternKeys(t *testing.T) {
+	input := `[{"name": "a", "id": 1}, {"name": "b", "id": 2}]`
+	p := &Parser{InternKeys: true}
+	first, err := p.ParseJson(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := p.ParseJson(strings.Clone(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyData := func(doc any, i int) *byte {
+		for key := range doc.([]any)[i].(map[string]any) {
+			if key == "name" {
+				return unsafe.StringData(key)
+			}
+		}
+		return nil
+	}
+	shared := keyData(first, 0)
+	if shared == nil || keyData(first, 1) != shared || keyData(second, 0) != shared {
+		t.Errorf("FAIL: expected every name key to share one string")
+	}
+	start := uintptr(unsafe.Pointer(unsafe.StringData(input)))
+	if at := uintptr(unsafe.Pointer(shared)); at >= start && at < start+uintptr(len(input)) {
+		t.Errorf("FAIL: expected interned keys to be copied out of the input")
+	}
+}
+
+func TestParser_Pool(t *testing.T) {
+	pool := sync.Pool{New: func() any { return &Parser{InternKeys: true} }}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				p := pool.Get().(*Parser)
+				value, err := p.ParseJson(`{"a": [1, 2], "b": {"c": "d"}}`)
+				pool.Put(p)
+				if err != nil || value.(map[string]any)["b"].(map[string]any)["c"] != "d" {
+					t.Errorf("FAIL: got %v %v", value, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,241 @@
+package json
+
+import (
+	"io"
+	"math/bits"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// pointerEscaper escapes an object key into a JSON Pointer segment.
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// Stats describes the shape and the weight of a stream of JSON values.
+type Stats struct {
+	// Bytes is the size of the stream and Values the number of top-level
+	// values in it.
+	Bytes  int64
+	Values int
+	// MaxDepth is the deepest nesting of containers, 0 for scalars only.
+	MaxDepth int
+	Kinds    KindCounts
+	// Keys holds the most frequent object keys, the most frequent first.
+	Keys []KeyCount
+	// Largest holds the largest values, containers or strings, the largest
+	// first.
+	Largest []Subtree
+	// StringLengths is a histogram of the lengths in bytes of the string
+	// values, keys excluded. Bucket i counts the lengths in [2^(i-1), 2^i).
+	StringLengths []Bucket
+}
+
+type KindCounts struct {
+	Objects  int
+	Arrays   int
+	Strings  int
+	Numbers  int
+	Booleans int
+	Nulls    int
+}
+
+type KeyCount struct {
+	Key   string
+	Count int
+}
+
+type Subtree struct {
+	// Value is the index of the top-level value holding the subtree and
+	// Pointer its JSON Pointer in that value.
+	Value   int
+	Pointer string
+	Bytes   int64
+}
+
+// Bucket counts the strings with a length in [Min, Max].
+type Bucket struct {
+	Min, Max int
+	Count    int
+}
+
+type statsFrame struct {
+	start    int64
+	isObject bool
+	// awaitingKey is set in objects until the key of the next member.
+	awaitingKey bool
+	key         string
+	index       int
+}
+
+// Analyzer gathers Stats from the events of a PushParser, so the values are
+// never built and the memory only grows with the nesting depth and the number
+// of distinct keys.
+type Analyzer struct {
+	// TopKeys and TopSubtrees bound Stats.Keys and Stats.Largest, 10 when
+	// zero.
+	TopKeys     int
+	TopSubtrees int
+
+	parser  *PushParser
+	stats   Stats
+	frames  []statsFrame
+	keys    map[string]int
+	lengths []int
+}
+
+func NewAnalyzer() *Analyzer {
+	a := &Analyzer{keys: make(map[string]int)}
+	a.parser = &PushParser{OnEvent: a.event}
+	return a
+}
+
+// Write feeds the next chunk of the stream.
+func (a *Analyzer) Write(chunk []byte) (int, error) {
+	n, err := a.parser.Write(chunk)
+	a.stats.Bytes += int64(n)
+	return n, err
+}
+
+// Close checks that the last value is complete.
+func (a *Analyzer) Close() error {
+	return a.parser.Close()
+}
+
+// Analyze reads r to the end and returns its statistics.
+func Analyze(r io.Reader) (*Stats, error) {
+	a := NewAnalyzer()
+	if _, err := io.Copy(a, r); err != nil {
+		return nil, err
+	}
+	if err := a.Close(); err != nil {
+		return nil, err
+	}
+	return a.Stats(), nil
+}
+
+// Stats returns the statistics of the input written so far.
+func (a *Analyzer) Stats() *Stats {
+	stats := a.stats
+	stats.Largest = slices.Clone(stats.Largest)
+
+	stats.Keys = make([]KeyCount, 0, len(a.keys))
+	for key, count := range a.keys {
+		stats.Keys = append(stats.Keys, KeyCount{Key: key, Count: count})
+	}
+	slices.SortFunc(stats.Keys, func(x, y KeyCount) int {
+		if x.Count != y.Count {
+			return y.Count - x.Count
+		}
+		return strings.Compare(x.Key, y.Key)
+	})
+	stats.Keys = stats.Keys[:min(len(stats.Keys), top(a.TopKeys))]
+
+	stats.StringLengths = make([]Bucket, len(a.lengths))
+	for i, count := range a.lengths {
+		bucket := Bucket{Count: count}
+		if i > 0 {
+			bucket.Min, bucket.Max = 1<<(i-1), 1<<i-1
+		}
+		stats.StringLengths[i] = bucket
+	}
+	return &stats
+}
+
+func top(n int) int {
+	if n <= 0 {
+		return 10
+	}
+	return n
+}
+
+func (a *Analyzer) event(e Event) error {
+	var parent *statsFrame
+	if len(a.frames) > 0 {
+		parent = &a.frames[len(a.frames)-1]
+	}
+	switch e.Kind {
+	case TokenKindColon:
+	case TokenKindComma:
+		if parent.isObject {
+			parent.awaitingKey = true
+		} else {
+			parent.index++
+		}
+	case TokenKindBraceOpen, TokenKindBracketOpen:
+		isObject := e.Kind == TokenKindBraceOpen
+		if isObject {
+			a.stats.Kinds.Objects++
+		} else {
+			a.stats.Kinds.Arrays++
+		}
+		a.frames = append(a.frames, statsFrame{start: e.Offset, isObject: isObject, awaitingKey: isObject})
+		a.stats.MaxDepth = max(a.stats.MaxDepth, len(a.frames))
+	case TokenKindBraceClose, TokenKindBracketClose:
+		frame := a.frames[len(a.frames)-1]
+		a.frames = a.frames[:len(a.frames)-1]
+		a.value(e.Offset + 1 - frame.start)
+	case TokenKindString:
+		if parent != nil && parent.awaitingKey {
+			parent.awaitingKey = false
+			parent.key = unquoteString(e.Value)
+			a.keys[parent.key]++
+			return nil
+		}
+		a.stats.Kinds.Strings++
+		length := len(e.Value) - 2
+		if strings.IndexByte(e.Value, '\\') >= 0 {
+			length = len(unquoteString(e.Value))
+		}
+		bucket := bits.Len(uint(length))
+		for len(a.lengths) <= bucket {
+			a.lengths = append(a.lengths, 0)
+		}
+		a.lengths[bucket]++
+		a.value(int64(len(e.Value)))
+	default:
+		switch e.Kind {
+		case TokenKindNumber:
+			a.stats.Kinds.Numbers++
+		case TokenKindBoolean:
+			a.stats.Kinds.Booleans++
+		case TokenKindNull:
+			a.stats.Kinds.Nulls++
+		}
+		a.value(int64(len(e.Value)))
+	}
+	return nil
+}
+
+// value records a complete value of size bytes at the current path.
+func (a *Analyzer) value(size int64) {
+	largest := a.stats.Largest
+	n := top(a.TopSubtrees)
+	if len(largest) < n || size > largest[len(largest)-1].Bytes {
+		i, _ := slices.BinarySearchFunc(largest, size, func(s Subtree, size int64) int {
+			// descending, equal sizes keep their order of appearance
+			if s.Bytes >= size {
+				return -1
+			}
+			return 1
+		})
+		subtree := Subtree{Value: a.stats.Values, Pointer: a.pointer(), Bytes: size}
+		largest = slices.Insert(largest, i, subtree)
+		a.stats.Largest = largest[:min(len(largest), n)]
+	}
+	if len(a.frames) == 0 {
+		a.stats.Values++
+	}
+}
+
+func (a *Analyzer) pointer() string {
+	var b strings.Builder
+	for _, frame := range a.frames {
+		b.WriteByte('/')
+		if frame.isObject {
+			b.WriteString(pointerEscaper.Replace(frame.key))
+		} else {
+			b.WriteString(strconv.Itoa(frame.index))
+		}
+	}
+	return b.String()
+}
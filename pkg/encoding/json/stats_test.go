@@ -0,0 +1,92 @@
+package json
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	input := `{"users": [{"id": 1, "name": "ana", "tags": ["a", "bb"]}, {"id": 2, "name": "bob", "bio": "` +
+		strings.Repeat("x", 40) + `"}], "a/b": {"c~d": null, "e": true}, "esc": "é\n"}`
+	stats, err := Analyze(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Bytes != int64(len(input)) || stats.Values != 1 || stats.MaxDepth != 4 {
+		t.Errorf("FAIL: got %d bytes, %d values, depth %d", stats.Bytes, stats.Values, stats.MaxDepth)
+	}
+	kinds := KindCounts{Objects: 4, Arrays: 2, Strings: 6, Numbers: 2, Booleans: 1, Nulls: 1}
+	if stats.Kinds != kinds {
+		t.Errorf("FAIL: expected %+v but got %+v", kinds, stats.Kinds)
+	}
+	keys := []KeyCount{{"id", 2}, {"name", 2}, {"a/b", 1}, {"bio", 1}, {"c~d", 1}, {"e", 1}, {"esc", 1}, {"tags", 1}, {"users", 1}}
+	if !reflect.DeepEqual(stats.Keys, keys) {
+		t.Errorf("FAIL: expected %v but got %v", keys, stats.Keys)
+	}
+	// "ana", "bob", "a", "bb", 40 x, and "é\n" which is 3 bytes once decoded
+	lengths := []Bucket{{0, 0, 0}, {1, 1, 1}, {2, 3, 4}, {4, 7, 0}, {8, 15, 0}, {16, 31, 0}, {32, 63, 1}}
+	if !reflect.DeepEqual(stats.StringLengths, lengths) {
+		t.Errorf("FAIL: expected %v but got %v", lengths, stats.StringLengths)
+	}
+
+	pointers := make([]string, 0)
+	for _, subtree := range stats.Largest {
+		pointers = append(pointers, subtree.Pointer)
+	}
+	expected := []string{"", "/users", "/users/1", "/users/0", "/users/1/bio", "/a~1b", "/users/0/tags", "/esc", "/users/0/name", "/users/1/name"}
+	if !reflect.DeepEqual(pointers, expected) {
+		t.Errorf("FAIL: expected %v but got %v", expected, pointers)
+	}
+	if stats.Largest[0].Bytes != int64(len(input)) || stats.Largest[4].Bytes != 42 || stats.Largest[5].Bytes != 24 {
+		t.Errorf("FAIL: got %+v", stats.Largest)
+	}
+}
+
+func TestAnalyzer_Stream(t *testing.T) {
+	a := NewAnalyzer()
+	a.TopKeys, a.TopSubtrees = 1, 2
+	input := "{\"k\": [1, 2, 3]}\n\"long string\"\n[[[true]]]\n"
+	for i := 0; i < len(input); i += 3 {
+		if _, err := a.Write([]byte(input[i:min(i+3, len(input))])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	stats := a.Stats()
+	if stats.Values != 3 || stats.MaxDepth != 3 || len(stats.Keys) != 1 {
+		t.Errorf("FAIL: got %+v", stats)
+	}
+	largest := []Subtree{{0, "", 16}, {1, "", 13}}
+	if !reflect.DeepEqual(stats.Largest, largest) {
+		t.Errorf("FAIL: expected %v but got %v", largest, stats.Largest)
+	}
+}
+
+func TestAnalyze_Errors(t *testing.T) {
+	for _, input := range []string{`{"a": }`, `[1, 2`, `{"a" 1}`} {
+		if _, err := Analyze(strings.NewReader(input)); err == nil {
+			t.Errorf("FAIL: expected an error for %s", input)
+		}
+	}
+}
+
+func TestAnalyze_File(t *testing.T) {
+	f, err := os.Open("tests/something.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stats, err := Analyze(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, _ := f.Stat()
+	if stats.Bytes != info.Size() || stats.Largest[0].Pointer != "" || stats.Largest[0].Bytes == 0 {
+		t.Errorf("FAIL: got %+v", stats)
+	}
+}
@@ -0,0 +1,353 @@
+package json
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// streamFlushSize is the size of the buffer of a StreamWriter.
+const streamFlushSize = 32 << 10
+
+type streamFrame struct {
+	object bool
+	// count is the number of members or elements written so far.
+	count int
+	// key is set between a key and its value.
+	key bool
+}
+
+// StreamWriter writes a JSON document call by call, for outputs too large to
+// be built as a value first. Only a small buffer and the stack of open
+// containers are kept in memory. Every call checks that it is allowed at this
+// point of the document: a value inside an object needs a key first, and
+// EndObject and EndArray must match the open container. The first error is
+// returned by every later call.
+//
+// Several top-level values may be written one after the other, each followed
+// by a newline, like NDJSON.
+//
+//	w := json.NewStreamWriter(out)
+//	w.BeginArray()
+//	for _, row := range rows {
+//		w.BeginObject()
+//		w.Key("id")
+//		w.Int(row.ID)
+//		w.EndObject()
+//	}
+//	w.EndArray()
+//	err := w.Close()
+type StreamWriter struct {
+	// Indent, when not empty, puts every member and element on its own line
+	// indented by Indent for each level. Set it before the first call.
+	Indent string
+
+	w      io.Writer
+	buf    []byte
+	frames []streamFrame
+	err    error
+}
+
+func NewStreamWriter(w io.Writer) *StreamWriter {
+	return &StreamWriter{w: w, buf: make([]byte, 0, streamFlushSize)}
+}
+
+// BeginObject opens an object.
+func (s *StreamWriter) BeginObject() error {
+	if err := s.value("BeginObject"); err != nil {
+		return err
+	}
+	s.buf = append(s.buf, '{')
+	s.frames = append(s.frames, streamFrame{object: true})
+	return s.done()
+}
+
+// EndObject closes the innermost container, which must be an object.
+func (s *StreamWriter) EndObject() error {
+	return s.end("EndObject", true, '}')
+}
+
+// BeginArray opens an array.
+func (s *StreamWriter) BeginArray() error {
+	if err := s.value("BeginArray"); err != nil {
+		return err
+	}
+	s.buf = append(s.buf, '[')
+	s.frames = append(s.frames, streamFrame{})
+	return s.done()
+}
+
+// EndArray closes the innermost container, which must be an array.
+func (s *StreamWriter) EndArray() error {
+	return s.end("EndArray", false, ']')
+}
+
+// Key writes the key of the next member of the innermost object.
+func (s *StreamWriter) Key(key string) error {
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.frames) == 0 || !s.frames[len(s.frames)-1].object {
+		return s.fail("Key", "a key can only be written inside an object")
+	}
+	frame := &s.frames[len(s.frames)-1]
+	if frame.key {
+		return s.fail("Key", "the previous key has no value")
+	}
+	s.separator(frame)
+	s.buf = appendQuoted(s.buf, key)
+	s.buf = append(s.buf, ':')
+	if s.Indent != "" {
+		s.buf = append(s.buf, ' ')
+	}
+	frame.key = true
+	return nil
+}
+
+// String writes a string value.
+func (s *StreamWriter) String(value string) error {
+	if err := s.value("String"); err != nil {
+		return err
+	}
+	s.buf = appendQuoted(s.buf, value)
+	return s.scalar()
+}
+
+// Number writes a number value, formatted like Marshal does. NaN and
+// infinities are not valid JSON and fail.
+func (s *StreamWriter) Number(value float64) error {
+	if err := s.value("Number"); err != nil {
+		return err
+	}
+	e := &encoder{buf: s.buf}
+	if err := e.float(value, 64); err != nil {
+		s.err = err
+		return err
+	}
+	s.buf = e.buf
+	return s.scalar()
+}
+
+// Int writes an integer value, exact even beyond the 53 bits of a float64.
+func (s *StreamWriter) Int(value int64) error {
+	if err := s.value("Int"); err != nil {
+		return err
+	}
+	s.buf = strconv.AppendInt(s.buf, value, 10)
+	return s.scalar()
+}
+
+// Bool writes true or false.
+func (s *StreamWriter) Bool(value bool) error {
+	if err := s.value("Bool"); err != nil {
+		return err
+	}
+	s.buf = strconv.AppendBool(s.buf, value)
+	return s.scalar()
+}
+
+// Null writes null.
+func (s *StreamWriter) Null() error {
+	if err := s.value("Null"); err != nil {
+		return err
+	}
+	s.buf = append(s.buf, "null"...)
+	return s.scalar()
+}
+
+// Raw splices a pre-encoded JSON value. The fragment must hold exactly one
+// valid value; its whitespace is replaced by the indentation of the document,
+// or removed without Indent.
+func (s *StreamWriter) Raw(fragment []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	if _, err := parseInPlace(string(fragment)); err != nil {
+		s.err = fmt.Errorf("invalid JSON fragment: %w", err)
+		return s.err
+	}
+	if err := s.value("Raw"); err != nil {
+		return err
+	}
+	s.fragment(string(fragment))
+	return s.scalar()
+}
+
+// Value writes v encoded by Marshal, indented like the document.
+func (s *StreamWriter) Value(v any) error {
+	if s.err != nil {
+		return s.err
+	}
+	out, err := Marshal(v)
+	if err != nil {
+		s.err = err
+		return err
+	}
+	if err := s.value("Value"); err != nil {
+		return err
+	}
+	if s.Indent == "" {
+		s.buf = append(s.buf, out...)
+	} else {
+		s.fragment(string(out))
+	}
+	return s.scalar()
+}
+
+// Flush writes the buffered output to the underlying writer.
+func (s *StreamWriter) Flush() error {
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.buf) > 0 {
+		_, s.err = s.w.Write(s.buf)
+		s.buf = s.buf[:0]
+	}
+	return s.err
+}
+
+// Close checks that every container was closed and flushes the output. It
+// does not close the underlying writer.
+func (s *StreamWriter) Close() error {
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.frames) > 0 {
+		return s.fail("Close", fmt.Sprintf("%d containers are still open", len(s.frames)))
+	}
+	return s.Flush()
+}
+
+func (s *StreamWriter) fail(call string, reason string) error {
+	s.err = fmt.Errorf("invalid call to %s: %s", call, reason)
+	return s.err
+}
+
+// value checks that a value can be written and writes what precedes it.
+func (s *StreamWriter) value(call string) error {
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.frames) == 0 {
+		return nil
+	}
+	frame := &s.frames[len(s.frames)-1]
+	if frame.object {
+		if !frame.key {
+			return s.fail(call, "a value inside an object needs a key first")
+		}
+		frame.key = false
+		frame.count++
+		return nil
+	}
+	s.separator(frame)
+	frame.count++
+	return nil
+}
+
+// separator writes the comma and the indentation preceding a member or an
+// element.
+func (s *StreamWriter) separator(frame *streamFrame) {
+	if frame.count > 0 {
+		s.buf = append(s.buf, ',')
+	}
+	s.newline(len(s.frames))
+}
+
+func (s *StreamWriter) newline(depth int) {
+	if s.Indent == "" {
+		return
+	}
+	s.buf = append(s.buf, '\n')
+	for i := 0; i < depth; i++ {
+		s.buf = append(s.buf, s.Indent...)
+	}
+}
+
+// fragment appends the valid JSON value with its whitespace replaced by the
+// indentation of the document, so that it lines up with the other values.
+func (s *StreamWriter) fragment(value string) {
+	depth := len(s.frames)
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case ' ', '\t', '\n', '\r':
+		case '"':
+			end := i + 1
+			for ; value[end] != '"'; end++ {
+				if value[end] == '\\' {
+					end++
+				}
+			}
+			s.buf = append(s.buf, value[i:end+1]...)
+			i = end
+		case '{', '[':
+			s.buf = append(s.buf, c)
+			next := i + 1
+			for isWhitespace(rune(value[next])) {
+				next++
+			}
+			// empty containers stay on one line, like BeginObject and EndObject
+			if value[next] == '}' || value[next] == ']' {
+				s.buf = append(s.buf, value[next])
+				i = next
+				continue
+			}
+			depth++
+			s.newline(depth)
+		case '}', ']':
+			depth--
+			s.newline(depth)
+			s.buf = append(s.buf, c)
+		case ',':
+			s.buf = append(s.buf, c)
+			s.newline(depth)
+		case ':':
+			s.buf = append(s.buf, c)
+			if s.Indent != "" {
+				s.buf = append(s.buf, ' ')
+			}
+		default:
+			s.buf = append(s.buf, c)
+		}
+	}
+}
+
+func (s *StreamWriter) end(call string, object bool, closing byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.frames) == 0 {
+		return s.fail(call, "no container is open")
+	}
+	frame := s.frames[len(s.frames)-1]
+	switch {
+	case frame.object != object && frame.object:
+		return s.fail(call, "the innermost container is an object")
+	case frame.object != object:
+		return s.fail(call, "the innermost container is an array")
+	case frame.key:
+		return s.fail(call, "the last key has no value")
+	}
+	s.frames = s.frames[:len(s.frames)-1]
+	if frame.count > 0 {
+		s.newline(len(s.frames))
+	}
+	s.buf = append(s.buf, closing)
+	return s.scalar()
+}
+
+// scalar ends a value, with a newline after a top-level one.
+func (s *StreamWriter) scalar() error {
+	if len(s.frames) == 0 {
+		s.buf = append(s.buf, '\n')
+	}
+	return s.done()
+}
+
+// done flushes the buffer once it is full.
+func (s *StreamWriter) done() error {
+	if len(s.buf) >= streamFlushSize {
+		return s.Flush()
+	}
+	return nil
+}
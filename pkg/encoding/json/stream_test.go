@@ -0,0 +1,220 @@
+package json
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// writeExport writes a document using every call of a StreamWriter.
+func writeExport(w *StreamWriter) error {
+	calls := []func() error{
+		w.BeginObject,
+		func() error { return w.Key("name") },
+		func() error { return w.String("ż \"q\"\n") },
+		func() error { return w.Key("rows") },
+		w.BeginArray,
+		func() error { return w.Number(1.5) },
+		func() error { return w.Int(math.MaxInt64) },
+		func() error { return w.Bool(true) },
+		w.Null,
+		w.BeginObject,
+		w.EndObject,
+		w.BeginArray,
+		w.EndArray,
+		func() error { return w.Raw([]byte(" {\"a\": [1, \"x, y\" ],\n\t\"e\": {}, \"s\": \"\\\"}\"} ")) },
+		func() error { return w.Value(map[string]int{"b": 2, "a": 1}) },
+		w.EndArray,
+		w.EndObject,
+	}
+	for _, call := range calls {
+		if err := call(); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+func TestStreamWriter(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeExport(NewStreamWriter(&out)); err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	expected := `{"name":"ż \"q\"\n","rows":[1.5,9223372036854775807,true,null,{},[],{"a":[1,"x, y"],"e":{},"s":"\"}"},{"a":1,"b":2}]}` + "\n"
+	if out.String() != expected {
+		t.Errorf("FAIL: expected\n%s\nbut got\n%s", expected, out.String())
+	}
+	if _, err := ParseJson(out.String()); err != nil {
+		t.Errorf("FAIL: expected valid JSON but got %v", err)
+	}
+}
+
+func TestStreamWriter_Indent(t *testing.T) {
+	var out bytes.Buffer
+	w := NewStreamWriter(&out)
+	w.Indent = "  "
+	if err := writeExport(w); err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	expected := `{
+  "name": "ż \"q\"\n",
+  "rows": [
+    1.5,
+    9223372036854775807,
+    true,
+    null,
+    {},
+    [],
+    {
+      "a": [
+        1,
+        "x, y"
+      ],
+      "e": {},
+      "s": "\"}"
+    },
+    {
+      "a": 1,
+      "b": 2
+    }
+  ]
+}
+`
+	if out.String() != expected {
+		t.Errorf("FAIL: expected\n%s\nbut got\n%s", expected, out.String())
+	}
+}
+
+func TestStreamWriter_TopLevelValues(t *testing.T) {
+	var out bytes.Buffer
+	w := NewStreamWriter(&out)
+	for i := 0; i < 3; i++ {
+		w.BeginObject()
+		w.Key("i")
+		w.Int(int64(i))
+		w.EndObject()
+	}
+	w.String("end")
+	if err := w.Close(); err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	expected := "{\"i\":0}\n{\"i\":1}\n{\"i\":2}\n\"end\"\n"
+	if out.String() != expected {
+		t.Errorf("FAIL: expected %q but got %q", expected, out.String())
+	}
+}
+
+func TestStreamWriter_CallOrder(t *testing.T) {
+	cases := []struct {
+		Name  string
+		Calls func(w *StreamWriter) error
+		Err   string
+	}{
+		{"value without key", func(w *StreamWriter) error {
+			w.BeginObject()
+			return w.String("a")
+		}, "invalid call to String: a value inside an object needs a key first"},
+		{"key in array", func(w *StreamWriter) error {
+			w.BeginArray()
+			return w.Key("a")
+		}, "invalid call to Key: a key can only be written inside an object"},
+		{"key at top level", func(w *StreamWriter) error {
+			return w.Key("a")
+		}, "invalid call to Key: a key can only be written inside an object"},
+		{"two keys", func(w *StreamWriter) error {
+			w.BeginObject()
+			w.Key("a")
+			return w.Key("b")
+		}, "invalid call to Key: the previous key has no value"},
+		{"end object after key", func(w *StreamWriter) error {
+			w.BeginObject()
+			w.Key("a")
+			return w.EndObject()
+		}, "invalid call to EndObject: the last key has no value"},
+		{"end array in object", func(w *StreamWriter) error {
+			w.BeginObject()
+			return w.EndArray()
+		}, "invalid call to EndArray: the innermost container is an object"},
+		{"end object in array", func(w *StreamWriter) error {
+			w.BeginArray()
+			return w.EndObject()
+		}, "invalid call to EndObject: the innermost container is an array"},
+		{"end without begin", func(w *StreamWriter) error {
+			return w.EndArray()
+		}, "invalid call to EndArray: no container is open"},
+		{"close with open containers", func(w *StreamWriter) error {
+			w.BeginArray()
+			w.BeginObject()
+			return w.Close()
+		}, "invalid call to Close: 2 containers are still open"},
+		{"NaN", func(w *StreamWriter) error {
+			return w.Number(math.NaN())
+		}, "unsupported value: NaN"},
+	}
+	for _, c := range cases {
+		var out bytes.Buffer
+		w := NewStreamWriter(&out)
+		err := c.Calls(w)
+		if err == nil || err.Error() != c.Err {
+			t.Errorf("FAIL: %s: expected %q but got %v", c.Name, c.Err, err)
+			continue
+		}
+		// the error sticks
+		if later := w.Null(); later != err {
+			t.Errorf("FAIL: %s: expected the error to be returned again but got %v", c.Name, later)
+		}
+	}
+}
+
+func TestStreamWriter_Raw(t *testing.T) {
+	for _, fragment := range []string{``, `{"a": 1`, `[1, 2,]`, `1 2`, `{"a": 1}}`} {
+		w := NewStreamWriter(&bytes.Buffer{})
+		w.BeginArray()
+		err := w.Raw([]byte(fragment))
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) || !strings.HasPrefix(err.Error(), "invalid JSON fragment: ") {
+			t.Errorf("FAIL: %q: expected a syntax error but got %v", fragment, err)
+		}
+	}
+}
+
+func TestStreamWriter_Flush(t *testing.T) {
+	var out bytes.Buffer
+	w := NewStreamWriter(&out)
+	w.BeginArray()
+	row := strings.Repeat("x", 1000)
+	for i := 0; i < 100; i++ {
+		w.String(row)
+	}
+	// the buffer is flushed whenever full, before the document is complete
+	if out.Len() == 0 || len(w.buf) >= streamFlushSize {
+		t.Errorf("FAIL: expected partial flushes but got %d bytes written and %d buffered", out.Len(), len(w.buf))
+	}
+	w.EndArray()
+	if err := w.Close(); err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	value, err := ParseJson(out.String())
+	if err != nil || len(value.([]any)) != 100 {
+		t.Errorf("FAIL: expected 100 elements but got %v", err)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestStreamWriter_WriteError(t *testing.T) {
+	w := NewStreamWriter(failingWriter{})
+	w.Null()
+	if err := w.Close(); err == nil || err.Error() != "disk full" {
+		t.Errorf("FAIL: expected the write error but got %v", err)
+	}
+	if err := w.Null(); err == nil || err.Error() != "disk full" {
+		t.Errorf("FAIL: expected the write error to stick but got %v", err)
+	}
+}
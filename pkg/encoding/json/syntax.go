@@ -0,0 +1,362 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// syntaxContextLines is the number of lines shown before and after the
+	// offending one.
+	syntaxContextLines = 2
+	// syntaxMaxWidth is the number of runes shown of each line, minified
+	// documents are cut around the column.
+	syntaxMaxWidth = 80
+)
+
+// SyntaxError is returned by ParseJson and the other parsers when the input is
+// not valid JSON. Error keeps the message of the parser, Pretty renders the
+// offending line with a caret and a hint:
+//
+//	syntax error at line 3, column 1: unexpected token: type= TokenKindBraceClose -> value= `}`
+//	  1 | {
+//	  2 |   "a": 1,
+//	  3 | }
+//	    | ^ trailing comma before '}'
+type SyntaxError struct {
+	msg string
+	// Offset is the byte offset of the offending token in the input.
+	Offset int
+	// Line and Column locate the offending token, both start at 1 and the
+	// column counts runes.
+	Line   int
+	Column int
+	// Hint explains the error in plain English, it may be empty.
+	Hint string
+
+	// lines holds the offending line and its neighbours, copied from the
+	// input so that the error outlives it.
+	lines     []string
+	firstLine int
+	// skipped is the number of runes missing from the start of the first
+	// line, when only the end of the input was kept.
+	skipped int
+}
+
+func (e *SyntaxError) Error() string {
+	return e.msg
+}
+
+// Pretty returns the error followed by the source lines around it, with a
+// caret under the offending token and the hint.
+func (e *SyntaxError) Pretty() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "syntax error at line %d, column %d: %s\n", e.Line, e.Column, e.msg)
+
+	// every line is cut to the same window so that they stay aligned
+	column := e.Column - e.skipped
+	from := max(0, column-1-syntaxMaxWidth/2)
+	width := len(fmt.Sprint(e.firstLine + len(e.lines) - 1))
+	for i, line := range e.lines {
+		n := e.firstLine + i
+		clipped, padding := clipLine(line, from), ""
+		if n == e.Line {
+			padding = caretPadding(line, from, column-1)
+		}
+		if i == 0 && e.skipped > 0 && from == 0 {
+			clipped, padding = "..."+clipped, "   "+padding
+		}
+		fmt.Fprintf(&sb, "%*d | %s\n", width, n, clipped)
+		if n != e.Line {
+			continue
+		}
+		fmt.Fprintf(&sb, "%*s | %s^", width, "", padding)
+		if e.Hint != "" {
+			sb.WriteString(" " + e.Hint)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// clipLine returns at most syntaxMaxWidth runes of line starting at the rune
+// from, marking the parts left out with "...".
+func clipLine(line string, from int) string {
+	runes := []rune(line)
+	if len(runes) <= syntaxMaxWidth && from == 0 {
+		return line
+	}
+	from = min(from, len(runes))
+	to := min(from+syntaxMaxWidth, len(runes))
+	clipped := string(runes[from:to])
+	if from > 0 {
+		clipped = "..." + clipped
+	}
+	if to < len(runes) {
+		clipped += "..."
+	}
+	return clipped
+}
+
+// caretPadding returns the blanks that put a caret under the rune column of
+// line, as clipped by clipLine. Tabs are kept so the caret lines up.
+func caretPadding(line string, from int, column int) string {
+	var sb strings.Builder
+	if from > 0 {
+		sb.WriteString("   ")
+	}
+	for i, r := range []rune(line) {
+		if i >= column {
+			break
+		}
+		if i < from {
+			continue
+		}
+		if r == '\t' {
+			sb.WriteRune('\t')
+		} else {
+			sb.WriteRune(' ')
+		}
+	}
+	return sb.String()
+}
+
+// syntaxError returns an error with msg located at the current token of t.
+func syntaxError(t tokenizer, msg string) *SyntaxError {
+	input, offset := t.location()
+	return newSyntaxError(input, offset, t.current(), msg)
+}
+
+const numberRangeHint = "numbers must fit in a 64-bit float"
+
+// numberError returns the error for the current token of t, a number that
+// strconv.ParseFloat rejected with err because it does not fit a float64.
+func numberError(t tokenizer, err error) *SyntaxError {
+	_, offset := t.location()
+	e := syntaxError(t, fmt.Sprintf("invalid number `%s` at position %d: %v", t.current().Value, offset, errors.Unwrap(err)))
+	e.Hint = numberRangeHint
+	return e
+}
+
+// unexpected returns the error for the current token of t.
+func unexpected(t tokenizer) *SyntaxError {
+	token := t.current()
+	return syntaxError(t, fmt.Sprintf("unexpected token: type= %s -> value= `%v`", token.Kind.toString(), token.Value))
+}
+
+func newSyntaxError(input string, offset int, token Token, msg string) *SyntaxError {
+	offset = min(max(offset, 0), len(input))
+	lineStart := strings.LastIndexByte(input[:offset], '\n') + 1
+	e := &SyntaxError{
+		msg:    msg,
+		Offset: offset,
+		Line:   strings.Count(input[:lineStart], "\n") + 1,
+		Column: utf8.RuneCountInString(input[lineStart:offset]) + 1,
+		Hint:   syntaxHint(input, offset, token),
+	}
+
+	// walk back and forth from the offending line to copy its neighbours
+	start := lineStart
+	for i := 0; i < syntaxContextLines && start > 0; i++ {
+		start = strings.LastIndexByte(input[:start-1], '\n') + 1
+	}
+	end := lineStart
+	for i := 0; i <= syntaxContextLines; i++ {
+		next := strings.IndexByte(input[end:], '\n')
+		if next < 0 {
+			end = len(input)
+			break
+		}
+		end += next + 1
+	}
+	e.firstLine = e.Line - strings.Count(input[start:lineStart], "\n")
+	text := input[start:end]
+	if lineStart < len(input) {
+		// keep the empty line after the last newline only when the error is on it
+		text = strings.TrimSuffix(text, "\n")
+	}
+	for _, line := range strings.Split(text, "\n") {
+		e.lines = append(e.lines, strings.Clone(strings.TrimSuffix(line, "\r")))
+	}
+	return e
+}
+
+// syntaxHint guesses the cause of an error at offset from the token and the
+// input around it.
+func syntaxHint(input string, offset int, token Token) string {
+	prev, prevAt := previousByte(input, offset)
+	switch token.Kind {
+	case TokenKindEOF:
+		if prev == ':' {
+			return "a value is missing after ':'"
+		}
+		switch unclosed(input[:offset]) {
+		case '{':
+			return "unexpected end of input, '}' is missing"
+		case '[':
+			return "unexpected end of input, ']' is missing"
+		}
+		return "unexpected end of input, a value is missing"
+
+	case TokenKindBraceClose, TokenKindBracketClose:
+		closing := input[offset]
+		if prev == ',' {
+			return fmt.Sprintf("trailing comma before '%c'", closing)
+		}
+		if prev == ':' {
+			return "a value is missing after ':'"
+		}
+		switch open := unclosed(input[:offset]); {
+		case open == 0:
+			return fmt.Sprintf("'%c' has no opening bracket", closing)
+		case open == '{' && closing == ']':
+			return "mismatched ']', the object needs a '}'"
+		case open == '[' && closing == '}':
+			return "mismatched '}', the array needs a ']'"
+		}
+
+	case TokenKindComma:
+		switch prev {
+		case ',', '[':
+			return "a value is missing before ','"
+		case '{':
+			return "a member is missing before ','"
+		case ':':
+			return "a value is missing after ':'"
+		}
+
+	case TokenKindColon:
+		if prev == '"' && unclosed(input[:offset]) != '{' {
+			return "':' can only follow an object key"
+		}
+
+	case TokenKindString, TokenKindNumber, TokenKindBoolean, TokenKindNull, TokenKindBraceOpen, TokenKindBracketOpen:
+		if prev == '0' && prevAt == offset-1 && token.Kind == TokenKindNumber {
+			return "numbers cannot have leading zeros"
+		}
+		if prev == '"' && isKey(input, prevAt) {
+			return "missing ':' after the key"
+		}
+		if isValueEnd(prev) {
+			return "missing comma before this value"
+		}
+
+	case TokenKindInvalid:
+		return invalidTokenHint(input[offset:], token.Value)
+	}
+	return ""
+}
+
+func invalidTokenHint(rest string, msg string) string {
+	if rest == "" {
+		return ""
+	}
+	end := strings.IndexFunc(rest, func(r rune) bool {
+		return r < utf8.RuneSelf && isDelimiter(byte(r))
+	})
+	if end < 0 {
+		end = len(rest)
+	}
+	word := rest[:end]
+	digits := strings.TrimPrefix(word, "-")
+
+	switch c := rest[0]; {
+	case c == '\'':
+		return "strings must be in double quotes"
+	case c == '"' && strings.Contains(msg, "unterminated"),
+		// a raw line break is most likely a missing quote
+		strings.Contains(msg, "control character U+000A"), strings.Contains(msg, "control character U+000D"):
+		return "unterminated string, the closing quote is missing"
+	case strings.Contains(msg, "control character"):
+		return "control characters must be escaped in strings, like \\n or \\t"
+	case strings.Contains(msg, "escape"):
+		return `invalid escape, only \" \\ \/ \b \f \n \r \t and \uXXXX are allowed`
+	case c == '"':
+		return "invalid escape or unescaped control character in the string"
+	case c == '+':
+		return "numbers cannot start with '+'"
+	case c == '.':
+		return "numbers must start with a digit"
+	case len(digits) > 1 && digits[0] == '0' && isDigit(rune(digits[1])):
+		return "numbers cannot have leading zeros"
+	case c == '-' || isDigit(rune(c)):
+		return "invalid number, digits are required after '-', around '.' and in the exponent"
+	}
+
+	for _, literal := range []string{"true", "false", "null"} {
+		switch {
+		case strings.EqualFold(word, literal):
+			return "true, false and null are lowercase"
+		case strings.HasPrefix(literal, word) && strings.Contains(msg, "literal"):
+			return fmt.Sprintf("incomplete literal, expected `%s`", literal)
+		}
+	}
+	if c := rest[0]; c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' {
+		return "missing quotes, strings and object keys must be in double quotes"
+	}
+	return ""
+}
+
+// previousByte returns the last byte before offset that is not whitespace,
+// and its offset, or 0 and -1.
+func previousByte(input string, offset int) (byte, int) {
+	for i := offset - 1; i >= 0; i-- {
+		if !isWhitespace(rune(input[i])) {
+			return input[i], i
+		}
+	}
+	return 0, -1
+}
+
+func isValueEnd(c byte) bool {
+	return c == '"' || c == '}' || c == ']' || c == 'e' || c == 'l' || isDigit(rune(c))
+}
+
+// isKey reports whether the string closed by the quote at end is an object
+// key: it opens a member of the innermost object.
+func isKey(input string, end int) bool {
+	start := end - 1
+	for ; start >= 0; start-- {
+		if input[start] != '"' {
+			continue
+		}
+		backslashes := 0
+		for k := start - 1; k >= 0 && input[k] == '\\'; k-- {
+			backslashes++
+		}
+		if backslashes%2 == 0 {
+			break
+		}
+	}
+	if start < 0 || unclosed(input[:start]) != '{' {
+		return false
+	}
+	prev, _ := previousByte(input, start)
+	return prev == '{' || prev == ','
+}
+
+// unclosed returns the innermost bracket left open in input, or 0.
+func unclosed(input string) byte {
+	stack := make([]byte, 0)
+	inString := false
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{' || c == '[':
+			stack = append(stack, c)
+		case (c == '}' || c == ']') && len(stack) > 0:
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if len(stack) == 0 {
+		return 0
+	}
+	return stack[len(stack)-1]
+}
@@ -0,0 +1,196 @@
+package json
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSyntaxError_Hints(t *testing.T) {
+	cases := []struct {
+		In     string
+		Line   int
+		Column int
+		Hint   string
+	}{
+		{"{\n  \"a\": 1,\n}", 3, 1, "trailing comma before '}'"},
+		{`[1, 2,]`, 1, 7, "trailing comma before ']'"},
+		{`{"a": 1 "b": 2}`, 1, 9, "missing comma before this value"},
+		{`[1 2]`, 1, 4, "missing comma before this value"},
+		{`{"a" 1}`, 1, 6, "missing ':' after the key"},
+		{`{a: 1}`, 1, 2, "missing quotes, strings and object keys must be in double quotes"},
+		{`{name: 1}`, 1, 2, "missing quotes, strings and object keys must be in double quotes"},
+		{`['a']`, 1, 2, "strings must be in double quotes"},
+		{`{"a": "b}`, 1, 7, "unterminated string, the closing quote is missing"},
+		{"{\"a\": \"abc\n}", 1, 7, "unterminated string, the closing quote is missing"},
+		{"[\"abc\r\n]", 1, 2, "unterminated string, the closing quote is missing"},
+		{"[\"a\tb\"]", 1, 2, "control characters must be escaped in strings, like \\n or \\t"},
+		{`["\x"]`, 1, 2, `invalid escape, only \" \\ \/ \b \f \n \r \t and \uXXXX are allowed`},
+		{`[01]`, 1, 3, "numbers cannot have leading zeros"},
+		{`[+1]`, 1, 2, "numbers cannot start with '+'"},
+		{`[.5]`, 1, 2, "numbers must start with a digit"},
+		{`[1.]`, 1, 2, "invalid number, digits are required after '-', around '.' and in the exponent"},
+		{`[True]`, 1, 2, "true, false and null are lowercase"},
+		{`[nul]`, 1, 2, "incomplete literal, expected `null`"},
+		{`{"a": }`, 1, 7, "a value is missing after ':'"},
+		{`[1,,2]`, 1, 4, "a value is missing before ','"},
+		{`{"a": [1}`, 1, 9, "mismatched '}', the array needs a ']'"},
+		{`{"a": 1]`, 1, 8, "mismatched ']', the object needs a '}'"},
+		{`{"a": [1, 2]`, 1, 13, "unexpected end of input, '}' is missing"},
+		{``, 1, 1, "unexpected end of input, a value is missing"},
+		{`["a": 1]`, 1, 5, "':' can only follow an object key"},
+		{`[] ]`, 1, 4, "']' has no opening bracket"},
+		{"{\n\t\"żółw\": 1\n\t\"b\": 2\n}", 3, 2, "missing comma before this value"},
+	}
+	for _, c := range cases {
+		_, err := ParseJson(c.In)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("FAIL: %q: expected a SyntaxError but got %v", c.In, err)
+			continue
+		}
+		if syntaxErr.Line != c.Line || syntaxErr.Column != c.Column || syntaxErr.Hint != c.Hint {
+			t.Errorf("FAIL: %q: expected %d:%d %q but got %d:%d %q", c.In, c.Line, c.Column, c.Hint, syntaxErr.Line, syntaxErr.Column, syntaxErr.Hint)
+		}
+	}
+}
+
+func TestSyntaxError_Pretty(t *testing.T) {
+	input := "{\n  \"a\": 1,\n  \"b\": [\n    true,\n  ],\n  \"c\": null\n}"
+	_, err := ParseJson(input)
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("FAIL: expected a SyntaxError but got %v", err)
+	}
+	if err.Error() != "unexpected token: type= TokenKindBracketClose -> value= `]`" {
+		t.Errorf("FAIL: expected the message to be kept but got %s", err)
+	}
+	expected := "syntax error at line 5, column 3: unexpected token: type= TokenKindBracketClose -> value= `]`\n" +
+		"3 |   \"b\": [\n" +
+		"4 |     true,\n" +
+		"5 |   ],\n" +
+		"  |   ^ trailing comma before ']'\n" +
+		"6 |   \"c\": null\n" +
+		"7 | }\n"
+	if pretty := syntaxErr.Pretty(); pretty != expected {
+		t.Errorf("FAIL: expected\n%s\nbut got\n%s", expected, pretty)
+	}
+}
+
+func TestSyntaxError_TrailingNewline(t *testing.T) {
+	expected := "syntax error at line 2, column 1: unexpected token: type= TokenKindEOF -> value= `EOF`\n" +
+		"1 | [1,2\n" +
+		"2 | \n" +
+		"  | ^ unexpected end of input, ']' is missing\n"
+	_, err := ParseJson("[1,2\n")
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("FAIL: expected a SyntaxError but got %v", err)
+	}
+	if pretty := syntaxErr.Pretty(); pretty != expected {
+		t.Errorf("FAIL: expected\n%s\nbut got\n%s", expected, pretty)
+	}
+	_, err = pushAll("[1,2\n", 2)
+	if !errors.As(err, &syntaxErr) || syntaxErr.Line != 2 || !strings.HasSuffix(syntaxErr.Pretty(), "2 | \n  | ^ unexpected end of input, ']' is missing\n") {
+		t.Errorf("FAIL: PushParser: expected a caret on the last line but got %v", err)
+	}
+
+	// an error before the last newline does not show the empty line
+	_, err = ParseJson("[1,]\n")
+	if !errors.As(err, &syntaxErr) || strings.Contains(syntaxErr.Pretty(), "2 |") {
+		t.Errorf("FAIL: expected a single line but got %v", err)
+	}
+}
+
+func TestSyntaxError_LongLine(t *testing.T) {
+	input := "[" + strings.Repeat(`"abcdefgh",`, 100) + "]"
+	_, err := ParseJson(input)
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("FAIL: expected a SyntaxError but got %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(syntaxErr.Pretty(), "\n"), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "1 | ...") || strings.HasSuffix(lines[1], "...") {
+		t.Fatalf("FAIL: expected the line to be cut before the column but got\n%s", syntaxErr.Pretty())
+	}
+	// the caret is under the closing bracket
+	if caret := strings.Index(lines[2], "^"); lines[1][caret] != ']' {
+		t.Errorf("FAIL: expected the caret under ] but got\n%s", syntaxErr.Pretty())
+	}
+}
+
+func TestSyntaxError_Parsers(t *testing.T) {
+	input := "{\n  \"a\": [1, 2,],\n  \"b\": 3\n}"
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(input), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	parsers := map[string]func() error{
+		"ParseJson":     func() error { _, err := ParseJson(input); return err },
+		"ParseFile":     func() error { _, err := ParseFile(path); return err },
+		"Parser":        func() error { _, err := NewParser().ParseJson(input); return err },
+		"ParseDocument": func() error { _, err := ParseDocument(input); return err },
+		"Unmarshal": func() error {
+			var v struct{ A []int }
+			return Unmarshal([]byte(input), &v)
+		},
+	}
+	for name, parse := range parsers {
+		var syntaxErr *SyntaxError
+		if err := parse(); !errors.As(err, &syntaxErr) {
+			t.Errorf("FAIL: %s: expected a SyntaxError but got %v", name, err)
+		} else if syntaxErr.Line != 2 || syntaxErr.Column != 14 || syntaxErr.Hint != "trailing comma before ']'" {
+			t.Errorf("FAIL: %s: got %d:%d %q", name, syntaxErr.Line, syntaxErr.Column, syntaxErr.Hint)
+		}
+	}
+}
+
+func TestSyntaxError_NumberRange(t *testing.T) {
+	input := "[1,\n 1e999]"
+	parsers := map[string]func() error{
+		"ParseJson":     func() error { _, err := ParseJson(input); return err },
+		"Parser":        func() error { _, err := NewParser().ParseJson(input); return err },
+		"ParseDocument": func() error { _, err := ParseDocument(input); return err },
+		"PushParser":    func() error { _, err := pushAll(input, 2); return err },
+	}
+	for name, parse := range parsers {
+		var syntaxErr *SyntaxError
+		if err := parse(); !errors.As(err, &syntaxErr) {
+			t.Errorf("FAIL: %s: expected a SyntaxError but got %v", name, err)
+		} else if syntaxErr.Error() != "invalid number `1e999` at position 5: value out of range" ||
+			syntaxErr.Line != 2 || syntaxErr.Column != 2 || syntaxErr.Hint != numberRangeHint {
+			t.Errorf("FAIL: %s: got %q at %d:%d %q", name, syntaxErr.Error(), syntaxErr.Line, syntaxErr.Column, syntaxErr.Hint)
+		}
+	}
+}
+
+func TestSyntaxError_ParseJsonParallel(t *testing.T) {
+	cases := []struct {
+		input        string
+		line, column int
+		hint         string
+	}{
+		{`[10, 20, {"a" 1}]`, 1, 15, "missing ':' after the key"},
+		{"[\n 1,\n 2,\n]", 4, 1, "trailing comma before ']'"},
+		{"[1,\n 2}", 2, 3, "mismatched '}', the array needs a ']'"},
+		{"[1,\n \"a]", 2, 2, "unterminated string, the closing quote is missing"},
+		{"[1] x", 1, 5, "missing quotes, strings and object keys must be in double quotes"},
+	}
+	for _, c := range cases {
+		_, expected := ParseJson(c.input)
+		_, err := ParseJsonParallel(c.input, 2)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("FAIL: %q: expected a SyntaxError but got %v", c.input, err)
+			continue
+		}
+		if syntaxErr.Line != c.line || syntaxErr.Column != c.column || syntaxErr.Hint != c.hint {
+			t.Errorf("FAIL: %q: expected %d:%d %q but got %d:%d %q", c.input, c.line, c.column, c.hint, syntaxErr.Line, syntaxErr.Column, syntaxErr.Hint)
+		}
+		if syntaxErr.Pretty() != expected.(*SyntaxError).Pretty() {
+			t.Errorf("FAIL: %q: expected the snippet of ParseJson but got\n%s", c.input, syntaxErr.Pretty())
+		}
+	}
+}
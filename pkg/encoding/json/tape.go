@@ -0,0 +1,513 @@
+package json
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// A Document stores a parsed value as a tape: a flat slice of tagged 64 bit
+// entries, one per value, and a buffer holding the decoded strings. Compared
+// to a tree of maps and interfaces it takes a fraction of the memory and a
+// handful of allocations, and it is read through a Cursor.
+//
+// Every entry holds its tag in the top byte and a payload in the rest:
+//
+//	null, true, false   no payload
+//	integer             the value, for integers that fit in 53 bits
+//	number              the next entry holds the float64 bits
+//	string              offset in the buffer of the uvarint length and bytes
+//	{ [                 index of the matching } ]
+//	} ]                 number of members or elements
+//
+// An object member is its key, a string entry, followed by its value. Equal
+// keys share their bytes in the buffer.
+type Document struct {
+	tape    []uint64
+	strings []byte
+}
+
+const (
+	tapeNull        = 'n'
+	tapeTrue        = 't'
+	tapeFalse       = 'f'
+	tapeInteger     = 'i'
+	tapeNumber      = 'd'
+	tapeString      = 's'
+	tapeObjectStart = '{'
+	tapeObjectEnd   = '}'
+	tapeArrayStart  = '['
+	tapeArrayEnd    = ']'
+
+	tapePayload = 1<<56 - 1
+	// maxTapeInteger bounds the integers stored in the payload, past it
+	// float64 loses precision anyway.
+	maxTapeInteger = 1 << 53
+)
+
+func tapeEntry(tag byte, payload int) uint64 {
+	return uint64(tag)<<56 | uint64(payload)
+}
+
+// ParseDocument parses json into a Document. It accepts and rejects the same
+// inputs as ParseJson.
+func ParseDocument(json string) (*Document, error) {
+	if !utf8.ValidString(json) {
+		// like ParseJson, replace every invalid byte with U+FFFD
+		json = string([]rune(json))
+	}
+	b := &tapeBuilder{
+		scanner: scanner{input: json},
+		doc:     &Document{tape: make([]uint64, 0, len(json)/8+1)},
+		keys:    make(map[string]int),
+	}
+	if err := b.value(b.scanner.NextToken()); err != nil {
+		return nil, err
+	}
+	if b.scanner.NextToken().Kind != TokenKindEOF {
+		return nil, b.unexpected()
+	}
+	return b.doc, nil
+}
+
+type tapeBuilder struct {
+	scanner scanner
+	doc     *Document
+	// keys maps the keys seen so far to their offset in the buffer
+	keys map[string]int
+}
+
+func (b *tapeBuilder) value(token Token) error {
+	doc := b.doc
+	switch token.Kind {
+	case TokenKindEOF:
+		return syntaxError(&b.scanner, "EOF: end of file")
+	case TokenKindNull:
+		doc.tape = append(doc.tape, tapeEntry(tapeNull, 0))
+	case TokenKindBoolean:
+		if token.Value == "true" {
+			doc.tape = append(doc.tape, tapeEntry(tapeTrue, 0))
+		} else {
+			doc.tape = append(doc.tape, tapeEntry(tapeFalse, 0))
+		}
+	case TokenKindNumber:
+		number, err := strconv.ParseFloat(token.Value, 64)
+		if err != nil {
+			return numberError(&b.scanner, err)
+		}
+		if number == math.Trunc(number) && math.Abs(number) < maxTapeInteger && !(number == 0 && math.Signbit(number)) {
+			doc.tape = append(doc.tape, tapeEntry(tapeInteger, int(int64(number))&tapePayload))
+		} else {
+			doc.tape = append(doc.tape, tapeEntry(tapeNumber, 0), math.Float64bits(number))
+		}
+	case TokenKindString:
+		b.string(token.Value)
+	case TokenKindBraceOpen:
+		return b.object()
+	case TokenKindBracketOpen:
+		return b.array()
+	default:
+		return b.unexpected()
+	}
+	return nil
+}
+
+func (b *tapeBuilder) string(token string) {
+	doc := b.doc
+	s := unquoteString(token)
+	doc.tape = append(doc.tape, tapeEntry(tapeString, len(doc.strings)))
+	doc.strings = binary.AppendUvarint(doc.strings, uint64(len(s)))
+	doc.strings = append(doc.strings, s...)
+}
+
+func (b *tapeBuilder) key(token string) {
+	doc := b.doc
+	s := unquoteString(token)
+	if offset, ok := b.keys[s]; ok {
+		doc.tape = append(doc.tape, tapeEntry(tapeString, offset))
+		return
+	}
+	b.keys[s] = len(doc.strings)
+	doc.tape = append(doc.tape, tapeEntry(tapeString, len(doc.strings)))
+	doc.strings = binary.AppendUvarint(doc.strings, uint64(len(s)))
+	doc.strings = append(doc.strings, s...)
+}
+
+// close sets the payloads of the container started at start.
+func (b *tapeBuilder) close(start int, tag byte, count int) {
+	doc := b.doc
+	doc.tape[start] |= uint64(len(doc.tape))
+	doc.tape = append(doc.tape, tapeEntry(tag, count))
+}
+
+func (b *tapeBuilder) array() error {
+	start := len(b.doc.tape)
+	b.doc.tape = append(b.doc.tape, tapeEntry(tapeArrayStart, 0))
+	token := b.scanner.NextToken()
+	if token.Kind == TokenKindBracketClose {
+		b.close(start, tapeArrayEnd, 0)
+		return nil
+	}
+	for count := 1; ; count++ {
+		if err := b.value(token); err != nil {
+			return err
+		}
+		switch b.scanner.NextToken().Kind {
+		case TokenKindBracketClose:
+			b.close(start, tapeArrayEnd, count)
+			return nil
+		case TokenKindComma:
+			token = b.scanner.NextToken()
+		default:
+			return b.unexpected()
+		}
+	}
+}
+
+func (b *tapeBuilder) object() error {
+	start := len(b.doc.tape)
+	b.doc.tape = append(b.doc.tape, tapeEntry(tapeObjectStart, 0))
+	for count := 0; ; count++ {
+		token := b.scanner.NextToken()
+		switch token.Kind {
+		case TokenKindBraceClose:
+			if count > 0 {
+				return b.unexpected()
+			}
+			b.close(start, tapeObjectEnd, 0)
+			return nil
+		case TokenKindString:
+			b.key(token.Value)
+			if b.scanner.NextToken().Kind != TokenKindColon {
+				return b.unexpected()
+			}
+			if err := b.value(b.scanner.NextToken()); err != nil {
+				return err
+			}
+			switch b.scanner.NextToken().Kind {
+			case TokenKindBraceClose:
+				b.close(start, tapeObjectEnd, count+1)
+				return nil
+			case TokenKindComma:
+				continue
+			default:
+				return b.unexpected()
+			}
+		default:
+			return b.unexpected()
+		}
+	}
+}
+
+func (b *tapeBuilder) unexpected() error {
+	return unexpected(&b.scanner)
+}
+
+// Root returns a cursor on the whole document.
+func (d *Document) Root() Cursor {
+	return Cursor{doc: d}
+}
+
+// Value converts the document to the values returned by ParseJson.
+func (d *Document) Value() any {
+	return d.Root().Value()
+}
+
+// MarshalJSON writes the document back as compact JSON.
+func (d *Document) MarshalJSON() ([]byte, error) {
+	return d.Root().AppendJSON(nil), nil
+}
+
+// Size returns the number of bytes held by the tape and the string buffer.
+func (d *Document) Size() int {
+	return len(d.tape)*8 + len(d.strings)
+}
+
+// Kind is the type of the value under a Cursor.
+type Kind int8
+
+const (
+	KindInvalid Kind = iota
+	KindNull
+	KindBoolean
+	KindNumber
+	KindString
+	KindArray
+	KindObject
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNull:
+		return "null"
+	case KindBoolean:
+		return "boolean"
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindArray:
+		return "array"
+	case KindObject:
+		return "object"
+	}
+	return "invalid"
+}
+
+// Cursor points at one value of a Document. The zero Cursor is invalid and
+// reports KindInvalid. Cursors are small values, copy them freely.
+type Cursor struct {
+	doc *Document
+	pos int
+}
+
+func (c Cursor) entry() (byte, int) {
+	e := c.doc.tape[c.pos]
+	return byte(e >> 56), int(e & tapePayload)
+}
+
+// Valid reports whether the cursor points at a value.
+func (c Cursor) Valid() bool {
+	return c.doc != nil
+}
+
+func (c Cursor) Kind() Kind {
+	if c.doc == nil {
+		return KindInvalid
+	}
+	switch tag, _ := c.entry(); tag {
+	case tapeNull:
+		return KindNull
+	case tapeTrue, tapeFalse:
+		return KindBoolean
+	case tapeInteger, tapeNumber:
+		return KindNumber
+	case tapeString:
+		return KindString
+	case tapeArrayStart:
+		return KindArray
+	case tapeObjectStart:
+		return KindObject
+	}
+	return KindInvalid
+}
+
+// Bool returns the value of a boolean, ok is false for other kinds.
+func (c Cursor) Bool() (value bool, ok bool) {
+	if c.doc == nil {
+		return false, false
+	}
+	tag, _ := c.entry()
+	return tag == tapeTrue, tag == tapeTrue || tag == tapeFalse
+}
+
+// Float returns the value of a number, ok is false for other kinds.
+func (c Cursor) Float() (value float64, ok bool) {
+	if c.Kind() != KindNumber {
+		return 0, false
+	}
+	return c.doc.float(c.pos), true
+}
+
+// Text returns the value of a string, ok is false for other kinds. The string
+// shares memory with the document.
+func (c Cursor) Text() (value string, ok bool) {
+	if c.Kind() != KindString {
+		return "", false
+	}
+	return c.doc.text(c.pos), true
+}
+
+func (d *Document) float(pos int) float64 {
+	e := d.tape[pos]
+	if byte(e>>56) == tapeInteger {
+		// sign extend the payload
+		return float64(int64(e<<8) >> 8)
+	}
+	return math.Float64frombits(d.tape[pos+1])
+}
+
+func (d *Document) text(pos int) string {
+	offset := int(d.tape[pos] & tapePayload)
+	length, n := binary.Uvarint(d.strings[offset:])
+	data := d.strings[offset+n : offset+n+int(length)]
+	if len(data) == 0 {
+		return ""
+	}
+	return unsafe.String(unsafe.SliceData(data), len(data))
+}
+
+// Len returns the number of elements of an array or members of an object,
+// and 0 for other kinds.
+func (c Cursor) Len() int {
+	switch c.Kind() {
+	case KindArray, KindObject:
+		_, end := c.entry()
+		return int(c.doc.tape[end] & tapePayload)
+	}
+	return 0
+}
+
+// next returns the position of the value following the one at pos.
+func (d *Document) next(pos int) int {
+	e := d.tape[pos]
+	switch byte(e >> 56) {
+	case tapeNumber:
+		return pos + 2
+	case tapeObjectStart, tapeArrayStart:
+		return int(e&tapePayload) + 1
+	}
+	return pos + 1
+}
+
+// Index returns the i-th element of an array.
+func (c Cursor) Index(i int) (Cursor, bool) {
+	if c.Kind() != KindArray || i < 0 || i >= c.Len() {
+		return Cursor{}, false
+	}
+	pos := c.pos + 1
+	for ; i > 0; i-- {
+		pos = c.doc.next(pos)
+	}
+	return Cursor{doc: c.doc, pos: pos}, true
+}
+
+// Field returns the value of the last member named key of an object, like
+// ParseJson keeps the last duplicate.
+func (c Cursor) Field(key string) (Cursor, bool) {
+	var found Cursor
+	c.Members(func(k string, value Cursor) bool {
+		if k == key {
+			found = value
+		}
+		return true
+	})
+	return found, found.doc != nil
+}
+
+// Elements calls fn with every element of an array until fn returns false.
+func (c Cursor) Elements(fn func(i int, value Cursor) bool) {
+	if c.Kind() != KindArray {
+		return
+	}
+	_, end := c.entry()
+	for i, pos := 0, c.pos+1; pos < end; i, pos = i+1, c.doc.next(pos) {
+		if !fn(i, Cursor{doc: c.doc, pos: pos}) {
+			return
+		}
+	}
+}
+
+// Members calls fn with every member of an object, in the order of the
+// input, until fn returns false.
+func (c Cursor) Members(fn func(key string, value Cursor) bool) {
+	if c.Kind() != KindObject {
+		return
+	}
+	_, end := c.entry()
+	for pos := c.pos + 1; pos < end; pos = c.doc.next(pos + 1) {
+		if !fn(c.doc.text(pos), Cursor{doc: c.doc, pos: pos + 1}) {
+			return
+		}
+	}
+}
+
+// Keys returns the keys of an object in the order of the input.
+func (c Cursor) Keys() []string {
+	keys := make([]string, 0, c.Len())
+	c.Members(func(key string, _ Cursor) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Value converts the value under the cursor to the values returned by
+// ParseJson.
+func (c Cursor) Value() any {
+	switch c.Kind() {
+	case KindBoolean:
+		value, _ := c.Bool()
+		return value
+	case KindNumber:
+		value, _ := c.Float()
+		return value
+	case KindString:
+		value, _ := c.Text()
+		return value
+	case KindArray:
+		arr := make([]any, 0, c.Len())
+		c.Elements(func(_ int, value Cursor) bool {
+			arr = append(arr, value.Value())
+			return true
+		})
+		return arr
+	case KindObject:
+		obj := make(map[string]any, c.Len())
+		c.Members(func(key string, value Cursor) bool {
+			obj[key] = value.Value()
+			return true
+		})
+		return obj
+	}
+	return nil
+}
+
+// AppendJSON appends the value under the cursor as compact JSON.
+func (c Cursor) AppendJSON(dst []byte) []byte {
+	if c.doc == nil {
+		return append(dst, "null"...)
+	}
+	e := &encoder{buf: dst}
+	end := c.doc.next(c.pos)
+	// first tells whether the next entry opens a container or follows a colon
+	first := true
+	objects := make([]bool, 0)
+	for pos := c.pos; pos < end; {
+		tag := byte(c.doc.tape[pos] >> 56)
+		if tag != tapeObjectEnd && tag != tapeArrayEnd && !first {
+			e.buf = append(e.buf, ',')
+		}
+		first = false
+		inObject := len(objects) > 0 && objects[len(objects)-1]
+		if inObject && tag != tapeObjectEnd {
+			// a member: the key, then its value
+			e.buf = appendQuoted(e.buf, c.doc.text(pos))
+			e.buf = append(e.buf, ':')
+			pos++
+			tag = byte(c.doc.tape[pos] >> 56)
+		}
+		switch tag {
+		case tapeNull:
+			e.buf = append(e.buf, "null"...)
+		case tapeTrue:
+			e.buf = append(e.buf, "true"...)
+		case tapeFalse:
+			e.buf = append(e.buf, "false"...)
+		case tapeInteger:
+			e.buf = strconv.AppendInt(e.buf, int64(c.doc.tape[pos]<<8)>>8, 10)
+		case tapeNumber:
+			// the tape never holds infinities or NaN
+			_ = e.float(c.doc.float(pos), 64)
+			pos++
+		case tapeString:
+			e.buf = appendQuoted(e.buf, c.doc.text(pos))
+		case tapeObjectStart, tapeArrayStart:
+			e.buf = append(e.buf, tag)
+			objects = append(objects, tag == tapeObjectStart)
+			first = true
+		case tapeObjectEnd, tapeArrayEnd:
+			e.buf = append(e.buf, tag)
+			objects = objects[:len(objects)-1]
+		}
+		pos++
+	}
+	return e.buf
+}
+
+// MarshalJSON writes the value under the cursor as compact JSON.
+func (c Cursor) MarshalJSON() ([]byte, error) {
+	return c.AppendJSON(nil), nil
+}
@@ -0,0 +1,144 @@
+package json
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseDocument_Conformance(t *testing.T) {
+	for name, input := range conformanceAccept {
+		expected, err := ParseJson(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc, err := ParseDocument(input)
+		if err != nil || !isEqual(doc.Value(), expected) {
+			t.Errorf("FAIL: %s: expected %v but got %v", name, expected, err)
+			continue
+		}
+		// the serialized document parses back to the same value
+		out, _ := doc.MarshalJSON()
+		if actual, err := ParseJson(string(out)); err != nil || !isEqual(actual, expected) {
+			t.Errorf("FAIL: %s: %s does not round trip: %v", name, out, err)
+		}
+	}
+	for name, input := range conformanceReject {
+		if _, err := ParseDocument(input); err == nil {
+			t.Errorf("FAIL: %s: expected an error for %q", name, input)
+		}
+	}
+	for name, c := range conformanceImplementationDefined {
+		expected, expectedErr := ParseJson(c.In)
+		doc, err := ParseDocument(c.In)
+		if (err == nil) != (expectedErr == nil) || err == nil && !isEqual(doc.Value(), expected) {
+			t.Errorf("FAIL: %s: expected %v %v but got %v", name, expected, expectedErr, err)
+		}
+	}
+}
+
+func TestParseDocument_MarshalJSON(t *testing.T) {
+	cases := map[string]string{
+		`{ "a" : [1, 2.5, -0, 1e21, {"b": null}], "c": "é\n\"", "d": {}, "e": [] }`: `{"a":[1,2.5,-0,1e+21,{"b":null}],"c":"é\n\"","d":{},"e":[]}`,
+		` [ true , false , "<>" ] `:       `[true,false,"<>"]`,
+		`"x"`:                             `"x"`,
+		`[[[]], [{}], {"a": {"b": [1]}}]`: `[[[]],[{}],{"a":{"b":[1]}}]`,
+	}
+	for input, expected := range cases {
+		doc, err := ParseDocument(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out, _ := doc.MarshalJSON(); string(out) != expected {
+			t.Errorf("FAIL: %s: expected %s but got %s", input, expected, out)
+		}
+	}
+}
+
+func TestCursor(t *testing.T) {
+	doc, err := ParseDocument(`{"name": "żółw", "tags": ["a", "b", "c"], "n": 42, "ok": true, "none": null, "dup": 1, "dup": 2}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := doc.Root()
+	if root.Kind() != KindObject || root.Len() != 7 {
+		t.Errorf("FAIL: expected an object of 7 members but got %s of %d", root.Kind(), root.Len())
+	}
+	if keys := strings.Join(root.Keys(), " "); keys != "name tags n ok none dup dup" {
+		t.Errorf("FAIL: expected the keys in document order but got %s", keys)
+	}
+
+	if name, ok := root.Field("name"); !ok || name.Kind() != KindString {
+		t.Errorf("FAIL: expected the name field")
+	} else if text, _ := name.Text(); text != "żółw" {
+		t.Errorf("FAIL: expected żółw but got %s", text)
+	}
+	if n, _ := root.Field("n"); n.Kind() != KindNumber {
+		t.Errorf("FAIL: expected a number but got %s", n.Kind())
+	} else if f, _ := n.Float(); f != 42 {
+		t.Errorf("FAIL: expected 42 but got %v", f)
+	}
+	if ok, _ := root.Field("ok"); ok.Kind() != KindBoolean {
+		t.Errorf("FAIL: expected a boolean but got %s", ok.Kind())
+	} else if b, _ := ok.Bool(); !b {
+		t.Errorf("FAIL: expected true")
+	}
+	if none, _ := root.Field("none"); none.Kind() != KindNull || none.Value() != nil {
+		t.Errorf("FAIL: expected null but got %s", none.Kind())
+	}
+	if dup, _ := root.Field("dup"); dup.Value() != 2.0 {
+		t.Errorf("FAIL: expected the last duplicate but got %v", dup.Value())
+	}
+	if missing, ok := root.Field("missing"); ok || missing.Valid() || missing.Kind() != KindInvalid {
+		t.Errorf("FAIL: expected an invalid cursor for a missing key")
+	}
+
+	tags, _ := root.Field("tags")
+	if c, ok := tags.Index(2); !ok || c.Value() != "c" {
+		t.Errorf("FAIL: expected c but got %v", c.Value())
+	}
+	if _, ok := tags.Index(3); ok {
+		t.Errorf("FAIL: expected no element at 3")
+	}
+	if _, ok := root.Index(0); ok {
+		t.Errorf("FAIL: expected no element in an object")
+	}
+	seen := make([]string, 0)
+	tags.Elements(func(i int, v Cursor) bool {
+		text, _ := v.Text()
+		seen = append(seen, text)
+		return i < 1
+	})
+	if strings.Join(seen, "") != "ab" {
+		t.Errorf("FAIL: expected the iteration to stop after b but got %v", seen)
+	}
+	if out, _ := tags.MarshalJSON(); string(out) != `["a","b","c"]` {
+		t.Errorf("FAIL: expected the array but got %s", out)
+	}
+	if _, ok := tags.Text(); ok {
+		t.Errorf("FAIL: expected no text for an array")
+	}
+}
+
+// allocated returns the bytes allocated by fn.
+func allocated(fn func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	fn()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
+
+func TestParseDocument_Memory(t *testing.T) {
+	input := largeArray(1000)
+	var doc *Document
+	tree := allocated(func() { _, _ = ParseJson(input) })
+	tape := allocated(func() { doc, _ = ParseDocument(input) })
+	if tape*3 > tree {
+		t.Errorf("FAIL: expected the document to allocate a fraction of the tree, got %d for %d bytes", tape, tree)
+	}
+	// the keys are stored once
+	if len(doc.strings) > 1000*(1+len("żółw"))+100 {
+		t.Errorf("FAIL: expected the keys to be shared but got %d bytes of strings", len(doc.strings))
+	}
+}
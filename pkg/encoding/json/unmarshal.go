@@ -0,0 +1,323 @@
+package json
+
+import (
+	"encoding"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/internal/structfields"
+)
+
+// Unmarshal decodes data into the value pointed to by v. Registered codecs
+// come first, then UnmarshalJSON and UnmarshalText methods. Otherwise values
+// are decoded like encoding/json does, with object members matched to struct
+// fields by their `json` name, exactly or else case-insensitively. null sets
+// pointers, interfaces, maps and slices to nil and leaves other values
+// unchanged. Decoding stops at the first error.
+func Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("cannot unmarshal into non-pointer type %T", v)
+	}
+	idx, err := BuildIndex(string(data))
+	if err != nil {
+		return err
+	}
+	d := &decoder{idx: idx, tokens: idx.tokens()}
+	if err := d.value(rv.Elem()); err != nil {
+		return err
+	}
+	return d.tokens.expectEOF()
+}
+
+type decoder struct {
+	idx    *StructuralIndex
+	tokens *indexLexer
+}
+
+func (d *decoder) value(rv reflect.Value) error {
+	l := d.tokens
+	if !l.valueAhead() {
+		if l.currentToken.Kind == TokenKindEOF {
+			return errors.New("EOF: end of file")
+		}
+		return l.unexpected()
+	}
+
+	if c, ok := lookupCodec(rv.Type()); ok {
+		value, err := newJsonParser(l).parseValue()
+		if err != nil {
+			return err
+		}
+		return c.decode(value, rv)
+	}
+	if rv.Kind() == reflect.Pointer {
+		if l.token(l.i).Kind == TokenKindNull {
+			l.NextToken()
+			rv.SetZero()
+			return nil
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return d.value(rv.Elem())
+	}
+	if m, ok := withMethod(rv, unmarshalerType); ok {
+		raw, err := d.raw()
+		if err != nil {
+			return err
+		}
+		return m.Interface().(Unmarshaler).UnmarshalJSON(raw)
+	}
+	if m, ok := withMethod(rv, textUnmarshalerType); ok {
+		token := l.NextToken()
+		switch token.Kind {
+		case TokenKindNull:
+			return nil
+		case TokenKindString:
+			return m.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(unquoteString(token.Value)))
+		}
+		return d.mismatch(rv)
+	}
+	if rv.Kind() == reflect.Interface {
+		if rv.NumMethod() != 0 {
+			return fmt.Errorf("cannot unmarshal into interface type %s", rv.Type())
+		}
+		value, err := newJsonParser(l).parseValue()
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			rv.SetZero()
+		} else {
+			rv.Set(reflect.ValueOf(value))
+		}
+		return nil
+	}
+
+	token := l.NextToken()
+	switch token.Kind {
+	case TokenKindNull:
+		switch rv.Kind() {
+		case reflect.Map, reflect.Slice:
+			rv.SetZero()
+		}
+		return nil
+	case TokenKindBoolean:
+		if rv.Kind() != reflect.Bool {
+			return d.mismatch(rv)
+		}
+		rv.SetBool(token.Value == "true")
+		return nil
+	case TokenKindNumber:
+		return d.number(token.Value, rv)
+	case TokenKindString:
+		s := unquoteString(token.Value)
+		switch {
+		case rv.Kind() == reflect.String:
+			rv.SetString(s)
+		case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8:
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return err
+			}
+			rv.SetBytes(b)
+		default:
+			return d.mismatch(rv)
+		}
+		return nil
+	case TokenKindBracketOpen:
+		return d.array(rv)
+	case TokenKindBraceOpen:
+		return d.object(rv)
+	default:
+		return l.unexpected()
+	}
+}
+
+func (d *decoder) number(s string, rv reflect.Value) error {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot unmarshal number %s into Go value of type %s", s, rv.Type())
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot unmarshal number %s into Go value of type %s", s, rv.Type())
+		}
+		rv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetFloat(f)
+	default:
+		return d.mismatch(rv)
+	}
+	return nil
+}
+
+// array decodes the elements following [ into a slice or an array. Elements
+// beyond the length of an array are validated and dropped.
+func (d *decoder) array(rv reflect.Value) error {
+	l := d.tokens
+	switch rv.Kind() {
+	case reflect.Slice:
+		rv.SetLen(0)
+	case reflect.Array:
+	default:
+		return d.mismatch(rv)
+	}
+
+	n := 0
+	if l.token(l.i).Kind == TokenKindBracketClose {
+		l.NextToken()
+	} else {
+		for {
+			switch {
+			case rv.Kind() == reflect.Slice:
+				elem := reflect.New(rv.Type().Elem()).Elem()
+				if err := d.value(elem); err != nil {
+					return err
+				}
+				rv.Set(reflect.Append(rv, elem))
+			case n < rv.Len():
+				if err := d.value(rv.Index(n)); err != nil {
+					return err
+				}
+			default:
+				if _, err := d.raw(); err != nil {
+					return err
+				}
+			}
+			n++
+
+			if l.NextToken().Kind == TokenKindBracketClose {
+				break
+			}
+			if l.currentToken.Kind != TokenKindComma {
+				return l.unexpected()
+			}
+		}
+	}
+
+	if rv.Kind() == reflect.Slice && rv.IsNil() {
+		rv.Set(reflect.MakeSlice(rv.Type(), 0, 0))
+	}
+	for ; n < rv.Len() && rv.Kind() == reflect.Array; n++ {
+		rv.Index(n).SetZero()
+	}
+	return nil
+}
+
+// object decodes the members following { into a struct or a map. Members
+// without a matching struct field are validated and dropped.
+func (d *decoder) object(rv reflect.Value) error {
+	l := d.tokens
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMap(rv.Type()))
+		}
+	case reflect.Struct:
+	default:
+		return d.mismatch(rv)
+	}
+
+	for first := true; ; first = false {
+		token := l.NextToken()
+		if first && token.Kind == TokenKindBraceClose {
+			return nil
+		}
+		if token.Kind != TokenKindString || l.NextToken().Kind != TokenKindColon {
+			return l.unexpected()
+		}
+		name := unquoteString(token.Value)
+
+		if rv.Kind() == reflect.Map {
+			key := reflect.New(rv.Type().Key()).Elem()
+			if err := decodeKey(name, key); err != nil {
+				return err
+			}
+			value := reflect.New(rv.Type().Elem()).Elem()
+			if err := d.value(value); err != nil {
+				return err
+			}
+			rv.SetMapIndex(key, value)
+		} else if f, ok := structfields.Lookup(structfields.Of(rv.Type()), name); ok {
+			field, ok := f.Set(rv)
+			if !ok {
+				return fmt.Errorf("cannot set field %s of %s through an embedded pointer to an unexported struct", f.Name, rv.Type())
+			}
+			if err := d.value(field); err != nil {
+				return err
+			}
+		} else if _, err := d.raw(); err != nil {
+			return err
+		}
+
+		if l.NextToken().Kind == TokenKindBraceClose {
+			return nil
+		}
+		if l.currentToken.Kind != TokenKindComma {
+			return l.unexpected()
+		}
+	}
+}
+
+// decodeKey converts an object member name to a map key. String, integer and
+// encoding.TextUnmarshaler keys are supported.
+func decodeKey(name string, key reflect.Value) error {
+	if m, ok := withMethod(key, textUnmarshalerType); ok && key.Kind() != reflect.Pointer {
+		return m.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(name))
+	}
+	switch key.Kind() {
+	case reflect.String:
+		key.SetString(name)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(name, 10, key.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot unmarshal key %q into Go value of type %s", name, key.Type())
+		}
+		key.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(name, 10, key.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot unmarshal key %q into Go value of type %s", name, key.Type())
+		}
+		key.SetUint(n)
+		return nil
+	}
+	return fmt.Errorf("unsupported map key type %s", key.Type())
+}
+
+// raw returns the bytes of the next value after checking that it is valid
+// JSON, and moves past it.
+func (d *decoder) raw() ([]byte, error) {
+	l := d.tokens
+	if !l.valueAhead() {
+		return nil, l.unexpected()
+	}
+	start := int(d.idx.positions[l.i])
+	end, next := d.idx.valueEnd(l.i)
+	raw := d.idx.input[start:end]
+	if _, err := parseInPlace(raw); err != nil {
+		return nil, err
+	}
+	l.i = next
+	return []byte(raw), nil
+}
+
+func (d *decoder) mismatch(rv reflect.Value) error {
+	token := d.tokens.current()
+	return fmt.Errorf("cannot unmarshal %s `%s` into Go value of type %s", token.Kind.toString(), token.Value, rv.Type())
+}
@@ -1,24 +1,141 @@
 package json
 
 import (
-	"fmt"
-	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
+// unquoteString decodes a string token produced by the lexer. The token is
+// expected to be valid; lone surrogate escapes decode to U+FFFD.
 func unquoteString(s string) string {
-	value, err := strconv.Unquote(s)
-	if err != nil {
-		fmt.Println("WARN: failed to unquote string: ", err)
+	if len(s) < 2 {
 		return s
 	}
-	return value
+	s = s[1 : len(s)-1]
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case 'u':
+			r := decodeHex4(s[i+1:])
+			i += 4
+			if utf16.IsSurrogate(r) {
+				r2 := rune(-1)
+				if len(s) > i+6 && s[i+1] == '\\' && s[i+2] == 'u' {
+					r2 = decodeHex4(s[i+3:])
+				}
+				if decoded := utf16.DecodeRune(r, r2); decoded != utf8.RuneError {
+					r = decoded
+					i += 6
+				} else {
+					r = utf8.RuneError
+				}
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
+
+func decodeHex4(s string) rune {
+	var r rune
+	for i := 0; i < 4 && i < len(s); i++ {
+		c := rune(s[i])
+		switch {
+		case isDigit(c):
+			c -= '0'
+		case 'a' <= c && c <= 'f':
+			c -= 'a' - 10
+		case 'A' <= c && c <= 'F':
+			c -= 'A' - 10
+		default:
+			return -1
+		}
+		r = r<<4 | c
+	}
+	return r
+}
+
+// isValidString reports whether s is a complete string token, quotes included.
+func isValidString(s string) bool {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return false
+	}
+	for i := 1; i < len(s)-1; i++ {
+		switch c := s[i]; {
+		case c < 0x20 || c == '"':
+			return false
+		case c == '\\':
+			i++
+			if i == len(s)-1 || !isEscapable(rune(s[i])) {
+				return false
+			}
+			if s[i] == 'u' {
+				if i+4 >= len(s)-1 || decodeHex4(s[i+1:i+5]) < 0 {
+					return false
+				}
+				i += 4
+			}
+		}
+	}
+	return true
 }
 
-//func unquoteString(s string) string {
-//	start := 1
-//	end := len(s) - 1
-//	if s[0] == '"' && s[end] == '"' {
-//		return s[start:end]
-//	}
-//	return s
-//}
+// isValidNumber reports whether s is a number following the RFC 8259 grammar.
+func isValidNumber(s string) bool {
+	i := 0
+	digits := func() bool {
+		start := i
+		for i < len(s) && isDigit(rune(s[i])) {
+			i++
+		}
+		return i > start
+	}
+
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	if i < len(s) && s[i] == '0' {
+		i++
+	} else if !digits() {
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		if !digits() {
+			return false
+		}
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		if !digits() {
+			return false
+		}
+	}
+	return i == len(s)
+}
@@ -0,0 +1,344 @@
+// Package json2go writes Go type declarations for sample JSON documents
+// decoded with json.ParseJson.
+package json2go
+
+import (
+	"errors"
+	"fmt"
+	"go/format"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type kind uint8
+
+const (
+	kindNull kind = 1 << iota
+	kindBool
+	kindInt
+	kindFloat
+	kindString
+	kindArray
+	kindObject
+)
+
+// shape is the union of every value seen at one place of the samples.
+type shape struct {
+	kinds kind
+	// elem merges the elements of all the arrays.
+	elem *shape
+	// fields and objects merge all the objects; a field seen in fewer objects
+	// than objects is optional.
+	fields  map[string]*field
+	objects int
+}
+
+type field struct {
+	shape shape
+	seen  int
+}
+
+func (s *shape) add(value any) {
+	switch value := value.(type) {
+	case nil:
+		s.kinds |= kindNull
+	case bool:
+		s.kinds |= kindBool
+	case float64:
+		if value == math.Trunc(value) && math.Abs(value) < 1<<63 {
+			s.kinds |= kindInt
+		} else {
+			s.kinds |= kindFloat
+		}
+	case string:
+		s.kinds |= kindString
+	case []any:
+		s.kinds |= kindArray
+		if s.elem == nil {
+			s.elem = &shape{}
+		}
+		for _, item := range value {
+			s.elem.add(item)
+		}
+	case map[string]any:
+		s.kinds |= kindObject
+		s.objects++
+		if s.fields == nil {
+			s.fields = make(map[string]*field)
+		}
+		for key, item := range value {
+			f, ok := s.fields[key]
+			if !ok {
+				f = &field{}
+				s.fields[key] = f
+			}
+			f.seen++
+			f.shape.add(item)
+		}
+	}
+}
+
+// Generator merges sample documents and declares the Go types that decode all
+// of them. Objects become structs with json tags; fields missing from some
+// samples get omitempty and fields that are null in some samples become
+// pointers. Integers and floats merge into float64, other mixed kinds into
+// any.
+type Generator struct {
+	// Package is the package clause of the output, main when empty.
+	Package string
+	// Name is the name of the root type, Root when empty.
+	Name string
+
+	root    shape
+	samples int
+}
+
+// Add merges a document decoded with json.ParseJson into the samples.
+func (g *Generator) Add(document any) {
+	g.root.add(document)
+	g.samples++
+}
+
+// Generate returns the formatted source declaring the root type and the
+// structs nested in it.
+func (g *Generator) Generate() ([]byte, error) {
+	if g.samples == 0 {
+		return nil, errors.New("json2go: no samples")
+	}
+	pkg := g.Package
+	if pkg == "" {
+		pkg = "main"
+	}
+	name := g.Name
+	if name == "" {
+		name = "Root"
+	}
+	if !isIdentifier(pkg) || !isIdentifier(name) {
+		return nil, fmt.Errorf("json2go: invalid package or type name %q %q", pkg, name)
+	}
+
+	e := &emitter{taken: map[string]bool{name: true}}
+	root := &decl{name: name}
+	e.decls = append(e.decls, root)
+	if g.root.kinds&^kindNull == kindObject {
+		root.body = e.structBody(&g.root, name)
+	} else {
+		root.body = e.goType(&g.root, name, name)
+	}
+
+	var src strings.Builder
+	fmt.Fprintf(&src, "package %s\n", pkg)
+	for _, d := range e.decls {
+		if d.body != "" {
+			fmt.Fprintf(&src, "\ntype %s %s\n", d.name, d.body)
+		}
+	}
+	return format.Source([]byte(src.String()))
+}
+
+// Generate is a shortcut for a Generator with the package main.
+func Generate(name string, documents ...any) ([]byte, error) {
+	g := &Generator{Name: name}
+	for _, document := range documents {
+		g.Add(document)
+	}
+	return g.Generate()
+}
+
+type decl struct {
+	name string
+	body string
+}
+
+type emitter struct {
+	taken map[string]bool
+	// decls are in the order the types are first referenced; the body of a
+	// struct that turned out identical to an earlier one stays empty.
+	decls []*decl
+}
+
+// goType returns the type of a value of shape s. name is the preferred name
+// of a struct declared for it and parent the type that holds it.
+func (e *emitter) goType(s *shape, name, parent string) string {
+	kinds := s.kinds &^ kindNull
+	if kinds == kindInt|kindFloat {
+		kinds = kindFloat
+	}
+	var t string
+	switch kinds {
+	case kindBool:
+		t = "bool"
+	case kindInt:
+		t = "int64"
+	case kindFloat:
+		t = "float64"
+	case kindString:
+		t = "string"
+	case kindArray:
+		return "[]" + e.goType(s.elem, singular(name), parent)
+	case kindObject:
+		t = e.structType(s, name, parent)
+	default:
+		return "any"
+	}
+	if s.kinds&kindNull != 0 {
+		return "*" + t
+	}
+	return t
+}
+
+// structType declares a struct for s, or reuses an identical one.
+func (e *emitter) structType(s *shape, name, parent string) string {
+	d := &decl{}
+	e.decls = append(e.decls, d)
+	// the fields are named before the struct, so its name is picked last
+	body := e.structBody(s, name)
+	for _, other := range e.decls {
+		if other.body == body {
+			e.decls = slices.DeleteFunc(e.decls, func(x *decl) bool { return x == d })
+			return other.name
+		}
+	}
+	d.name = e.unique(name, parent)
+	d.body = body
+	return d.name
+}
+
+func (e *emitter) unique(name, parent string) string {
+	candidate := name
+	if e.taken[candidate] {
+		candidate = parent + name
+	}
+	for i := 2; e.taken[candidate]; i++ {
+		candidate = parent + name + strconv.Itoa(i)
+	}
+	e.taken[candidate] = true
+	return candidate
+}
+
+func (e *emitter) structBody(s *shape, name string) string {
+	keys := make([]string, 0, len(s.fields))
+	for key := range s.fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var body strings.Builder
+	body.WriteString("struct {\n")
+	names := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		f := s.fields[key]
+		fieldName := exportedName(key)
+		for i := 2; names[fieldName]; i++ {
+			fieldName = exportedName(key) + strconv.Itoa(i)
+		}
+		names[fieldName] = true
+
+		t := e.goType(&f.shape, fieldName, name)
+		tag := key
+		if f.seen < s.objects {
+			tag += ",omitempty"
+			// omitempty has no effect on struct values
+			if f.shape.kinds == kindObject {
+				t = "*" + t
+			}
+		}
+		tag = "json:" + strconv.Quote(tag)
+		if strings.ContainsRune(tag, '`') {
+			tag = strconv.Quote(tag)
+		} else {
+			tag = "`" + tag + "`"
+		}
+		fmt.Fprintf(&body, "%s %s %s\n", fieldName, t, tag)
+	}
+	body.WriteString("}")
+	return body.String()
+}
+
+var initialisms = map[string]bool{
+	"API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true, "HTML": true,
+	"HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true, "OS": true, "RAM": true,
+	"SQL": true, "SSH": true, "TCP": true, "TLS": true, "TTL": true, "UI": true, "UID": true,
+	"URI": true, "URL": true, "UTF8": true, "UUID": true, "VM": true, "XML": true,
+}
+
+// exportedName turns a key such as user_id, userId or user-id into UserID.
+func exportedName(key string) string {
+	var b strings.Builder
+	for _, word := range splitWords(key) {
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(word)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(word[size:])
+	}
+	name := b.String()
+	if name == "" {
+		return "Field"
+	}
+	if r, _ := utf8.DecodeRuneInString(name); !unicode.IsLetter(r) {
+		return "X" + name
+	}
+	return name
+}
+
+// splitWords splits on anything but letters and digits, and before an upper
+// case letter that follows a lower case one.
+func splitWords(key string) []string {
+	words := make([]string, 0)
+	start := -1
+	var previous rune
+	for i, r := range key {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			if start >= 0 {
+				words = append(words, key[start:i])
+				start = -1
+			}
+		case start < 0:
+			start = i
+		case unicode.IsUpper(r) && unicode.IsLower(previous):
+			words = append(words, key[start:i])
+			start = i
+		}
+		previous = r
+	}
+	if start >= 0 {
+		words = append(words, key[start:])
+	}
+	return words
+}
+
+// singular names the elements of an array type called name.
+func singular(name string) string {
+	var s string
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		s = name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"),
+		strings.HasSuffix(name, "ches"), strings.HasSuffix(name, "shes"):
+		s = name[:len(name)-2]
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") &&
+		!strings.HasSuffix(name, "us") && !strings.HasSuffix(name, "is"):
+		s = name[:len(name)-1]
+	}
+	if s == "" {
+		return name + "Item"
+	}
+	return s
+}
+
+func isIdentifier(name string) bool {
+	for i, r := range name {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return name != ""
+}
@@ -0,0 +1,137 @@
+package json2go_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/json"
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/json2go"
+)
+
+func generate(t *testing.T, g *json2go.Generator, samples ...string) string {
+	t.Helper()
+	for _, sample := range samples {
+		document, err := json.ParseJson(sample)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.Add(document)
+	}
+	out, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestGenerate(t *testing.T) {
+	out := generate(t, &json2go.Generator{Package: "api", Name: "Order"},
+		`{"id": 1, "created_at": "2024-01-02", "total": 10, "customer": {"id": 7, "email": "a@b.c"},
+			"items": [{"sku": "a", "qty": 1}, {"sku": "b", "qty": 2, "price": 2.5}], "note": null, "tags": ["x"]}`,
+		`{"id": 2, "total": 12.5, "customer": {"id": 8, "email": "d@e.f"}, "seller": {"id": 1, "email": "s@t.u"},
+			"items": [], "note": "fragile", "tags": [], "coupon": {"code": "X"}}`,
+	)
+	expected := "package api\n" + `
+type Order struct {
+	Coupon    *Coupon  ` + "`json:\"coupon,omitempty\"`" + `
+	CreatedAt string   ` + "`json:\"created_at,omitempty\"`" + `
+	Customer  Customer ` + "`json:\"customer\"`" + `
+	ID        int64    ` + "`json:\"id\"`" + `
+	Items     []Item   ` + "`json:\"items\"`" + `
+	Note      *string  ` + "`json:\"note\"`" + `
+	Seller    *Customer ` + "`json:\"seller,omitempty\"`" + `
+	Tags      []string ` + "`json:\"tags\"`" + `
+	Total     float64  ` + "`json:\"total\"`" + `
+}
+
+type Coupon struct {
+	Code string ` + "`json:\"code\"`" + `
+}
+
+type Customer struct {
+	Email string ` + "`json:\"email\"`" + `
+	ID    int64  ` + "`json:\"id\"`" + `
+}
+
+type Item struct {
+	Price float64 ` + "`json:\"price,omitempty\"`" + `
+	Qty   int64   ` + "`json:\"qty\"`" + `
+	Sku   string  ` + "`json:\"sku\"`" + `
+}
+`
+	if normalize(out) != normalize(expected) {
+		t.Errorf("FAIL: expected\n%s\nbut got\n%s", expected, out)
+	}
+}
+
+// normalize ignores the alignment, which gofmt already guarantees.
+func normalize(src string) string {
+	return strings.Join(strings.Fields(src), " ")
+}
+
+func TestGenerate_Types(t *testing.T) {
+	cases := []struct {
+		samples  []string
+		expected string
+	}{
+		{[]string{`"a"`}, "type Root string"},
+		{[]string{`1`, `2.5`}, "type Root float64"},
+		{[]string{`1`, `null`}, "type Root *int64"},
+		{[]string{`1`, `"a"`}, "type Root any"},
+		{[]string{`null`}, "type Root any"},
+		{[]string{`[]`}, "type Root []any"},
+		{[]string{`[[true]]`}, "type Root [][]bool"},
+		{[]string{`[1, null]`}, "type Root []*int64"},
+		{[]string{`[{"a": 1}]`}, "type Root []RootItem type RootItem struct { A int64 `json:\"a\"` }"},
+		{[]string{`{"a": {"b": {"c": 1}}}`}, "type Root struct { A A `json:\"a\"` } type A struct { B B `json:\"b\"` } type B struct { C int64 `json:\"c\"` }"},
+		{[]string{`{"a": {"x": 1}, "b": {"a": "s"}}`}, "type Root struct { A A `json:\"a\"` B B `json:\"b\"` } type A struct { X int64 `json:\"x\"` } type B struct { A string `json:\"a\"` }"},
+		{[]string{`{"root": {"x": 1}}`}, "type Root struct { Root RootRoot `json:\"root\"` } type RootRoot struct { X int64 `json:\"x\"` }"},
+		{[]string{`{"categories": [{"x": 1}], "addresses": [{"y": 1}], "status": [{"z": 1}]}`}, "type Root struct { " +
+			"Addresses []Address `json:\"addresses\"` Categories []Category `json:\"categories\"` Status []StatusItem `json:\"status\"` } " +
+			"type Address struct { Y int64 `json:\"y\"` } type Category struct { X int64 `json:\"x\"` } type StatusItem struct { Z int64 `json:\"z\"` }"},
+		{[]string{`{"a": {"x": 1}}`, `{"a": null}`}, "type Root struct { A *A `json:\"a\"` } type A struct { X int64 `json:\"x\"` }"},
+		{[]string{`{"a": 1}`, `{"a": "x"}`}, "type Root struct { A any `json:\"a\"` }"},
+	}
+	for _, c := range cases {
+		out := generate(t, &json2go.Generator{}, c.samples...)
+		if got := strings.TrimPrefix(normalize(out), "package main "); got != c.expected {
+			t.Errorf("FAIL: %v: expected %s but got %s", c.samples, c.expected, got)
+		}
+	}
+}
+
+func TestGenerate_Names(t *testing.T) {
+	cases := map[string]string{
+		"user_id":    "UserID",
+		"userId":     "UserID",
+		"user-name":  "UserName",
+		"HTTPServer": "HTTPServer",
+		"api_url":    "APIURL",
+		"9lives":     "X9lives",
+		"@type":      "Type",
+		"":           "Field",
+		"żółw":       "Żółw",
+	}
+	for key, expected := range cases {
+		out := generate(t, &json2go.Generator{}, `{"`+key+`": true}`)
+		if !strings.Contains(out, expected+" bool") {
+			t.Errorf("FAIL: %q: expected the field %s but got\n%s", key, expected, out)
+		}
+	}
+
+	out := generate(t, &json2go.Generator{}, `{"a_b": 1, "a-b": "x", "a`+"`"+`b": true}`)
+	for _, expected := range []string{"AB  string", "AB2 int64", "AB3 bool", `"json:\"a` + "`" + `b\""`} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("FAIL: expected %s in\n%s", expected, out)
+		}
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	if _, err := (&json2go.Generator{}).Generate(); err == nil {
+		t.Errorf("FAIL: expected an error without samples")
+	}
+	if _, err := json2go.Generate("not a name", 1.0); err == nil {
+		t.Errorf("FAIL: expected an error for an invalid type name")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/json"
 )
 
 type Number interface {
@@ -15,6 +17,26 @@ type JSONValue interface {
 	bool | float64 | string | []any | map[string]any | Number
 }
 
+// ErrNotFound matches, through errors.Is, the errors of a missing key or an
+// out of range index, as opposed to indexing into the wrong type.
+var ErrNotFound = errors.New("not found")
+
+type notFoundError struct {
+	msg string
+}
+
+func (e notFoundError) Error() string {
+	return e.msg
+}
+
+func (notFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func notFound(format string, args ...any) error {
+	return notFoundError{fmt.Sprintf(format, args...)}
+}
+
 type JSONExplorer struct {
 	data any
 	_err error
@@ -28,6 +50,9 @@ func ValueOf[T JSONValue](simpleJson JSONExplorer) (x T, err error) {
 	if simpleJson._err != nil {
 		return x, simpleJson._err
 	}
+	if simpleJson.data, simpleJson._err = parseLazy(simpleJson.data); simpleJson._err != nil {
+		return x, simpleJson._err
+	}
 
 	switch data := simpleJson.data.(type) {
 	case T:
@@ -79,6 +104,38 @@ func convertValue(v reflect.Value) any {
 	}
 }
 
+// expandLazy turns a json.RawValue into a lazy object or array view, so that
+// only the members on the explored path get parsed, and a json.Document into
+// a cursor on its root.
+func expandLazy(data any) (any, error) {
+	switch value := data.(type) {
+	case json.RawValue:
+		return value.Lazy()
+	case *json.Document:
+		return value.Root(), nil
+	}
+	return data, nil
+}
+
+// parseLazy fully parses the lazy values, so that they can be converted.
+func parseLazy(data any) (any, error) {
+	switch value := data.(type) {
+	case json.RawValue:
+		return value.Parse()
+	case *json.LazyObject:
+		raw, _ := value.MarshalJSON()
+		return json.RawValue(raw).Parse()
+	case *json.LazyArray:
+		raw, _ := value.MarshalJSON()
+		return json.RawValue(raw).Parse()
+	case *json.Document:
+		return value.Value(), nil
+	case json.Cursor:
+		return value.Value(), nil
+	}
+	return data, nil
+}
+
 func (j JSONExplorer) At(x int) JSONExplorer {
 	if j._err != nil {
 		return j
@@ -87,12 +144,29 @@ func (j JSONExplorer) At(x int) JSONExplorer {
 		j._err = fmt.Errorf("invalid index %d: index must be non-negative", x)
 		return j
 	}
+	if j.data, j._err = expandLazy(j.data); j._err != nil {
+		return j
+	}
 	switch value := j.data.(type) {
 	case []any:
 		if x < len(value) {
 			j.data = value[x]
 		} else {
-			j._err = fmt.Errorf("index out of range: %d (slice length: %d)", x, len(value))
+			j._err = notFound("index out of range: %d (slice length: %d)", x, len(value))
+		}
+	case *json.LazyArray:
+		if x < value.Len() {
+			j.data, j._err = value.Raw(x)
+		} else {
+			j._err = notFound("index out of range: %d (slice length: %d)", x, value.Len())
+		}
+	case json.Cursor:
+		if value.Kind() != json.KindArray {
+			j._err = fmt.Errorf("cannot index into %s", value.Kind())
+		} else if next, ok := value.Index(x); ok {
+			j.data = next
+		} else {
+			j._err = notFound("index out of range: %d (slice length: %d)", x, value.Len())
 		}
 	default:
 		j._err = fmt.Errorf("cannot index into type= %T", reflect.TypeOf(j.data).Kind())
@@ -104,12 +178,29 @@ func (j JSONExplorer) Field(key string) JSONExplorer {
 	if j._err != nil {
 		return j
 	}
+	if j.data, j._err = expandLazy(j.data); j._err != nil {
+		return j
+	}
 	switch value := j.data.(type) {
 	case map[string]any:
 		if next, ok := value[key]; ok {
 			j.data = next
 		} else {
-			j._err = fmt.Errorf("key %s not found in object", key)
+			j._err = notFound("key %s not found in object", key)
+		}
+	case *json.LazyObject:
+		if next, ok := value.Raw(key); ok {
+			j.data = next
+		} else {
+			j._err = notFound("key %s not found in object", key)
+		}
+	case json.Cursor:
+		if value.Kind() != json.KindObject {
+			j._err = fmt.Errorf("key: %s not found in %s", key, value.Kind())
+		} else if next, ok := value.Field(key); ok {
+			j.data = next
+		} else {
+			j._err = notFound("key %s not found in object", key)
 		}
 	default:
 		j._err = fmt.Errorf("key: %s not found in type: %T", key, reflect.TypeOf(j.data).Kind().String())
@@ -141,6 +232,9 @@ func (j JSONExplorer) TraverseToKey(key string) JSONExplorer {
 	if j._err != nil {
 		return j
 	}
+	if j.data, j._err = expandLazy(j.data); j._err != nil {
+		return j
+	}
 
 	switch data := j.data.(type) {
 	case []any:
@@ -149,6 +243,24 @@ func (j JSONExplorer) TraverseToKey(key string) JSONExplorer {
 				return found
 			}
 		}
+	case *json.LazyArray:
+		for i := 0; i < data.Len(); i++ {
+			item, _ := data.Raw(i)
+			if found := NewJSONExplorer(item).TraverseToKey(key); found._err == nil {
+				return found
+			}
+		}
+	case *json.LazyObject:
+		for _, k := range data.Keys() {
+			item, _ := data.Raw(k)
+			explorer := NewJSONExplorer(item)
+			if k == key {
+				return explorer
+			}
+			if found := explorer.TraverseToKey(key); found._err == nil {
+				return found
+			}
+		}
 	case map[string]any:
 		for k, item := range data {
 			explorer := NewJSONExplorer(item)
@@ -159,6 +271,25 @@ func (j JSONExplorer) TraverseToKey(key string) JSONExplorer {
 				return found
 			}
 		}
+	case json.Cursor:
+		var result JSONExplorer
+		found := false
+		visit := func(k string, item json.Cursor) bool {
+			explorer := NewJSONExplorer(item)
+			if k == key {
+				result, found = explorer, true
+			} else if deeper := explorer.TraverseToKey(key); deeper._err == nil {
+				result, found = deeper, true
+			}
+			return !found
+		}
+		data.Members(visit)
+		data.Elements(func(_ int, item json.Cursor) bool {
+			return visit("", item)
+		})
+		if found {
+			return result
+		}
 	}
 	j._err = errors.New("TraverseToKey not found")
 	return j
@@ -2,7 +2,9 @@ package jsonexplorer_test
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	parser "github.com/GabiBizdoc/golang-playground/pkg/encoding/json"
 	. "github.com/GabiBizdoc/golang-playground/pkg/encoding/jsonexplorer"
 	"os"
 	"reflect"
@@ -79,6 +81,29 @@ func TestJSONExplorer_InvalidKeys(t *testing.T) {
 	}
 }
 
+func TestJSONExplorer_NotFound(t *testing.T) {
+	raw := parser.RawValue(`[{"a": 1}, 2]`)
+	doc, err := parser.ParseDocument(string(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := raw.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, root := range []any{parsed, raw, doc} {
+		explorer := NewJSONExplorer(root)
+		for _, path := range [][]any{{2}, {0, "missing"}} {
+			if _, err := explorer.Traverse(path...).Value(); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected ErrNotFound for %v in %T, but got %v", path, root, err)
+			}
+		}
+		if _, err := explorer.Traverse(1, "a").Value(); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected a type error for indexing a number in %T, but got %v", root, err)
+		}
+	}
+}
+
 func TestJSONExplorer_TraverseToKey(t *testing.T) {
 	value, err := ValueOf[int](NewJSONExplorer(data).TraverseToKey("n"))
 	if err != nil {
@@ -88,3 +113,67 @@ func TestJSONExplorer_TraverseToKey(t *testing.T) {
 		t.Errorf("Expected 42, but got %v ", value)
 	}
 }
+
+func TestJSONExplorer_RawValue(t *testing.T) {
+	raw := parser.RawValue(`{"a": [1, {"b": "c"}], "skipped": [1, 2, {"x": 3}], "n": 42}`)
+	explorer := NewJSONExplorer(raw)
+
+	value, err := ValueOf[string](explorer.Traverse("a", 1, "b"))
+	if err != nil || value != "c" {
+		t.Errorf("Expected c, but got %v (%v)", value, err)
+	}
+	n, err := ValueOf[int](explorer.TraverseToKey("x"))
+	if err != nil || n != 3 {
+		t.Errorf("Expected 3, but got %v (%v)", n, err)
+	}
+	arr, err := ValueOf[[]any](explorer.Field("a"))
+	if err != nil || !reflect.DeepEqual(arr, []any{1.0, map[string]any{"b": "c"}}) {
+		t.Errorf("Expected the parsed array, but got %v (%v)", arr, err)
+	}
+	if _, err := explorer.Traverse("a", 5).Value(); err == nil {
+		t.Errorf("Expected an error for an out of range index")
+	}
+
+	skipped, err := explorer.Field("skipped").Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := skipped.(parser.RawValue).MarshalJSON(); string(out) != `[1, 2, {"x": 3}]` {
+		t.Errorf("Expected the original bytes, but got %s", out)
+	}
+}
+
+func TestJSONExplorer_Document(t *testing.T) {
+	doc, err := parser.ParseDocument(`{"a": [1, {"b": "c"}], "skipped": [1, 2, {"x": 3}], "n": 42}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	explorer := NewJSONExplorer(doc)
+
+	value, err := ValueOf[string](explorer.Traverse("a", 1, "b"))
+	if err != nil || value != "c" {
+		t.Errorf("Expected c, but got %v (%v)", value, err)
+	}
+	n, err := ValueOf[int](explorer.TraverseToKey("x"))
+	if err != nil || n != 3 {
+		t.Errorf("Expected 3, but got %v (%v)", n, err)
+	}
+	arr, err := ValueOf[[]any](explorer.Field("a"))
+	if err != nil || !reflect.DeepEqual(arr, []any{1.0, map[string]any{"b": "c"}}) {
+		t.Errorf("Expected the converted array, but got %v (%v)", arr, err)
+	}
+	if _, err := explorer.Traverse("a", 5).Value(); err == nil {
+		t.Errorf("Expected an error for an out of range index")
+	}
+	if _, err := explorer.Traverse("n", 0).Value(); err == nil {
+		t.Errorf("Expected an error for indexing a number")
+	}
+
+	skipped, err := explorer.Field("skipped").Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := skipped.(parser.Cursor).MarshalJSON(); string(out) != `[1,2,{"x":3}]` {
+		t.Errorf("Expected the serialized array, but got %s", out)
+	}
+}
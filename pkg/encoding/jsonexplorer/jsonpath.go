@@ -0,0 +1,682 @@
+package jsonexplorer
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/json"
+)
+
+// Match is a node selected by a JSONPath query.
+type Match struct {
+	JSONExplorer
+	// Path is the normalized path of the node, such as $['store']['book'][0].
+	Path string
+}
+
+// JSONPath is a compiled RFC 9535 JSONPath query.
+type JSONPath struct {
+	query *filterQuery
+}
+
+// CompileJSONPath parses an RFC 9535 JSONPath query, such as
+// $.store.book[?@.price < 10].title.
+func CompileJSONPath(query string) (*JSONPath, error) {
+	p := &pathParser{input: query}
+	if p.peek() != '$' {
+		return nil, p.errorf("a query must start with $")
+	}
+	q, err := p.query()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(query) {
+		return nil, p.errorf("unexpected %q", query[p.pos:])
+	}
+	return &JSONPath{query: q}, nil
+}
+
+// Query returns every node selected by the query, in the order given by
+// RFC 9535. Object members are visited in document order for lazy values and
+// documents, and in key order for maps.
+func (j JSONExplorer) Query(query string) ([]Match, error) {
+	path, err := CompileJSONPath(query)
+	if err != nil {
+		return nil, err
+	}
+	return path.Query(j)
+}
+
+// Query evaluates the query against the value of j.
+func (path *JSONPath) Query(j JSONExplorer) ([]Match, error) {
+	if j._err != nil {
+		return nil, j._err
+	}
+	ctx := &evalContext{root: j.data}
+	nodes := path.query.nodes(ctx, j.data)
+	if ctx.err != nil {
+		return nil, ctx.err
+	}
+	matches := make([]Match, len(nodes))
+	for i, n := range nodes {
+		matches[i] = Match{JSONExplorer: NewJSONExplorer(n.value), Path: normalizedPath(n.path)}
+	}
+	return matches, nil
+}
+
+// node is a value with the path, names and indices, leading to it.
+type node struct {
+	value any
+	path  []any
+}
+
+func (n node) child(key any, value any) node {
+	return node{value: value, path: append(n.path[:len(n.path):len(n.path)], key)}
+}
+
+type evalContext struct {
+	root any
+	err  error
+}
+
+// children calls fn with every member of an object or element of an array.
+func (ctx *evalContext) children(value any, fn func(key any, child any)) {
+	value, err := expandLazy(value)
+	if err != nil {
+		ctx.fail(err)
+		return
+	}
+	switch value := value.(type) {
+	case []any:
+		for i, item := range value {
+			fn(i, item)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			fn(k, value[k])
+		}
+	case *json.LazyArray:
+		for i := 0; i < value.Len(); i++ {
+			item, _ := value.Raw(i)
+			fn(i, item)
+		}
+	case *json.LazyObject:
+		for _, k := range value.Keys() {
+			item, _ := value.Raw(k)
+			fn(k, item)
+		}
+	case json.Cursor:
+		value.Elements(func(i int, item json.Cursor) bool {
+			fn(i, item)
+			return true
+		})
+		value.Members(func(k string, item json.Cursor) bool {
+			fn(k, item)
+			return true
+		})
+	}
+}
+
+func (ctx *evalContext) fail(err error) {
+	if ctx.err == nil {
+		ctx.err = err
+	}
+}
+
+// resolve fully parses a lazy value so that it can be compared.
+func (ctx *evalContext) resolve(value any) any {
+	value, err := parseLazy(value)
+	if err != nil {
+		ctx.fail(err)
+	}
+	return value
+}
+
+type filterQuery struct {
+	relative bool
+	segments []segment
+}
+
+func (q *filterQuery) nodes(ctx *evalContext, current any) []node {
+	start := ctx.root
+	if q.relative {
+		start = current
+	}
+	nodes := []node{{value: start}}
+	for _, seg := range q.segments {
+		next := make([]node, 0, len(nodes))
+		for _, n := range nodes {
+			next = seg.apply(ctx, n, next)
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// singular reports whether the query selects at most one node.
+func (q *filterQuery) singular() bool {
+	for _, seg := range q.segments {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		switch seg.selectors[0].(type) {
+		case nameSelector, indexSelector:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+type segment struct {
+	descendant bool
+	selectors  []selector
+}
+
+func (seg segment) apply(ctx *evalContext, n node, out []node) []node {
+	for _, sel := range seg.selectors {
+		out = sel.selectFrom(ctx, n, out)
+	}
+	if seg.descendant {
+		ctx.children(n.value, func(key any, child any) {
+			out = seg.apply(ctx, n.child(key, child), out)
+		})
+	}
+	return out
+}
+
+type selector interface {
+	selectFrom(ctx *evalContext, n node, out []node) []node
+}
+
+type (
+	nameSelector     string
+	wildcardSelector struct{}
+	indexSelector    int
+	sliceSelector    struct{ start, end, step *int }
+	filterSelector   struct{ expr logicalExpr }
+)
+
+func (s nameSelector) selectFrom(ctx *evalContext, n node, out []node) []node {
+	value, err := expandLazy(n.value)
+	if err != nil {
+		ctx.fail(err)
+		return out
+	}
+	switch value := value.(type) {
+	case map[string]any:
+		if child, ok := value[string(s)]; ok {
+			out = append(out, n.child(string(s), child))
+		}
+	case *json.LazyObject:
+		if child, ok := value.Raw(string(s)); ok {
+			out = append(out, n.child(string(s), child))
+		}
+	case json.Cursor:
+		if child, ok := value.Field(string(s)); ok {
+			out = append(out, n.child(string(s), child))
+		}
+	}
+	return out
+}
+
+func (wildcardSelector) selectFrom(ctx *evalContext, n node, out []node) []node {
+	ctx.children(n.value, func(key any, child any) {
+		out = append(out, n.child(key, child))
+	})
+	return out
+}
+
+func (s indexSelector) selectFrom(ctx *evalContext, n node, out []node) []node {
+	items := ctx.elements(n.value)
+	if items == nil {
+		return out
+	}
+	i := int(s)
+	if i < 0 {
+		i += items.len()
+	}
+	if 0 <= i && i < items.len() {
+		out = append(out, n.child(i, items.at(i)))
+	}
+	return out
+}
+
+func (s sliceSelector) selectFrom(ctx *evalContext, n node, out []node) []node {
+	items := ctx.elements(n.value)
+	if items == nil {
+		return out
+	}
+	length := items.len()
+	step := 1
+	if s.step != nil {
+		step = *s.step
+	}
+	if step == 0 {
+		return out
+	}
+	normalize := func(bound *int, fallback int) int {
+		if bound == nil {
+			return fallback
+		}
+		if *bound < 0 {
+			return length + *bound
+		}
+		return *bound
+	}
+
+	if step > 0 {
+		lower := min(max(normalize(s.start, 0), 0), length)
+		upper := min(max(normalize(s.end, length), 0), length)
+		for i := lower; i < upper; i += step {
+			out = append(out, n.child(i, items.at(i)))
+		}
+	} else {
+		upper := min(max(normalize(s.start, length-1), -1), length-1)
+		lower := min(max(normalize(s.end, -length-1), -1), length-1)
+		for i := upper; lower < i; i += step {
+			out = append(out, n.child(i, items.at(i)))
+		}
+	}
+	return out
+}
+
+func (s filterSelector) selectFrom(ctx *evalContext, n node, out []node) []node {
+	ctx.children(n.value, func(key any, child any) {
+		if s.expr.test(ctx, child) {
+			out = append(out, n.child(key, child))
+		}
+	})
+	return out
+}
+
+// elements gives indexed access to an array, or nil for other values.
+func (ctx *evalContext) elements(value any) interface {
+	len() int
+	at(i int) any
+} {
+	value, err := expandLazy(value)
+	if err != nil {
+		ctx.fail(err)
+		return nil
+	}
+	switch value := value.(type) {
+	case []any:
+		return sliceElements(value)
+	case *json.LazyArray:
+		return lazyElements{value}
+	case json.Cursor:
+		if value.Kind() != json.KindArray {
+			return nil
+		}
+		items := make(cursorElements, 0, value.Len())
+		value.Elements(func(_ int, item json.Cursor) bool {
+			items = append(items, item)
+			return true
+		})
+		return items
+	}
+	return nil
+}
+
+type sliceElements []any
+
+func (s sliceElements) len() int     { return len(s) }
+func (s sliceElements) at(i int) any { return s[i] }
+
+type lazyElements struct{ *json.LazyArray }
+
+func (l lazyElements) len() int { return l.Len() }
+func (l lazyElements) at(i int) any {
+	item, _ := l.Raw(i)
+	return item
+}
+
+// cursorElements holds the elements of a tape array, which has no random
+// access.
+type cursorElements []json.Cursor
+
+func (c cursorElements) len() int     { return len(c) }
+func (c cursorElements) at(i int) any { return c[i] }
+
+type logicalExpr interface {
+	test(ctx *evalContext, current any) bool
+}
+
+type valueExpr interface {
+	// value returns false for Nothing, the absence of a value.
+	value(ctx *evalContext, current any) (any, bool)
+}
+
+type nodesExpr interface {
+	nodes(ctx *evalContext, current any) []node
+}
+
+func (e orNode) test(ctx *evalContext, current any) bool {
+	for _, operand := range e {
+		if operand.test(ctx, current) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e andNode) test(ctx *evalContext, current any) bool {
+	for _, operand := range e {
+		if !operand.test(ctx, current) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e notNode) test(ctx *evalContext, current any) bool {
+	return !e.expr.test(ctx, current)
+}
+
+// existsExpr is true when the query selects at least one node.
+type existsExpr struct{ query nodesExpr }
+
+func (e existsExpr) test(ctx *evalContext, current any) bool {
+	return len(e.query.nodes(ctx, current)) > 0
+}
+
+func (e comparisonNode) test(ctx *evalContext, current any) bool {
+	left, leftOk := e.left.value(ctx, current)
+	right, rightOk := e.right.value(ctx, current)
+	switch e.op {
+	case "==":
+		return equal(leftOk, left, rightOk, right)
+	case "!=":
+		return !equal(leftOk, left, rightOk, right)
+	case "<":
+		return less(leftOk, left, rightOk, right)
+	case ">":
+		return less(rightOk, right, leftOk, left)
+	case "<=":
+		return less(leftOk, left, rightOk, right) || equal(leftOk, left, rightOk, right)
+	default: // >=
+		return less(rightOk, right, leftOk, left) || equal(leftOk, left, rightOk, right)
+	}
+}
+
+func (e literalNode) value(*evalContext, any) (any, bool) {
+	return e.literal, true
+}
+
+type singularQuery struct{ query *filterQuery }
+
+func (q singularQuery) value(ctx *evalContext, current any) (any, bool) {
+	nodes := q.query.nodes(ctx, current)
+	if len(nodes) != 1 {
+		return nil, false
+	}
+	return ctx.resolve(nodes[0].value), true
+}
+
+// equal compares two values as RFC 9535 §2.3.5.2.2 describes: Nothing is only
+// equal to Nothing, numbers compare by value and containers deeply.
+func equal(aOk bool, a any, bOk bool, b any) bool {
+	if !aOk || !bOk {
+		return aOk == bOk
+	}
+	if x, ok := toNumber(a); ok {
+		y, ok := toNumber(b)
+		return ok && x == y
+	}
+	switch a := a.(type) {
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(true, a[i], true, b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			w, ok := b[k]
+			if !ok || !equal(true, v, true, w) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// less orders numbers and strings; every other comparison is false.
+func less(aOk bool, a any, bOk bool, b any) bool {
+	if !aOk || !bOk {
+		return false
+	}
+	if x, ok := toNumber(a); ok {
+		y, ok := toNumber(b)
+		return ok && x < y
+	}
+	if x, ok := a.(string); ok {
+		y, ok := b.(string)
+		return ok && x < y
+	}
+	return false
+}
+
+func toNumber(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// pathType is the type of a function parameter or result, see RFC 9535 §2.4.1.
+type pathType int8
+
+const (
+	valueType pathType = iota
+	logicalType
+	nodesType
+)
+
+type function struct {
+	params []pathType
+	result pathType
+	// call receives, for each parameter, a value and whether it is present,
+	// a bool or a []node.
+	call func(ctx *evalContext, call *funcCall, args []any) (any, bool)
+}
+
+type funcCall struct {
+	name string
+	fn   function
+	args []any
+	// patterns caches the compiled regular expressions of match and search.
+	patterns sync.Map
+}
+
+func (c *funcCall) evaluate(ctx *evalContext, current any) (any, bool) {
+	args := make([]any, len(c.args))
+	for i, arg := range c.args {
+		switch arg := arg.(type) {
+		case valueExpr:
+			value, ok := arg.value(ctx, current)
+			args[i] = optional{value, ok}
+		case logicalExpr:
+			args[i] = arg.test(ctx, current)
+		case nodesExpr:
+			args[i] = arg.nodes(ctx, current)
+		}
+	}
+	return c.fn.call(ctx, c, args)
+}
+
+func (c *funcCall) nodes(ctx *evalContext, current any) []node {
+	result, _ := c.evaluate(ctx, current)
+	nodes, _ := result.([]node)
+	return nodes
+}
+
+type optional struct {
+	value any
+	ok    bool
+}
+
+type valueCall struct{ call *funcCall }
+
+func (c valueCall) value(ctx *evalContext, current any) (any, bool) {
+	return c.call.evaluate(ctx, current)
+}
+
+type logicalCall struct{ call *funcCall }
+
+func (c logicalCall) test(ctx *evalContext, current any) bool {
+	result, ok := c.call.evaluate(ctx, current)
+	return ok && result == true
+}
+
+// functions are the function extensions of RFC 9535 §2.4.
+var functions = map[string]function{
+	"length": {params: []pathType{valueType}, result: valueType, call: func(ctx *evalContext, _ *funcCall, args []any) (any, bool) {
+		arg := args[0].(optional)
+		if !arg.ok {
+			return nil, false
+		}
+		switch value := arg.value.(type) {
+		case string:
+			return float64(utf8.RuneCountInString(value)), true
+		case []any:
+			return float64(len(value)), true
+		case map[string]any:
+			return float64(len(value)), true
+		}
+		return nil, false
+	}},
+	"count": {params: []pathType{nodesType}, result: valueType, call: func(ctx *evalContext, _ *funcCall, args []any) (any, bool) {
+		return float64(len(args[0].([]node))), true
+	}},
+	"match": {params: []pathType{valueType, valueType}, result: logicalType, call: func(ctx *evalContext, call *funcCall, args []any) (any, bool) {
+		return call.regexpMatch(args, true), true
+	}},
+	"search": {params: []pathType{valueType, valueType}, result: logicalType, call: func(ctx *evalContext, call *funcCall, args []any) (any, bool) {
+		return call.regexpMatch(args, false), true
+	}},
+	"value": {params: []pathType{nodesType}, result: valueType, call: func(ctx *evalContext, _ *funcCall, args []any) (any, bool) {
+		nodes := args[0].([]node)
+		if len(nodes) != 1 {
+			return nil, false
+		}
+		return ctx.resolve(nodes[0].value), true
+	}},
+}
+
+// regexpMatch implements match, when whole is set, and search. Invalid
+// patterns never match.
+func (c *funcCall) regexpMatch(args []any, whole bool) bool {
+	s, ok := args[0].(optional).value.(string)
+	pattern, ok2 := args[1].(optional).value.(string)
+	if !ok || !ok2 {
+		return false
+	}
+	cached, ok := c.patterns.Load(pattern)
+	if !ok {
+		translated := translateIRegexp(pattern)
+		if whole {
+			translated = `\A(?:` + translated + `)\z`
+		}
+		re, err := regexp.Compile(translated)
+		if err != nil {
+			re = nil
+		}
+		cached, _ = c.patterns.LoadOrStore(pattern, re)
+	}
+	re := cached.(*regexp.Regexp)
+	return re != nil && re.MatchString(s)
+}
+
+// translateIRegexp rewrites an I-Regexp (RFC 9485) for the regexp package: a
+// dot outside of a character class does not match \n nor \r.
+func translateIRegexp(pattern string) string {
+	var sb strings.Builder
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\' && i+1 < len(pattern):
+			sb.WriteByte(c)
+			i++
+			sb.WriteByte(pattern[i])
+		case c == '[':
+			inClass = true
+			sb.WriteByte(c)
+		case c == ']':
+			inClass = false
+			sb.WriteByte(c)
+		case c == '.' && !inClass:
+			sb.WriteString(`[^\n\r]`)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// normalizedPath formats a path as described in RFC 9535 §2.7.
+func normalizedPath(path []any) string {
+	var sb strings.Builder
+	sb.WriteByte('$')
+	for _, key := range path {
+		switch key := key.(type) {
+		case int:
+			fmt.Fprintf(&sb, "[%d]", key)
+		case string:
+			sb.WriteString("['")
+			for _, r := range key {
+				switch r {
+				case '\b':
+					sb.WriteString(`\b`)
+				case '\f':
+					sb.WriteString(`\f`)
+				case '\n':
+					sb.WriteString(`\n`)
+				case '\r':
+					sb.WriteString(`\r`)
+				case '\t':
+					sb.WriteString(`\t`)
+				case '\'':
+					sb.WriteString(`\'`)
+				case '\\':
+					sb.WriteString(`\\`)
+				default:
+					if r < 0x20 {
+						fmt.Fprintf(&sb, `\u%04x`, r)
+					} else {
+						sb.WriteRune(r)
+					}
+				}
+			}
+			sb.WriteString("']")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,580 @@
+package jsonexplorer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// maxSafeInt bounds the integers of a query to the I-JSON range.
+const maxSafeInt = 1<<53 - 1
+
+// pathParser parses a JSONPath query following the ABNF of RFC 9535.
+type pathParser struct {
+	input string
+	pos   int
+}
+
+func (p *pathParser) errorf(format string, a ...any) error {
+	return fmt.Errorf("invalid JSONPath at position %d: %s", p.pos, fmt.Sprintf(format, a...))
+}
+
+func (p *pathParser) peek() byte {
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+func (p *pathParser) consume(s string) bool {
+	if strings.HasPrefix(p.input[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *pathParser) skipBlank() {
+	for p.pos < len(p.input) {
+		switch p.input[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// query parses a root or relative query, starting with $ or @.
+func (p *pathParser) query() (*filterQuery, error) {
+	q := &filterQuery{relative: p.peek() == '@'}
+	p.pos++
+	for {
+		start := p.pos
+		p.skipBlank()
+		if c := p.peek(); c != '.' && c != '[' {
+			p.pos = start
+			return q, nil
+		}
+		seg, err := p.segment()
+		if err != nil {
+			return nil, err
+		}
+		q.segments = append(q.segments, seg)
+	}
+}
+
+func (p *pathParser) segment() (segment, error) {
+	var seg segment
+	if p.consume("..") {
+		seg.descendant = true
+		if p.peek() != '[' {
+			sel, err := p.dotSelector()
+			if err != nil {
+				return seg, err
+			}
+			seg.selectors = []selector{sel}
+			return seg, nil
+		}
+	} else if p.consume(".") {
+		sel, err := p.dotSelector()
+		if err != nil {
+			return seg, err
+		}
+		seg.selectors = []selector{sel}
+		return seg, nil
+	}
+
+	p.pos++ // [
+	for {
+		p.skipBlank()
+		sel, err := p.selector()
+		if err != nil {
+			return seg, err
+		}
+		seg.selectors = append(seg.selectors, sel)
+		p.skipBlank()
+		if p.consume("]") {
+			return seg, nil
+		}
+		if !p.consume(",") {
+			return seg, p.errorf("expected , or ]")
+		}
+	}
+}
+
+// dotSelector parses the wildcard or member name that follows . or ..
+func (p *pathParser) dotSelector() (selector, error) {
+	if p.consume("*") {
+		return wildcardSelector{}, nil
+	}
+	start := p.pos
+	for p.pos < len(p.input) {
+		r, size := utf8.DecodeRuneInString(p.input[p.pos:])
+		if !isNameFirst(r) && (p.pos == start || !isDigit(r)) {
+			break
+		}
+		p.pos += size
+	}
+	if p.pos == start {
+		return nil, p.errorf("expected a member name")
+	}
+	return nameSelector(p.input[start:p.pos]), nil
+}
+
+func isNameFirst(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || r == '_' ||
+		0x80 <= r && r <= 0xd7ff || 0xe000 <= r && r <= 0x10ffff
+}
+
+func isDigit(r rune) bool {
+	return '0' <= r && r <= '9'
+}
+
+func (p *pathParser) selector() (selector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		name, err := p.stringLiteral()
+		return nameSelector(name), err
+	case c == '*':
+		p.pos++
+		return wildcardSelector{}, nil
+	case c == '?':
+		p.pos++
+		p.skipBlank()
+		expr, err := p.logicalOr()
+		if err != nil {
+			return nil, err
+		}
+		logical, err := asLogical(expr)
+		if err != nil {
+			return nil, p.errorf("%s", err)
+		}
+		return filterSelector{logical}, nil
+	case c == ':' || c == '-' || isDigit(rune(c)):
+		return p.indexOrSlice()
+	}
+	return nil, p.errorf("expected a selector")
+}
+
+func (p *pathParser) indexOrSlice() (selector, error) {
+	var bounds [3]*int
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			p.skipBlank()
+			if !p.consume(":") {
+				if i == 1 {
+					if bounds[0] == nil {
+						return nil, p.errorf("expected an index")
+					}
+					return indexSelector(*bounds[0]), nil
+				}
+				break
+			}
+			p.skipBlank()
+		}
+		if c := p.peek(); c == '-' || isDigit(rune(c)) {
+			n, err := p.integer()
+			if err != nil {
+				return nil, err
+			}
+			bounds[i] = &n
+		}
+	}
+	return sliceSelector{start: bounds[0], end: bounds[1], step: bounds[2]}, nil
+}
+
+// integer parses an int without leading zeros or -0, in the I-JSON range.
+func (p *pathParser) integer() (int, error) {
+	start := p.pos
+	p.consume("-")
+	digits := p.pos
+	for isDigit(rune(p.peek())) {
+		p.pos++
+	}
+	text := p.input[start:p.pos]
+	if p.pos == digits || p.input[digits] == '0' && (p.pos-digits > 1 || digits > start) {
+		return 0, p.errorf("invalid integer %q", text)
+	}
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil || n > maxSafeInt || n < -maxSafeInt {
+		return 0, p.errorf("integer %s out of range", text)
+	}
+	return int(n), nil
+}
+
+// stringLiteral parses a single or double quoted string.
+func (p *pathParser) stringLiteral() (string, error) {
+	quote := p.input[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for {
+		if p.pos >= len(p.input) {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.input[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c < 0x20:
+			return "", p.errorf("control character in string")
+		case c != '\\':
+			sb.WriteByte(c)
+			p.pos++
+			continue
+		}
+
+		p.pos++
+		switch c := p.peek(); c {
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case '/', '\\', quote:
+			sb.WriteByte(c)
+		case 'u':
+			r, err := p.hex4()
+			if err != nil {
+				return "", err
+			}
+			if utf16.IsSurrogate(r) {
+				if r >= 0xdc00 || !p.consume(`\u`) {
+					return "", p.errorf("unpaired surrogate")
+				}
+				p.pos--
+				low, err := p.hex4()
+				if err != nil {
+					return "", err
+				}
+				if r = utf16.DecodeRune(r, low); r == utf8.RuneError {
+					return "", p.errorf("unpaired surrogate")
+				}
+			}
+			sb.WriteRune(r)
+			continue
+		default:
+			return "", p.errorf("invalid escape")
+		}
+		p.pos++
+	}
+}
+
+// hex4 parses the four hex digits following the u at the current position.
+func (p *pathParser) hex4() (rune, error) {
+	if p.pos+5 > len(p.input) {
+		return 0, p.errorf("invalid unicode escape")
+	}
+	n, err := strconv.ParseUint(p.input[p.pos+1:p.pos+5], 16, 32)
+	if err != nil {
+		return 0, p.errorf("invalid unicode escape")
+	}
+	p.pos += 5
+	return rune(n), nil
+}
+
+// Filter expressions are parsed into these nodes before they are type checked
+// by asLogical, asValue and asNodes.
+type (
+	literalNode    struct{ literal any }
+	comparisonNode struct {
+		op          string
+		left, right valueExpr
+	}
+	orNode  []logicalExpr
+	andNode []logicalExpr
+	notNode struct{ expr logicalExpr }
+)
+
+func (p *pathParser) logicalOr() (any, error) {
+	return p.logicalChain("||", p.logicalAnd, func(operands []logicalExpr) any { return orNode(operands) })
+}
+
+func (p *pathParser) logicalAnd() (any, error) {
+	return p.logicalChain("&&", p.basic, func(operands []logicalExpr) any { return andNode(operands) })
+}
+
+// logicalChain parses operands separated by op. A single operand is returned
+// as is, so that function arguments keep their type.
+func (p *pathParser) logicalChain(op string, operand func() (any, error), build func([]logicalExpr) any) (any, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	var operands []logicalExpr
+	for {
+		start := p.pos
+		p.skipBlank()
+		if !p.consume(op) {
+			p.pos = start
+			break
+		}
+		if operands == nil {
+			logical, err := asLogical(first)
+			if err != nil {
+				return nil, p.errorf("%s", err)
+			}
+			operands = append(operands, logical)
+		}
+		p.skipBlank()
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		logical, err := asLogical(next)
+		if err != nil {
+			return nil, p.errorf("%s", err)
+		}
+		operands = append(operands, logical)
+	}
+	if operands == nil {
+		return first, nil
+	}
+	return build(operands), nil
+}
+
+var comparisonOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func (p *pathParser) basic() (any, error) {
+	if p.consume("!") {
+		p.skipBlank()
+		var operand any
+		var err error
+		if p.consume("(") {
+			operand, err = p.parenthesized()
+		} else if c := p.peek(); c == '@' || c == '$' {
+			operand, err = p.query()
+		} else {
+			operand, err = p.function()
+		}
+		if err != nil {
+			return nil, err
+		}
+		logical, err := asLogical(operand)
+		if err != nil {
+			return nil, p.errorf("%s", err)
+		}
+		return notNode{logical}, nil
+	}
+	if p.consume("(") {
+		return p.parenthesized()
+	}
+
+	left, err := p.comparable()
+	if err != nil {
+		return nil, err
+	}
+	start := p.pos
+	p.skipBlank()
+	for _, op := range comparisonOps {
+		if !p.consume(op) {
+			continue
+		}
+		p.skipBlank()
+		right, err := p.comparable()
+		if err != nil {
+			return nil, err
+		}
+		leftValue, err := asValue(left)
+		if err != nil {
+			return nil, p.errorf("%s", err)
+		}
+		rightValue, err := asValue(right)
+		if err != nil {
+			return nil, p.errorf("%s", err)
+		}
+		return comparisonNode{op: op, left: leftValue, right: rightValue}, nil
+	}
+	p.pos = start
+	return left, nil
+}
+
+func (p *pathParser) parenthesized() (any, error) {
+	p.skipBlank()
+	expr, err := p.logicalOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if !p.consume(")") {
+		return nil, p.errorf("expected )")
+	}
+	logical, err := asLogical(expr)
+	if err != nil {
+		return nil, p.errorf("%s", err)
+	}
+	return logical, nil
+}
+
+// comparable parses a literal, a query or a function call.
+func (p *pathParser) comparable() (any, error) {
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		return p.query()
+	case c == '\'' || c == '"':
+		s, err := p.stringLiteral()
+		return literalNode{s}, err
+	case c == '-' || isDigit(rune(c)):
+		return p.number()
+	case 'a' <= c && c <= 'z':
+		for _, literal := range []struct {
+			word  string
+			value any
+		}{{"true", true}, {"false", false}, {"null", nil}} {
+			if p.consume(literal.word) {
+				return literalNode{literal.value}, nil
+			}
+		}
+		return p.function()
+	}
+	return nil, p.errorf("expected an expression")
+}
+
+// number parses a JSON number, allowing -0.
+func (p *pathParser) number() (any, error) {
+	start := p.pos
+	p.consume("-")
+	digits := p.pos
+	for isDigit(rune(p.peek())) {
+		p.pos++
+	}
+	if p.pos == digits || p.input[digits] == '0' && p.pos-digits > 1 {
+		return nil, p.errorf("invalid number")
+	}
+	if p.consume(".") {
+		fraction := p.pos
+		for isDigit(rune(p.peek())) {
+			p.pos++
+		}
+		if p.pos == fraction {
+			return nil, p.errorf("invalid number")
+		}
+	}
+	if c := p.peek(); c == 'e' || c == 'E' {
+		p.pos++
+		if c := p.peek(); c == '+' || c == '-' {
+			p.pos++
+		}
+		exponent := p.pos
+		for isDigit(rune(p.peek())) {
+			p.pos++
+		}
+		if p.pos == exponent {
+			return nil, p.errorf("invalid number")
+		}
+	}
+	f, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+	if err != nil {
+		return nil, p.errorf("invalid number")
+	}
+	return literalNode{f}, nil
+}
+
+func (p *pathParser) function() (any, error) {
+	start := p.pos
+	for c := p.peek(); 'a' <= c && c <= 'z' || p.pos > start && (c == '_' || isDigit(rune(c))); c = p.peek() {
+		p.pos++
+	}
+	name := p.input[start:p.pos]
+	fn, ok := functions[name]
+	if !ok || !p.consume("(") {
+		p.pos = start
+		return nil, p.errorf("unknown function %q", name)
+	}
+
+	call := &funcCall{name: name, fn: fn}
+	p.skipBlank()
+	for i := 0; !p.consume(")"); i++ {
+		if i > 0 {
+			if !p.consume(",") {
+				return nil, p.errorf("expected , or )")
+			}
+			p.skipBlank()
+		}
+		if i >= len(fn.params) {
+			return nil, p.errorf("too many arguments for %s", name)
+		}
+		expr, err := p.logicalOr()
+		if err != nil {
+			return nil, err
+		}
+		var arg any
+		switch fn.params[i] {
+		case valueType:
+			arg, err = asValue(expr)
+		case logicalType:
+			arg, err = asLogical(expr)
+		case nodesType:
+			arg, err = asNodes(expr)
+		}
+		if err != nil {
+			return nil, p.errorf("argument %d of %s: %s", i+1, name, err)
+		}
+		call.args = append(call.args, arg)
+		p.skipBlank()
+	}
+	if len(call.args) != len(fn.params) {
+		return nil, p.errorf("%s expects %d arguments", name, len(fn.params))
+	}
+	return call, nil
+}
+
+// asLogical converts a parsed expression for a place expecting a LogicalType.
+// Queries test for the existence of a node.
+func asLogical(expr any) (logicalExpr, error) {
+	switch expr := expr.(type) {
+	case logicalExpr:
+		return expr, nil
+	case *filterQuery:
+		return existsExpr{expr}, nil
+	case *funcCall:
+		switch expr.fn.result {
+		case logicalType:
+			return logicalCall{expr}, nil
+		case nodesType:
+			return existsExpr{expr}, nil
+		}
+		return nil, fmt.Errorf("the result of %s must be compared", expr.name)
+	}
+	return nil, fmt.Errorf("a literal must be compared")
+}
+
+// asValue converts a parsed expression for a place expecting a ValueType.
+func asValue(expr any) (valueExpr, error) {
+	switch expr := expr.(type) {
+	case literalNode:
+		return expr, nil
+	case *filterQuery:
+		if !expr.singular() {
+			return nil, fmt.Errorf("a query that can select several nodes cannot be compared")
+		}
+		return singularQuery{expr}, nil
+	case *funcCall:
+		if expr.fn.result == valueType {
+			return valueCall{expr}, nil
+		}
+		return nil, fmt.Errorf("the result of %s cannot be compared", expr.name)
+	}
+	return nil, fmt.Errorf("a logical expression cannot be compared")
+}
+
+// asNodes converts a parsed expression for a place expecting a NodesType.
+func asNodes(expr any) (nodesExpr, error) {
+	switch expr := expr.(type) {
+	case *filterQuery:
+		return expr, nil
+	case *funcCall:
+		if expr.fn.result == nodesType {
+			return expr, nil
+		}
+	}
+	return nil, fmt.Errorf("expected a query")
+}
@@ -0,0 +1,204 @@
+package jsonexplorer_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	parser "github.com/GabiBizdoc/golang-playground/pkg/encoding/json"
+	. "github.com/GabiBizdoc/golang-playground/pkg/encoding/jsonexplorer"
+)
+
+const bookstore = `{"store": {
+	"bicycle": {"color": "red", "price": 399},
+	"book": [
+		{"author": "Nigel Rees", "category": "reference", "price": 8.95, "title": "Sayings of the Century"},
+		{"author": "Evelyn Waugh", "category": "fiction", "price": 12.99, "title": "Sword of Honour"},
+		{"author": "Herman Melville", "category": "fiction", "isbn": "0-553-21311-3", "price": 8.99, "title": "Moby Dick"},
+		{"author": "J. R. R. Tolkien", "category": "fiction", "isbn": "0-395-19395-8", "price": 22.99, "title": "The Lord of the Rings"}
+	]
+}}`
+
+const filterDocument = `{"a": [3, 5, 1, 2, 4, 6, {"b": "j"}, {"b": "k"}, {"b": {}}, {"b": "kilo"}],
+	"e": "f", "o": {"p": 1, "q": 2, "r": 3, "s": 5, "t": {"u": 6}}}`
+
+// queryBoth runs the query on the parsed document, on a lazy RawValue and on a
+// Document, and returns the normalized paths and the values encoded as JSON.
+func queryBoth(t *testing.T, document string, query string) (paths []string, values []string) {
+	t.Helper()
+	parsed, err := parser.ParseJson(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := parser.ParseDocument(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, data := range []any{parsed, parser.RawValue(document), doc} {
+		matches, err := NewJSONExplorer(data).Query(query)
+		if err != nil {
+			t.Errorf("FAIL: %s: %v", query, err)
+			return nil, nil
+		}
+		var p, v []string
+		for _, match := range matches {
+			value, err := match.Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := json.Marshal(value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p = append(p, match.Path)
+			v = append(v, string(out))
+		}
+		if i > 0 && (strings.Join(p, " ") != strings.Join(paths, " ") || strings.Join(v, " ") != strings.Join(values, " ")) {
+			t.Errorf("FAIL: %s: expected the lazy documents to give %v %v but got %v %v", query, paths, values, p, v)
+		}
+		paths, values = p, v
+	}
+	return paths, values
+}
+
+func TestJSONExplorer_Query(t *testing.T) {
+	cases := []struct {
+		Document string
+		Query    string
+		Paths    string
+		Values   string
+	}{
+		{bookstore, `$.store.book[*].author`,
+			`$['store']['book'][0]['author'] $['store']['book'][1]['author'] $['store']['book'][2]['author'] $['store']['book'][3]['author']`,
+			`"Nigel Rees" "Evelyn Waugh" "Herman Melville" "J. R. R. Tolkien"`},
+		{bookstore, `$..author`, "", `"Nigel Rees" "Evelyn Waugh" "Herman Melville" "J. R. R. Tolkien"`},
+		{bookstore, `$.store..price`, "", `399 8.95 12.99 8.99 22.99`},
+		{bookstore, `$..book[2]`, `$['store']['book'][2]`, ""},
+		{bookstore, `$..book[2].author`, "", `"Herman Melville"`},
+		{bookstore, `$..book[2].publisher`, "", ""},
+		{bookstore, `$..book[-1].title`, `$['store']['book'][3]['title']`, `"The Lord of the Rings"`},
+		{bookstore, `$..book[0,1].title`, "", `"Sayings of the Century" "Sword of Honour"`},
+		{bookstore, `$..book[:2].title`, "", `"Sayings of the Century" "Sword of Honour"`},
+		{bookstore, `$..book[?@.isbn].title`, "", `"Moby Dick" "The Lord of the Rings"`},
+		{bookstore, `$.store.book[?@.price < 10].title`, "", `"Sayings of the Century" "Moby Dick"`},
+		{bookstore, `$..[?@.color == 'red'].price`, `$['store']['bicycle']['price']`, `399`},
+
+		{`{"o": {"j j": {"k.k": 3}}, "'": {"@": 2}}`, `$.o['j j']`, `$['o']['j j']`, `{"k.k":3}`},
+		{`{"o": {"j j": {"k.k": 3}}, "'": {"@": 2}}`, `$.o['j j']['k.k']`, `$['o']['j j']['k.k']`, `3`},
+		{`{"o": {"j j": {"k.k": 3}}, "'": {"@": 2}}`, `$.o["j j"]["k.k"]`, "", `3`},
+		{`{"o": {"j j": {"k.k": 3}}, "'": {"@": 2}}`, `$["'"]["@"]`, `$['\'']['@']`, `2`},
+
+		{`{"a": [5, 3], "o": {"j": 1, "k": 2}}`, `$[*]`, `$['a'] $['o']`, `[5,3] {"j":1,"k":2}`},
+		{`{"a": [5, 3], "o": {"j": 1, "k": 2}}`, `$.o[*, *]`, "", `1 2 1 2`},
+		{`{"a": [5, 3], "o": {"j": 1, "k": 2}}`, `$.a[*]`, `$['a'][0] $['a'][1]`, `5 3`},
+
+		{`["a", "b"]`, `$[1]`, `$[1]`, `"b"`},
+		{`["a", "b"]`, `$[-2]`, `$[0]`, `"a"`},
+		{`["a", "b"]`, `$[2]`, "", ""},
+		{`["a", "b", "c", "d", "e", "f", "g"]`, `$[1:3]`, `$[1] $[2]`, `"b" "c"`},
+		{`["a", "b", "c", "d", "e", "f", "g"]`, `$[5:]`, "", `"f" "g"`},
+		{`["a", "b", "c", "d", "e", "f", "g"]`, `$[1:5:2]`, "", `"b" "d"`},
+		{`["a", "b", "c", "d", "e", "f", "g"]`, `$[5:1:-2]`, `$[5] $[3]`, `"f" "d"`},
+		{`["a", "b", "c", "d", "e", "f", "g"]`, `$[::-1]`, "", `"g" "f" "e" "d" "c" "b" "a"`},
+		{`["a", "b", "c", "d", "e", "f", "g"]`, `$[-100:100:0]`, "", ""},
+		{`["a", "b", "c", "d", "e", "f", "g"]`, `$[ -2 : ]`, "", `"f" "g"`},
+
+		{filterDocument, `$.a[?@.b == 'kilo']`, `$['a'][9]`, `{"b":"kilo"}`},
+		{filterDocument, `$.a[?(@.b == 'kilo')]`, `$['a'][9]`, `{"b":"kilo"}`},
+		{filterDocument, `$.a[?@>3.5]`, `$['a'][1] $['a'][4] $['a'][5]`, `5 4 6`},
+		{filterDocument, `$.a[?@.b]`, `$['a'][6] $['a'][7] $['a'][8] $['a'][9]`, ""},
+		{filterDocument, `$[?@.*]`, `$['a'] $['o']`, ""},
+		{filterDocument, `$[?@[?@.b]]`, `$['a']`, ""},
+		{filterDocument, `$.o[?@<3, ?@<3]`, `$['o']['p'] $['o']['q'] $['o']['p'] $['o']['q']`, `1 2 1 2`},
+		{filterDocument, `$.a[?@<2 || @.b == "k"]`, `$['a'][2] $['a'][7]`, `1 {"b":"k"}`},
+		{filterDocument, `$.a[?match(@.b, "[jk]")]`, "", `{"b":"j"} {"b":"k"}`},
+		{filterDocument, `$.a[?search(@.b, "[jk]")]`, "", `{"b":"j"} {"b":"k"} {"b":"kilo"}`},
+		{filterDocument, `$.o[?@>1 && @<4]`, `$['o']['q'] $['o']['r']`, `2 3`},
+		{filterDocument, `$.o[?@.u || @.x]`, `$['o']['t']`, `{"u":6}`},
+		{filterDocument, `$.a[?@.b == $.x]`, "", `3 5 1 2 4 6`},
+		{filterDocument, `$.a[?@ == @]`, "", `3 5 1 2 4 6 {"b":"j"} {"b":"k"} {"b":{}} {"b":"kilo"}`},
+		{filterDocument, `$.a[?!@.b]`, "", `3 5 1 2 4 6`},
+		{filterDocument, `$.a[?!(@ > 1 && @ < 6)]`, "", `1 6 {"b":"j"} {"b":"k"} {"b":{}} {"b":"kilo"}`},
+		{filterDocument, `$[?@ == "f"]`, `$['e']`, `"f"`},
+		{filterDocument, `$.o[?@ == $.a[2]]`, `$['o']['p']`, `1`},
+		{filterDocument, `$.o[?@.u >= 6]`, `$['o']['t']`, ""},
+		{filterDocument, `$.a[?@.b != "k"]`, "", `3 5 1 2 4 6 {"b":"j"} {"b":{}} {"b":"kilo"}`},
+		{filterDocument, `$.a[?@.b <= "k"]`, "", `{"b":"j"} {"b":"k"}`},
+
+		{`{"a": [5, 3, [{"j": 4}, {"k": 6}]], "o": {"j": 1, "k": 2}}`, `$..j`, `$['a'][2][0]['j'] $['o']['j']`, `4 1`},
+		{`{"a": [5, 3, [{"j": 4}, {"k": 6}]], "o": {"j": 1, "k": 2}}`, `$..[0]`, `$['a'][0] $['a'][2][0]`, `5 {"j":4}`},
+		{`{"a": [5, 3, [{"j": 4}, {"k": 6}]], "o": {"j": 1, "k": 2}}`, `$..*`, "", `[5,3,[{"j":4},{"k":6}]] {"j":1,"k":2} 5 3 [{"j":4},{"k":6}] {"j":4} {"k":6} 4 6 1 2`},
+		{`{"a": [5, 3, [{"j": 4}, {"k": 6}]], "o": {"j": 1, "k": 2}}`, `$.a..[?@.j]`, `$['a'][2][0]`, ""},
+
+		{`{"a": null, "b": [null], "c": [{}], "null": 1}`, `$.a`, "", `null`},
+		{`{"a": null, "b": [null], "c": [{}], "null": 1}`, `$.a[0]`, "", ""},
+		{`{"a": null, "b": [null], "c": [{}], "null": 1}`, `$.a.d`, "", ""},
+		{`{"a": null, "b": [null], "c": [{}], "null": 1}`, `$.b[0]`, "", `null`},
+		{`{"a": null, "b": [null], "c": [{}], "null": 1}`, `$.b[*]`, "", `null`},
+		{`{"a": null, "b": [null], "c": [{}], "null": 1}`, `$.b[?@]`, "", `null`},
+		{`{"a": null, "b": [null], "c": [{}], "null": 1}`, `$.b[?@==null]`, "", `null`},
+		{`{"a": null, "b": [null], "c": [{}], "null": 1}`, `$.c[?@.d==null]`, "", ""},
+		{`{"a": null, "b": [null], "c": [{}], "null": 1}`, `$.null`, "", `1`},
+
+		{`[{"a": "ab"}, {"a": [1, 2, 3]}, {"a": {"x": 1}}, {"a": 1}, {"b": 2}]`, `$[?length(@.a) >= 2]`, `$[0] $[1]`, ""},
+		{`[{"a": [1, 2]}, {"a": [1]}, {"a": {"x": 1, "y": 1}}]`, `$[?count(@.a.*) == 2]`, `$[0] $[2]`, ""},
+		{`[{"c": "red"}, {"d": {"c": "red"}}, {"c": "red", "d": {"c": "blue"}}]`, `$[?value(@..c) == "red"]`, `$[0] $[1]`, ""},
+		{`["2024-01-02", "2024-1-2", "x2024-01-02", "2024-01-02\nx"]`, `$[?match(@, "\\d{4}-\\d{2}-\\d{2}")]`, `$[0]`, ""},
+		{`["a\nb", "a.b", "axb"]`, `$[?match(@, "a.b")]`, `$[1] $[2]`, ""},
+		{`["ab", "b"]`, `$[?search(@, "^b")]`, `$[1]`, ""},
+		{`["ab", "b"]`, `$[?match(@, "(")]`, "", ""},
+		{`{"\u000b": 1, "\\": 2, "é": 3}`, `$.*`, `$['\u000b'] $['\\'] $['é']`, `1 2 3`},
+		{`{"😀": 1}`, `$["😀"]`, `$['😀']`, `1`},
+		{`{"😀": 1}`, `$.😀`, `$['😀']`, `1`},
+	}
+	for _, testCase := range cases {
+		paths, values := queryBoth(t, testCase.Document, testCase.Query)
+		if testCase.Paths != "" && strings.Join(paths, " ") != testCase.Paths {
+			t.Errorf("FAIL: %s: expected paths %s but got %s", testCase.Query, testCase.Paths, strings.Join(paths, " "))
+		}
+		if (testCase.Values != "" || testCase.Paths == "") && strings.Join(values, " ") != testCase.Values {
+			t.Errorf("FAIL: %s: expected values %s but got %s", testCase.Query, testCase.Values, strings.Join(values, " "))
+		}
+	}
+}
+
+func TestJSONExplorer_QueryMatch(t *testing.T) {
+	matches, err := NewJSONExplorer(parser.RawValue(bookstore)).Query(`$.store.book[?@.price > 20]`)
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("Expected one match, but got %v (%v)", matches, err)
+	}
+	title, err := ValueOf[string](matches[0].Field("title"))
+	if err != nil || title != "The Lord of the Rings" {
+		t.Errorf("Expected the match to be explorable, but got %v (%v)", title, err)
+	}
+}
+
+func TestCompileJSONPath_Invalid(t *testing.T) {
+	queries := []string{
+		``, `store`, ` $`, `$ `, `$.`, `$..`, `$. a`, `$[`, `$[1`, `$['a'`, `$[01]`, `$[-0]`, `$[1.0]`,
+		`$[9007199254740992]`, `$[1:2:3:4]`, `$['\a']`, `$['\uD800']`, "$['\x01']", `$.1a`,
+		`$[?@.a == 1 == 2]`, `$[?1]`, `$[?true]`, `$[?@.a === 1]`, `$[?(@.a]`, `$[?!@.a == 1]`,
+		`$[?@.* == 1]`, `$[?@..a == 1]`, `$[?@[0, 1] == 1]`,
+		`$[?length(@) < 3 == true]`, `$[?length(@.*) < 3]`, `$[?count(1) == 1]`, `$[?count(foo(@.*)) == 1]`,
+		`$[?match(@.timezone, 'Europe/.*') == true]`, `$[?value(@..color)]`, `$[?length(@)]`,
+		`$[?bar(@.a)]`, `$[?length (@)]`, `$[?length(@, @)]`, `$[?match(@)]`, `$[?Length(@) == 1]`,
+		`$[?@.a == 01]`, `$[?@.a == 1.]`, `$[?@.a == 1e]`, `$[?@.a == .5]`, `$[?@.a == nul]`, `$[?@.b == {}]`,
+	}
+	for _, query := range queries {
+		if _, err := CompileJSONPath(query); err == nil {
+			t.Errorf("FAIL: expected %q to be rejected", query)
+		}
+	}
+
+	valid := []string{
+		`$`, `$.a`, `$ .a`, `$[ 'a' , "b" ]`, `$[?length(@) < 3]`, `$[?count(@.*) == 1]`,
+		`$[?match(@.timezone, 'Europe/.*')]`, `$[?value(@..color) == "red"]`, `$[?@.a == -0]`,
+		`$[?@.a == 1.5e-3]`, `$[?@.a==true&&@.b!=null||!@.c]`, `$[? ( @.a ) ]`, `$[::]`, `$[:]`,
+		`$[-9007199254740991]`,
+	}
+	for _, query := range valid {
+		if _, err := CompileJSONPath(query); err != nil {
+			t.Errorf("FAIL: expected %q to be valid, got %v", query, err)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+// Package jsonschema infers JSON Schemas (draft 2020-12) from sample
+// documents decoded with json.ParseJson.
+package jsonschema
+
+import (
+	"io"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/json"
+)
+
+// Draft is the $schema of the inferred schemas.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// DefaultMaxEnum is the number of distinct strings an enum may hold when
+// Inferrer.MaxEnum is zero.
+const DefaultMaxEnum = 8
+
+type kind uint8
+
+const (
+	kindNull kind = 1 << iota
+	kindBoolean
+	kindInteger
+	kindNumber
+	kindString
+	kindArray
+	kindObject
+)
+
+var kindNames = []struct {
+	kind kind
+	name string
+}{
+	{kindNull, "null"},
+	{kindBoolean, "boolean"},
+	{kindInteger, "integer"},
+	{kindNumber, "number"},
+	{kindString, "string"},
+	{kindArray, "array"},
+	{kindObject, "object"},
+}
+
+// node summarizes every value seen at one place of the samples. Its size
+// depends on the shape of the samples, never on their number.
+type node struct {
+	kinds kind
+
+	// numbers
+	numberCount int
+	min, max    float64
+
+	// strings; values counts the distinct strings until there are more than
+	// the enum limit, then it is dropped
+	stringCount    int
+	minLen, maxLen int
+	values         map[string]int
+	tooMany        bool
+
+	// arrays
+	items *node
+
+	// objects; seen counts the objects holding this node as a property
+	objects  int
+	children map[string]*node
+	seen     int
+}
+
+func (n *node) add(value any, maxEnum int) {
+	switch value := value.(type) {
+	case nil:
+		n.kinds |= kindNull
+	case bool:
+		n.kinds |= kindBoolean
+	case float64:
+		if value == math.Trunc(value) {
+			n.kinds |= kindInteger
+		} else {
+			n.kinds |= kindNumber
+		}
+		if n.numberCount == 0 || value < n.min {
+			n.min = value
+		}
+		if n.numberCount == 0 || value > n.max {
+			n.max = value
+		}
+		n.numberCount++
+	case string:
+		n.kinds |= kindString
+		length := len([]rune(value))
+		if n.stringCount == 0 || length < n.minLen {
+			n.minLen = length
+		}
+		if n.stringCount == 0 || length > n.maxLen {
+			n.maxLen = length
+		}
+		n.stringCount++
+		if n.tooMany || maxEnum < 0 {
+			return
+		}
+		if n.values == nil {
+			n.values = make(map[string]int)
+		}
+		n.values[value]++
+		if len(n.values) > maxEnum {
+			n.values = nil
+			n.tooMany = true
+		}
+	case []any:
+		n.kinds |= kindArray
+		if n.items == nil {
+			n.items = &node{}
+		}
+		for _, item := range value {
+			n.items.add(item, maxEnum)
+		}
+	case map[string]any:
+		n.kinds |= kindObject
+		n.objects++
+		if n.children == nil {
+			n.children = make(map[string]*node)
+		}
+		for key, item := range value {
+			child, ok := n.children[key]
+			if !ok {
+				child = &node{}
+				n.children[key] = child
+			}
+			child.seen++
+			child.add(item, maxEnum)
+		}
+	}
+}
+
+func (n *node) schema() map[string]any {
+	schema := make(map[string]any)
+	kinds := n.kinds
+	if kinds&kindNumber != 0 {
+		kinds &^= kindInteger
+	}
+	types := make([]any, 0, 1)
+	for _, k := range kindNames {
+		if kinds&k.kind != 0 {
+			types = append(types, k.name)
+		}
+	}
+	switch len(types) {
+	case 0:
+		// only empty arrays were seen, anything goes
+		return schema
+	case 1:
+		schema["type"] = types[0]
+	default:
+		schema["type"] = types
+	}
+
+	if kinds&(kindInteger|kindNumber) != 0 {
+		schema["minimum"] = n.min
+		schema["maximum"] = n.max
+	}
+	if kinds&kindString != 0 {
+		// an enum is only worth it when values repeat
+		if n.values != nil && len(n.values) < n.stringCount && kinds&^kindNull == kindString {
+			enum := make([]any, 0, len(n.values)+1)
+			for value := range n.values {
+				enum = append(enum, value)
+			}
+			slices.SortFunc(enum, func(a, b any) int {
+				return strings.Compare(a.(string), b.(string))
+			})
+			if kinds&kindNull != 0 {
+				enum = append(enum, nil)
+			}
+			schema["enum"] = enum
+		} else {
+			schema["minLength"] = float64(n.minLen)
+			schema["maxLength"] = float64(n.maxLen)
+		}
+	}
+	if kinds&kindArray != 0 && n.items.kinds != 0 {
+		schema["items"] = n.items.schema()
+	}
+	if kinds&kindObject != 0 {
+		properties := make(map[string]any, len(n.children))
+		required := make([]any, 0, len(n.children))
+		for key, child := range n.children {
+			properties[key] = child.schema()
+			if child.seen == n.objects {
+				required = append(required, key)
+			}
+		}
+		slices.SortFunc(required, func(a, b any) int {
+			return strings.Compare(a.(string), b.(string))
+		})
+		schema["properties"] = properties
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+	}
+	return schema
+}
+
+// Inferrer merges sample documents into one schema that validates all of
+// them. It keeps a summary per place in the documents, so samples can be
+// added one at a time from a corpus that does not fit in memory.
+type Inferrer struct {
+	// MaxEnum is the most distinct values a string may take to be described
+	// by an enum, DefaultMaxEnum when zero. Negative disables enums.
+	MaxEnum int
+
+	root    node
+	samples int
+}
+
+// Add merges a document decoded with json.ParseJson.
+func (in *Inferrer) Add(document any) {
+	maxEnum := in.MaxEnum
+	if maxEnum == 0 {
+		maxEnum = DefaultMaxEnum
+	}
+	in.root.add(document, maxEnum)
+	in.samples++
+}
+
+// AddStream merges every value of r, which holds any number of documents one
+// after the other like NDJSON. Only one document is in memory at a time.
+func (in *Inferrer) AddStream(r io.Reader) error {
+	parser := json.NewPushParser(func(document any) error {
+		in.Add(document)
+		return nil
+	})
+	if _, err := io.Copy(parser, r); err != nil {
+		return err
+	}
+	return parser.Close()
+}
+
+// Samples returns the number of documents added.
+func (in *Inferrer) Samples() int {
+	return in.samples
+}
+
+// Schema returns the schema inferred so far as a tree that json.Marshal
+// encodes. Without samples it accepts anything.
+func (in *Inferrer) Schema() map[string]any {
+	schema := in.root.schema()
+	schema["$schema"] = Draft
+	return schema
+}
+
+// Infer returns the schema of documents.
+func Infer(documents ...any) map[string]any {
+	in := &Inferrer{}
+	for _, document := range documents {
+		in.Add(document)
+	}
+	return in.Schema()
+}
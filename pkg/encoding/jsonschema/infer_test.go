@@ -0,0 +1,131 @@
+package jsonschema_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/json"
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/jsonschema"
+)
+
+func schemaOf(t *testing.T, in *jsonschema.Inferrer, samples ...string) string {
+	t.Helper()
+	for _, sample := range samples {
+		document, err := json.ParseJson(sample)
+		if err != nil {
+			t.Fatal(err)
+		}
+		in.Add(document)
+	}
+	out, err := json.Marshal(in.Schema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := strings.Replace(string(out), `"$schema":"`+jsonschema.Draft+`"`, "", 1)
+	return strings.Replace(schema, "{,", "{", 1)
+}
+
+func TestInferrer(t *testing.T) {
+	cases := []struct {
+		samples  []string
+		expected string
+	}{
+		{nil, `{}`},
+		{[]string{`true`}, `{"type":"boolean"}`},
+		{[]string{`null`}, `{"type":"null"}`},
+		{[]string{`1`, `5`, `-2`}, `{"maximum":5,"minimum":-2,"type":"integer"}`},
+		{[]string{`1`, `2.5`}, `{"maximum":2.5,"minimum":1,"type":"number"}`},
+		{[]string{`1`, `"a"`, `null`}, `{"maxLength":1,"maximum":1,"minLength":1,"minimum":1,"type":["null","integer","string"]}`},
+		{[]string{`"ab"`, `"ŝŝŝ"`}, `{"maxLength":3,"minLength":2,"type":"string"}`},
+		{[]string{`"on"`, `"off"`, `"on"`}, `{"enum":["off","on"],"type":"string"}`},
+		{[]string{`"on"`, `null`, `"on"`}, `{"enum":["on",null],"type":["null","string"]}`},
+		{[]string{`[]`}, `{"type":"array"}`},
+		{[]string{`[1, 2]`, `[3]`}, `{"items":{"maximum":3,"minimum":1,"type":"integer"},"type":"array"}`},
+		{[]string{`[[]]`}, `{"items":{"type":"array"},"type":"array"}`},
+		{[]string{`{}`}, `{"properties":{},"type":"object"}`},
+		{
+			[]string{`{"id": 1, "tags": ["a"], "user": {"name": "x"}}`, `{"id": 2, "tags": [], "user": {"name": "y", "age": 30}}`},
+			`{"properties":{"id":{"maximum":2,"minimum":1,"type":"integer"},"tags":{"items":{"maxLength":1,"minLength":1,"type":"string"},"type":"array"},` +
+				`"user":{"properties":{"age":{"maximum":30,"minimum":30,"type":"integer"},"name":{"maxLength":1,"minLength":1,"type":"string"}},"required":["name"],"type":"object"}},` +
+				`"required":["id","tags","user"],"type":"object"}`,
+		},
+		{[]string{`{"a": 1}`, `null`}, `{"properties":{"a":{"maximum":1,"minimum":1,"type":"integer"}},"required":["a"],"type":["null","object"]}`},
+	}
+	for _, c := range cases {
+		if out := schemaOf(t, &jsonschema.Inferrer{}, c.samples...); out != c.expected {
+			t.Errorf("FAIL: %v: expected %s but got %s", c.samples, c.expected, out)
+		}
+	}
+}
+
+func TestInferrer_Enum(t *testing.T) {
+	samples := make([]string, 0)
+	for i := 0; i < 20; i++ {
+		samples = append(samples, fmt.Sprintf(`"v%d"`, i%3))
+	}
+	if out := schemaOf(t, &jsonschema.Inferrer{}, samples...); out != `{"enum":["v0","v1","v2"],"type":"string"}` {
+		t.Errorf("FAIL: got %s", out)
+	}
+	if out := schemaOf(t, &jsonschema.Inferrer{MaxEnum: 2}, samples...); out != `{"maxLength":2,"minLength":2,"type":"string"}` {
+		t.Errorf("FAIL: expected no enum above MaxEnum but got %s", out)
+	}
+	if out := schemaOf(t, &jsonschema.Inferrer{MaxEnum: -1}, samples...); out != `{"maxLength":2,"minLength":2,"type":"string"}` {
+		t.Errorf("FAIL: expected no enum when disabled but got %s", out)
+	}
+	// an enum of strings would reject the numbers
+	if out := schemaOf(t, &jsonschema.Inferrer{}, `"a"`, `"a"`, `1`); out != `{"maxLength":1,"maximum":1,"minLength":1,"minimum":1,"type":["integer","string"]}` {
+		t.Errorf("FAIL: got %s", out)
+	}
+}
+
+// corpus writes NDJSON lines as they are read, so the test never holds the
+// whole corpus.
+type corpus struct {
+	lines, next int
+	buf         []byte
+}
+
+func (c *corpus) Read(p []byte) (int, error) {
+	for len(c.buf) == 0 {
+		if c.next == c.lines {
+			return 0, io.EOF
+		}
+		level := []string{"info", "warn", "error"}[c.next%3]
+		c.buf = fmt.Appendf(nil, `{"seq": %d, "level": %q, "latency": %d.5}`+"\n", c.next, level, c.next%100)
+		if c.next%10 == 0 {
+			c.buf = fmt.Appendf(nil, `{"seq": %d, "level": %q, "error": {"code": %d}}`+"\n", c.next, level, c.next)
+		}
+		c.next++
+	}
+	n := copy(p, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
+
+func TestInferrer_AddStream(t *testing.T) {
+	in := &jsonschema.Inferrer{}
+	if err := in.AddStream(&corpus{lines: 10_000}); err != nil {
+		t.Fatal(err)
+	}
+	if in.Samples() != 10_000 {
+		t.Errorf("FAIL: expected 10000 samples but got %d", in.Samples())
+	}
+	out, err := json.Marshal(in.Schema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"$schema":"https://json-schema.org/draft/2020-12/schema","properties":{` +
+		`"error":{"properties":{"code":{"maximum":9990,"minimum":0,"type":"integer"}},"required":["code"],"type":"object"},` +
+		`"latency":{"maximum":99.5,"minimum":1.5,"type":"number"},` +
+		`"level":{"enum":["error","info","warn"],"type":"string"},` +
+		`"seq":{"maximum":9999,"minimum":0,"type":"integer"}},"required":["level","seq"],"type":"object"}`
+	if string(out) != expected {
+		t.Errorf("FAIL: expected %s but got %s", expected, out)
+	}
+
+	if err := in.AddStream(strings.NewReader(`{"seq": 1} {"seq": `)); err == nil {
+		t.Errorf("FAIL: expected an error for a truncated stream")
+	}
+}
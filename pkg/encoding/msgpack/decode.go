@@ -0,0 +1,534 @@
+package msgpack
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/internal/structfields"
+)
+
+// maxDepth bounds the nesting of arrays and maps, so that a crafted input
+// cannot exhaust the stack.
+const maxDepth = 10000
+
+var timeType = reflect.TypeOf(time.Time{})
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// Decoder reads a stream of MessagePack values.
+type Decoder struct {
+	r byteReader
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	if br, ok := r.(byteReader); ok {
+		return &Decoder{r: br}
+	}
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Unmarshal decodes a single value. Integers decode to int64, or uint64 when
+// they do not fit, floats to float64, the timestamp extension to time.Time
+// and the other extensions to Ext. Maps decode to map[string]any when every
+// key is a string and to map[any]any otherwise.
+func Unmarshal(data []byte) (any, error) {
+	r := bytes.NewReader(data)
+	value, err := NewDecoder(r).Decode()
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("msgpack: %d bytes of trailing data", r.Len())
+	}
+	return value, nil
+}
+
+// UnmarshalInto decodes a single value into v, which must be a non-nil
+// pointer. Struct fields are matched by their `json` struct tag names.
+func UnmarshalInto(data []byte, v any) error {
+	r := bytes.NewReader(data)
+	if err := NewDecoder(r).DecodeInto(v); err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		return fmt.Errorf("msgpack: %d bytes of trailing data", r.Len())
+	}
+	return nil
+}
+
+// Decode reads the next value. It returns io.EOF when the stream ends before
+// a new value.
+func (d *Decoder) Decode() (any, error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	return d.value(b, 0)
+}
+
+// DecodeInto reads the next value into v, which must be a non-nil pointer.
+func (d *Decoder) DecodeInto(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("msgpack: DecodeInto expects a non-nil pointer, got %T", v)
+	}
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	return d.into(b, rv.Elem(), 0)
+}
+
+func (d *Decoder) next(depth int) (byte, error) {
+	if depth > maxDepth {
+		return 0, errors.New("msgpack: maximum nesting depth exceeded")
+	}
+	b, err := d.r.ReadByte()
+	return b, unexpectedEOF(err)
+}
+
+// value decodes the value whose first byte is b.
+func (d *Decoder) value(b byte, depth int) (any, error) {
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b == 0xc0:
+		return nil, nil
+	case b == 0xc2:
+		return false, nil
+	case b == 0xc3:
+		return true, nil
+	case b == 0xca:
+		n, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case b == 0xcb:
+		n, err := d.uint(8)
+		return math.Float64frombits(n), err
+	case b >= 0xcc && b <= 0xcf:
+		n, err := d.uint(1 << (b - 0xcc))
+		if n > math.MaxInt64 {
+			return n, err
+		}
+		return int64(n), err
+	case b >= 0xd0 && b <= 0xd3:
+		size := 1 << (b - 0xd0)
+		n, err := d.uint(size)
+		shift := 64 - 8*size
+		return int64(n<<shift) >> shift, err
+	}
+
+	if n, ok, err := d.length(b, reflect.String); ok || err != nil {
+		if err != nil {
+			return nil, err
+		}
+		s, err := d.read(n)
+		return string(s), err
+	}
+	if n, ok, err := d.length(b, reflect.Slice); ok || err != nil {
+		if err != nil {
+			return nil, err
+		}
+		return d.read(n)
+	}
+	if n, ok, err := d.length(b, reflect.Array); ok || err != nil {
+		if err != nil {
+			return nil, err
+		}
+		arr := make([]any, 0, min(n, 1024))
+		for i := 0; i < n; i++ {
+			b, err := d.next(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			value, err := d.value(b, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		return arr, nil
+	}
+	if n, ok, err := d.length(b, reflect.Map); ok || err != nil {
+		if err != nil {
+			return nil, err
+		}
+		return d.mapValue(n, depth)
+	}
+	if typ, data, ok, err := d.ext(b); ok || err != nil {
+		if err != nil {
+			return nil, err
+		}
+		if typ == ExtTimestamp {
+			return decodeTimestamp(data)
+		}
+		return Ext{Type: typ, Data: data}, nil
+	}
+	return nil, fmt.Errorf("msgpack: invalid format byte 0x%02x", b)
+}
+
+func (d *Decoder) mapValue(n int, depth int) (any, error) {
+	obj := make(map[string]any, min(n, 1024))
+	var others map[any]any
+	for i := 0; i < 2*n; i += 2 {
+		kb, err := d.next(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		key, err := d.value(kb, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		vb, err := d.next(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.value(vb, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if k, ok := key.(string); ok && others == nil {
+			obj[k] = value
+			continue
+		}
+		if key != nil && !reflect.TypeOf(key).Comparable() {
+			return nil, fmt.Errorf("msgpack: unsupported map key type %T", key)
+		}
+		if others == nil {
+			others = make(map[any]any, len(obj)+1)
+			for k, v := range obj {
+				others[k] = v
+			}
+		}
+		others[key] = value
+	}
+	if others != nil {
+		return others, nil
+	}
+	return obj, nil
+}
+
+// length decodes the header of a string (reflect.String), binary
+// (reflect.Slice), array (reflect.Array) or map (reflect.Map) starting with
+// b. ok is false when b starts another type.
+func (d *Decoder) length(b byte, kind reflect.Kind) (n int, ok bool, err error) {
+	var fix, fixMask, code8, code16, code32 byte
+	switch kind {
+	case reflect.String:
+		fix, fixMask, code8, code16, code32 = 0xa0, 0xe0, 0xd9, 0xda, 0xdb
+	case reflect.Slice:
+		code8, code16, code32 = 0xc4, 0xc5, 0xc6
+	case reflect.Array:
+		fix, fixMask, code16, code32 = 0x90, 0xf0, 0xdc, 0xdd
+	case reflect.Map:
+		fix, fixMask, code16, code32 = 0x80, 0xf0, 0xde, 0xdf
+	}
+	var size int
+	switch {
+	case fixMask != 0 && b&fixMask == fix:
+		return int(b &^ fixMask), true, nil
+	case code8 != 0 && b == code8:
+		size = 1
+	case b == code16:
+		size = 2
+	case b == code32:
+		size = 4
+	default:
+		return 0, false, nil
+	}
+	length, err := d.uint(size)
+	return int(length), true, err
+}
+
+func (d *Decoder) ext(b byte) (typ int8, data []byte, ok bool, err error) {
+	var n int
+	switch b {
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		n = 1 << (b - 0xd4)
+	case 0xc7, 0xc8, 0xc9:
+		length, err := d.uint(1 << (b - 0xc7))
+		if err != nil {
+			return 0, nil, true, err
+		}
+		n = int(length)
+	default:
+		return 0, nil, false, nil
+	}
+	t, err := d.r.ReadByte()
+	if err != nil {
+		return 0, nil, true, unexpectedEOF(err)
+	}
+	data, err = d.read(n)
+	return int8(t), data, true, err
+}
+
+func decodeTimestamp(data []byte) (time.Time, error) {
+	switch len(data) {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0).UTC(), nil
+	case 8:
+		n := binary.BigEndian.Uint64(data)
+		return time.Unix(int64(n&(1<<34-1)), int64(n>>34)).UTC(), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(data)
+		return time.Unix(int64(binary.BigEndian.Uint64(data[4:])), int64(nsec)).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("msgpack: invalid timestamp length %d", len(data))
+}
+
+func (d *Decoder) uint(size int) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(d.r, buf[8-size:]); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+// read reads n bytes without trusting n for the allocation.
+func (d *Decoder) read(n int) ([]byte, error) {
+	if n <= 4096 {
+		buf := make([]byte, n)
+		_, err := io.ReadFull(d.r, buf)
+		return buf, unexpectedEOF(err)
+	}
+	var buf bytes.Buffer
+	if read, err := io.CopyN(&buf, d.r, int64(n)); err != nil || read != int64(n) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return buf.Bytes(), nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// into decodes the value whose first byte is b directly into rv.
+func (d *Decoder) into(b byte, rv reflect.Value, depth int) error {
+	if b == 0xc0 {
+		rv.SetZero()
+		return nil
+	}
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return d.into(b, rv.Elem(), depth)
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			return fmt.Errorf("msgpack: cannot decode into %s", rv.Type())
+		}
+		value, err := d.value(b, depth)
+		if err != nil {
+			return err
+		}
+		if value != nil {
+			rv.Set(reflect.ValueOf(value))
+		}
+		return nil
+	case reflect.Struct:
+		if rv.Type() == timeType {
+			break
+		}
+		n, ok, err := d.length(b, reflect.Map)
+		if !ok || err != nil {
+			return d.mismatch(b, rv, err)
+		}
+		fields := structfields.Of(rv.Type())
+		for i := 0; i < n; i++ {
+			kb, err := d.next(depth + 1)
+			if err != nil {
+				return err
+			}
+			key, err := d.value(kb, depth+1)
+			if err != nil {
+				return err
+			}
+			name, _ := key.(string)
+			vb, err := d.next(depth + 1)
+			if err != nil {
+				return err
+			}
+			if f, ok := structfields.Lookup(fields, name); ok {
+				field, ok := f.Set(rv)
+				if !ok {
+					return fmt.Errorf("msgpack: cannot set field %s of %s through an embedded pointer to an unexported struct", f.Name, rv.Type())
+				}
+				if err := d.into(vb, field, depth+1); err != nil {
+					return err
+				}
+			} else if _, err := d.value(vb, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		n, ok, err := d.length(b, reflect.Map)
+		if !ok || err != nil {
+			return d.mismatch(b, rv, err)
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMapWithSize(rv.Type(), min(n, 1024)))
+		}
+		for i := 0; i < n; i++ {
+			key := reflect.New(rv.Type().Key()).Elem()
+			value := reflect.New(rv.Type().Elem()).Elem()
+			for _, target := range []reflect.Value{key, value} {
+				next, err := d.next(depth + 1)
+				if err != nil {
+					return err
+				}
+				if err := d.into(next, target, depth+1); err != nil {
+					return err
+				}
+			}
+			rv.SetMapIndex(key, value)
+		}
+		return nil
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			if n, ok, err := d.length(b, reflect.Slice); ok || err != nil {
+				if err != nil {
+					return err
+				}
+				data, err := d.read(n)
+				if err != nil {
+					return err
+				}
+				if rv.Kind() == reflect.Slice {
+					rv.SetBytes(data)
+				} else {
+					reflect.Copy(rv, reflect.ValueOf(data))
+				}
+				return nil
+			}
+		}
+		n, ok, err := d.length(b, reflect.Array)
+		if !ok || err != nil {
+			return d.mismatch(b, rv, err)
+		}
+		if rv.Kind() == reflect.Slice {
+			rv.Set(reflect.MakeSlice(rv.Type(), 0, min(n, 1024)))
+		}
+		for i := 0; i < n; i++ {
+			next, err := d.next(depth + 1)
+			if err != nil {
+				return err
+			}
+			switch {
+			case rv.Kind() == reflect.Slice:
+				elem := reflect.New(rv.Type().Elem()).Elem()
+				if err := d.into(next, elem, depth+1); err != nil {
+					return err
+				}
+				rv.Set(reflect.Append(rv, elem))
+			case i < rv.Len():
+				if err := d.into(next, rv.Index(i), depth+1); err != nil {
+					return err
+				}
+			default:
+				if _, err := d.value(next, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	value, err := d.value(b, depth)
+	if err != nil {
+		return err
+	}
+	return assign(rv, value)
+}
+
+func (d *Decoder) mismatch(b byte, rv reflect.Value, err error) error {
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("msgpack: cannot decode format byte 0x%02x into %s", b, rv.Type())
+}
+
+// assign stores a decoded scalar into rv, checking for overflows.
+func assign(rv reflect.Value, value any) error {
+	switch rv.Kind() {
+	case reflect.Bool:
+		if b, ok := value.(bool); ok {
+			rv.SetBool(b)
+			return nil
+		}
+	case reflect.String:
+		if s, ok := value.(string); ok {
+			rv.SetString(s)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch value := value.(type) {
+		case int64:
+			n = value
+		case float64:
+			if value != math.Trunc(value) {
+				return fmt.Errorf("msgpack: cannot safely convert %v to %s", value, rv.Type())
+			}
+			n = int64(value)
+		default:
+			return fmt.Errorf("msgpack: cannot decode %T into %s", value, rv.Type())
+		}
+		if rv.OverflowInt(n) {
+			return fmt.Errorf("msgpack: %d overflows %s", n, rv.Type())
+		}
+		rv.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var n uint64
+		switch value := value.(type) {
+		case int64:
+			if value < 0 {
+				return fmt.Errorf("msgpack: %d overflows %s", value, rv.Type())
+			}
+			n = uint64(value)
+		case uint64:
+			n = value
+		default:
+			return fmt.Errorf("msgpack: cannot decode %T into %s", value, rv.Type())
+		}
+		if rv.OverflowUint(n) {
+			return fmt.Errorf("msgpack: %d overflows %s", n, rv.Type())
+		}
+		rv.SetUint(n)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		switch value := value.(type) {
+		case float64:
+			rv.SetFloat(value)
+			return nil
+		case int64:
+			rv.SetFloat(float64(value))
+			return nil
+		case uint64:
+			rv.SetFloat(float64(value))
+			return nil
+		}
+	case reflect.Struct:
+		if t, ok := value.(time.Time); ok && rv.Type() == timeType {
+			rv.Set(reflect.ValueOf(t))
+			return nil
+		}
+	}
+	return fmt.Errorf("msgpack: cannot decode %T into %s", value, rv.Type())
+}
@@ -0,0 +1,285 @@
+package msgpack
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/internal/structfields"
+)
+
+// ExtTimestamp is the extension type of the timestamp extension.
+const ExtTimestamp int8 = -1
+
+// Ext is an extension value whose type has no specific Go type.
+type Ext struct {
+	Type int8
+	Data []byte
+}
+
+// Marshal encodes nil, bool, integers, floats, string, []byte, []any,
+// map[string]any, map[any]any, time.Time (timestamp extension) and Ext.
+// Other structs, slices, maps and pointers are encoded through reflection,
+// with structs encoded as maps named after their `json` struct tags and the
+// fields of embedded structs promoted, as encoding/json does.
+func Marshal(v any) ([]byte, error) {
+	e := &encoder{}
+	if err := e.encode(v); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) encode(v any) error {
+	switch value := v.(type) {
+	case nil:
+		e.buf = append(e.buf, 0xc0)
+	case bool:
+		if value {
+			e.buf = append(e.buf, 0xc3)
+		} else {
+			e.buf = append(e.buf, 0xc2)
+		}
+	case int:
+		e.int(int64(value))
+	case int8:
+		e.int(int64(value))
+	case int16:
+		e.int(int64(value))
+	case int32:
+		e.int(int64(value))
+	case int64:
+		e.int(value)
+	case uint:
+		e.uint(uint64(value))
+	case uint8:
+		e.uint(uint64(value))
+	case uint16:
+		e.uint(uint64(value))
+	case uint32:
+		e.uint(uint64(value))
+	case uint64:
+		e.uint(value)
+	case float32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xca), math.Float32bits(value))
+	case float64:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcb), math.Float64bits(value))
+	case string:
+		e.string(value)
+	case []byte:
+		e.bytes(value)
+	case time.Time:
+		e.timestamp(value)
+	case Ext:
+		e.ext(value.Type, value.Data)
+	case []any:
+		e.arrayHeader(len(value))
+		for _, item := range value {
+			if err := e.encode(item); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		e.mapHeader(len(value))
+		for k, item := range value {
+			e.string(k)
+			if err := e.encode(item); err != nil {
+				return err
+			}
+		}
+	case map[any]any:
+		e.mapHeader(len(value))
+		for k, item := range value {
+			if err := e.encode(k); err != nil {
+				return err
+			}
+			if err := e.encode(item); err != nil {
+				return err
+			}
+		}
+	default:
+		return e.encodeValue(reflect.ValueOf(v))
+	}
+	return nil
+}
+
+func (e *encoder) encodeValue(rv reflect.Value) error {
+	switch rv.Kind() {
+	case reflect.Bool:
+		return e.encode(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.int(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.uint(rv.Uint())
+	case reflect.Float32:
+		return e.encode(float32(rv.Float()))
+	case reflect.Float64:
+		return e.encode(rv.Float())
+	case reflect.String:
+		e.string(rv.String())
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		return e.encode(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			e.bytes(b)
+			return nil
+		}
+		e.arrayHeader(rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			if err := e.encode(rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if rv.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		e.mapHeader(rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			if err := e.encode(iter.Key().Interface()); err != nil {
+				return err
+			}
+			if err := e.encode(iter.Value().Interface()); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		fields := structfields.Of(rv.Type())
+		values := make([]reflect.Value, 0, len(fields))
+		names := make([]string, 0, len(fields))
+		for _, f := range fields {
+			value, ok := f.Get(rv)
+			if !ok || f.OmitEmpty && value.IsZero() {
+				continue
+			}
+			values = append(values, value)
+			names = append(names, f.Name)
+		}
+		e.mapHeader(len(values))
+		for i, value := range values {
+			e.string(names[i])
+			if err := e.encode(value.Interface()); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", rv.Type())
+	}
+	return nil
+}
+
+func (e *encoder) int(n int64) {
+	switch {
+	case n >= 0:
+		e.uint(uint64(n))
+	case n >= -32:
+		e.buf = append(e.buf, byte(n))
+	case n >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xd2), uint32(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xd3), uint64(n))
+	}
+}
+
+func (e *encoder) uint(n uint64) {
+	switch {
+	case n <= 0x7f:
+		e.buf = append(e.buf, byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xcd), uint16(n))
+	case n <= math.MaxUint32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xce), uint32(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcf), n)
+	}
+}
+
+// header writes the shortest of the fix, 8, 16 and 32 bit forms. A zero fix
+// or 8 bit code means that form does not exist for the type.
+func (e *encoder) header(n int, fix byte, fixMax int, code8, code16, code32 byte) {
+	switch {
+	case fix != 0 && n <= fixMax:
+		e.buf = append(e.buf, fix|byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		e.buf = append(e.buf, code8, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, code16), uint16(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, code32), uint32(n))
+	}
+}
+
+func (e *encoder) string(s string) {
+	e.header(len(s), 0xa0, 31, 0xd9, 0xda, 0xdb)
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) bytes(b []byte) {
+	e.header(len(b), 0, 0, 0xc4, 0xc5, 0xc6)
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) arrayHeader(n int) {
+	e.header(n, 0x90, 15, 0, 0xdc, 0xdd)
+}
+
+func (e *encoder) mapHeader(n int) {
+	e.header(n, 0x80, 15, 0, 0xde, 0xdf)
+}
+
+func (e *encoder) ext(typ int8, data []byte) {
+	switch len(data) {
+	case 1:
+		e.buf = append(e.buf, 0xd4)
+	case 2:
+		e.buf = append(e.buf, 0xd5)
+	case 4:
+		e.buf = append(e.buf, 0xd6)
+	case 8:
+		e.buf = append(e.buf, 0xd7)
+	case 16:
+		e.buf = append(e.buf, 0xd8)
+	default:
+		e.header(len(data), 0, 0, 0xc7, 0xc8, 0xc9)
+	}
+	e.buf = append(e.buf, byte(typ))
+	e.buf = append(e.buf, data...)
+}
+
+// timestamp uses the smallest of the 32, 64 and 96 bit timestamp formats.
+func (e *encoder) timestamp(t time.Time) {
+	sec, nsec := t.Unix(), uint32(t.Nanosecond())
+	switch {
+	case sec>>34 == 0 && nsec == 0 && sec <= math.MaxUint32:
+		e.ext(ExtTimestamp, binary.BigEndian.AppendUint32(nil, uint32(sec)))
+	case sec>>34 == 0:
+		e.ext(ExtTimestamp, binary.BigEndian.AppendUint64(nil, uint64(nsec)<<34|uint64(sec)))
+	default:
+		data := binary.BigEndian.AppendUint32(nil, nsec)
+		e.ext(ExtTimestamp, binary.BigEndian.AppendUint64(data, uint64(sec)))
+	}
+}
@@ -0,0 +1,226 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/json"
+)
+
+func TestMarshal_Formats(t *testing.T) {
+	cases := []struct {
+		Value any
+		Hex   string
+	}{
+		{nil, "c0"},
+		{false, "c2"},
+		{true, "c3"},
+		{0, "00"},
+		{127, "7f"},
+		{128, "cc80"},
+		{256, "cd0100"},
+		{65536, "ce00010000"},
+		{uint64(math.MaxUint64), "cfffffffffffffffff"},
+		{-1, "ff"},
+		{-32, "e0"},
+		{-33, "d0df"},
+		{-129, "d1ff7f"},
+		{-32769, "d2ffff7fff"},
+		{int64(math.MinInt64), "d38000000000000000"},
+		{float32(1.5), "ca3fc00000"},
+		{1.5, "cb3ff8000000000000"},
+		{"", "a0"},
+		{"abc", "a3616263"},
+		{strings.Repeat("a", 32), "d920" + strings.Repeat("61", 32)},
+		{[]byte{1, 2}, "c4020102"},
+		{[]any{}, "90"},
+		{[]any{1, "a"}, "9201a161"},
+		{map[string]any{"a": 1}, "81a16101"},
+		{Ext{Type: 5, Data: []byte{1}}, "d40501"},
+		{Ext{Type: 5, Data: []byte{1, 2, 3}}, "c7030501020 3"},
+		{time.Unix(1, 0), "d6ff00000001"},
+		{time.Unix(1, 5), "d7ff0000001400000001"},
+		{time.Unix(-1, 0), "c70cff00000000ffffffffffffffff"},
+	}
+	for _, testCase := range cases {
+		out, err := Marshal(testCase.Value)
+		expected := strings.ReplaceAll(testCase.Hex, " ", "")
+		if err != nil || hex.EncodeToString(out) != expected {
+			t.Errorf("FAIL: %#v expected %s but got %x (%v)", testCase.Value, expected, out, err)
+		}
+	}
+}
+
+func TestUnmarshal_RoundTrip(t *testing.T) {
+	values := []any{
+		nil, true, int64(-1), int64(300), uint64(math.MaxUint64), 1.5, "text",
+		[]byte{1, 2, 3}, []any{int64(1), []any{}, map[string]any{}},
+		map[string]any{"a": []any{"b", nil}},
+		map[any]any{int64(1): "one", "two": int64(2)},
+		time.Date(2024, 2, 29, 12, 30, 0, 0, time.UTC),
+		time.Date(2024, 2, 29, 12, 30, 0, 123456789, time.UTC),
+		time.Date(1969, 7, 20, 20, 17, 0, 0, time.UTC),
+		time.Date(2600, 1, 1, 0, 0, 0, 1, time.UTC),
+		Ext{Type: 42, Data: bytes.Repeat([]byte{7}, 300)},
+	}
+	for _, value := range values {
+		out, err := Marshal(value)
+		if err != nil {
+			t.Errorf("FAIL: %#v error: %s", value, err)
+			continue
+		}
+		decoded, err := Unmarshal(out)
+		if err != nil {
+			t.Errorf("FAIL: %#v error: %s", value, err)
+			continue
+		}
+		if !reflect.DeepEqual(value, decoded) {
+			t.Errorf("FAIL: expected %#v but got %#v", value, decoded)
+		}
+	}
+
+	tree, err := json.ParseJson(`{"a": [1, -2.5, "x", null, true, {"b": []}], "c": {}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Unmarshal(out)
+	if err != nil || !reflect.DeepEqual(tree, decoded) {
+		t.Errorf("FAIL: expected %#v but got %#v (%v)", tree, decoded, err)
+	}
+}
+
+func TestDecoder_Stream(t *testing.T) {
+	var stream bytes.Buffer
+	for _, value := range []any{int64(1), "two", []any{int64(3)}} {
+		out, _ := Marshal(value)
+		stream.Write(out)
+	}
+	d := NewDecoder(io.MultiReader(bytes.NewReader(stream.Bytes()[:2]), bytes.NewReader(stream.Bytes()[2:])))
+	values := make([]any, 0)
+	for {
+		value, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, value)
+	}
+	if expected := []any{int64(1), "two", []any{int64(3)}}; !reflect.DeepEqual(expected, values) {
+		t.Errorf("FAIL: expected %#v but got %#v", expected, values)
+	}
+}
+
+func TestUnmarshalInto_Struct(t *testing.T) {
+	type Address struct {
+		City string `json:"city"`
+	}
+	type User struct {
+		Name      string            `json:"name"`
+		Age       uint8             `json:"age,omitempty"`
+		Score     float64           `json:"score"`
+		Tags      []string          `json:"tags"`
+		Address   *Address          `json:"address"`
+		Labels    map[string]int    `json:"labels"`
+		Avatar    []byte            `json:"avatar"`
+		CreatedAt time.Time         `json:"created_at"`
+		Extra     any               `json:"extra"`
+		Ignored   string            `json:"-"`
+		Nested    map[string]string `json:"nested"`
+		Plain     int
+	}
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	out, err := Marshal(map[string]any{
+		"name":       "ada",
+		"age":        36,
+		"score":      int64(10),
+		"tags":       []any{"a", "b"},
+		"address":    map[string]any{"city": "London"},
+		"labels":     map[string]any{"x": 1},
+		"avatar":     []byte{1, 2},
+		"created_at": created,
+		"extra":      []any{1.5},
+		"Ignored":    "no",
+		"unknown":    map[string]any{"deep": []any{1, 2}},
+		"nested":     nil,
+		"plain":      7,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var user User
+	if err := UnmarshalInto(out, &user); err != nil {
+		t.Fatal(err)
+	}
+	expected := User{
+		Name: "ada", Age: 36, Score: 10, Tags: []string{"a", "b"}, Address: &Address{City: "London"},
+		Labels: map[string]int{"x": 1}, Avatar: []byte{1, 2}, CreatedAt: created, Extra: []any{1.5}, Plain: 7,
+	}
+	if !reflect.DeepEqual(expected, user) {
+		t.Errorf("FAIL: expected %#v but got %#v", expected, user)
+	}
+
+	encoded, err := Marshal(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back User
+	if err := UnmarshalInto(encoded, &back); err != nil || !reflect.DeepEqual(expected, back) {
+		t.Errorf("FAIL: expected %#v but got %#v (%v)", expected, back, err)
+	}
+
+	var small struct {
+		Age int8 `json:"age"`
+	}
+	big, _ := Marshal(map[string]any{"age": 300})
+	if err := UnmarshalInto(big, &small); err == nil {
+		t.Errorf("FAIL: expected an overflow error")
+	}
+	if err := UnmarshalInto(out, small); err == nil {
+		t.Errorf("FAIL: expected an error for a non pointer target")
+	}
+}
+
+func TestUnmarshalInto_Embedded(t *testing.T) {
+	type Base struct {
+		ID   int `json:"id"`
+		Name string
+	}
+	type Item struct {
+		*Base
+		Name string
+	}
+	out, err := Marshal(Item{&Base{ID: 7, Name: "hidden"}, "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := Unmarshal(out)
+	if m, ok := value.(map[string]any); err != nil || !ok || len(m) != 2 || m["Name"] != "x" {
+		t.Errorf("FAIL: expected the promoted id and the own name but got %#v (%v)", value, err)
+	}
+	var back Item
+	if err := UnmarshalInto(out, &back); err != nil || back.Base == nil || back.ID != 7 || back.Name != "x" {
+		t.Errorf("FAIL: expected the embedded pointer to be allocated but got %#v (%v)", back, err)
+	}
+}
+
+func TestUnmarshal_Invalid(t *testing.T) {
+	for _, h := range []string{"", "c1", "cc", "a2 61", "92 01", "81 a161", "d6 ff 0000", "d5 ff 0000", "c4 05 01", "01 02"} {
+		data, _ := hex.DecodeString(strings.ReplaceAll(h, " ", ""))
+		if value, err := Unmarshal(data); err == nil {
+			t.Errorf("FAIL: %s expected an error but got %#v", h, value)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/json"
+)
+
+// ErrClosed is returned by the calls of a Client whose connection ended.
+var ErrClosed = errors.New("connection closed")
+
+// transport sends a message and returns the responses to the ids it holds,
+// by the text of their id.
+type transport interface {
+	roundTrip(ctx context.Context, msg []byte, ids []string) (map[string]response, error)
+	close() error
+}
+
+// Client calls the methods of a JSON-RPC server. It is safe for concurrent
+// use; over a connection, concurrent calls share it and their responses are
+// matched by id.
+type Client struct {
+	nextID    atomic.Int64
+	transport transport
+}
+
+// NewHTTPClient returns a client posting every message to url. A nil client
+// stands for http.DefaultClient.
+func NewHTTPClient(url string, client *http.Client) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{transport: &httpTransport{url: url, client: client}}
+}
+
+// NewClient returns a client talking over conn, like a net.Conn or the stdio
+// of a child process. It reads responses until conn ends or Close is called.
+func NewClient(conn io.ReadWriteCloser, framing Framing) *Client {
+	t := &connTransport{
+		conn:    conn,
+		framing: framing,
+		pending: make(map[string]chan response),
+		done:    make(chan struct{}),
+	}
+	go t.readLoop()
+	return &Client{transport: t}
+}
+
+// BatchCall is a call of a batch. Result, when not nil, is where the result
+// is decoded. Error is set by Client.Batch, to an *Error for error responses.
+type BatchCall struct {
+	Method string
+	Params any
+	Result any
+	// Notify sends the call as a notification, which gets no response.
+	Notify bool
+	Error  error
+}
+
+// Call calls method with params, an array, an object or nil, and decodes its
+// result into result unless it is nil. Error responses are returned as
+// *Error.
+func (c *Client) Call(ctx context.Context, method string, params any, result any) error {
+	calls := []BatchCall{{Method: method, Params: params, Result: result}}
+	if err := c.send(ctx, calls, false); err != nil {
+		return err
+	}
+	return calls[0].Error
+}
+
+// Notify sends a notification, which the server does not answer.
+func (c *Client) Notify(ctx context.Context, method string, params any) error {
+	calls := []BatchCall{{Method: method, Params: params, Notify: true}}
+	if err := c.send(ctx, calls, false); err != nil {
+		return err
+	}
+	return calls[0].Error
+}
+
+// Batch sends calls in a single message and sets the Error of each call. It
+// returns an error only when the batch could not be sent or answered.
+func (c *Client) Batch(ctx context.Context, calls []BatchCall) error {
+	if len(calls) == 0 {
+		return nil
+	}
+	return c.send(ctx, calls, true)
+}
+
+// Close closes the connection of the client.
+func (c *Client) Close() error {
+	return c.transport.close()
+}
+
+func (c *Client) send(ctx context.Context, calls []BatchCall, batch bool) error {
+	var msg []byte
+	if batch {
+		msg = append(msg, '[')
+	}
+	ids := make([]string, len(calls))
+	for i := range calls {
+		call := &calls[i]
+		req := request{Version: Version, Method: call.Method}
+		if call.Params != nil {
+			params, err := json.Marshal(call.Params)
+			if err != nil {
+				return fmt.Errorf("cannot encode the params of %s: %w", call.Method, err)
+			}
+			if !isStructured(params) {
+				return fmt.Errorf("cannot call %s: params must be an array or an object, not %T", call.Method, call.Params)
+			}
+			req.Params = params
+		}
+		if !call.Notify {
+			ids[i] = strconv.FormatInt(c.nextID.Add(1), 10)
+			req.ID = json.RawValue(ids[i])
+		}
+		encoded, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			msg = append(msg, ',')
+		}
+		msg = append(msg, encoded...)
+	}
+	if batch {
+		msg = append(msg, ']')
+	}
+
+	expected := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != "" {
+			expected = append(expected, id)
+		}
+	}
+	responses, err := c.transport.roundTrip(ctx, msg, expected)
+	if err != nil {
+		return err
+	}
+	for i := range calls {
+		call := &calls[i]
+		if call.Notify {
+			continue
+		}
+		r, ok := responses[ids[i]]
+		switch {
+		case !ok:
+			call.Error = fmt.Errorf("no response to the call of %s", call.Method)
+		case r.Error != nil:
+			call.Error = r.Error
+		case call.Result != nil:
+			if err := json.Unmarshal(r.Result, call.Result); err != nil {
+				call.Error = fmt.Errorf("cannot decode the result of %s: %w", call.Method, err)
+			}
+		}
+	}
+	return nil
+}
+
+type httpTransport struct {
+	url    string
+	client *http.Client
+}
+
+func (t *httpTransport) roundTrip(ctx context.Context, msg []byte, ids []string) (map[string]response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(msg))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMessageSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return nil, fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	decoded, err := decodeResponses(body)
+	if err != nil {
+		return nil, err
+	}
+	responses := make(map[string]response, len(decoded))
+	for _, r := range decoded {
+		// an error without id rejects the whole message
+		if len(r.ID) == 0 || string(r.ID) == "null" {
+			if r.Error != nil {
+				return nil, r.Error
+			}
+			continue
+		}
+		responses[string(r.ID)] = r
+	}
+	return responses, nil
+}
+
+func (t *httpTransport) close() error {
+	return nil
+}
+
+type connTransport struct {
+	conn    io.ReadWriteCloser
+	framing Framing
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	pending map[string]chan response
+	// err is why readLoop stopped, set before done is closed.
+	err  error
+	done chan struct{}
+}
+
+func (t *connTransport) roundTrip(ctx context.Context, msg []byte, ids []string) (map[string]response, error) {
+	waiting := make(map[string]chan response, len(ids))
+	t.mu.Lock()
+	if t.err != nil {
+		t.mu.Unlock()
+		return nil, t.err
+	}
+	for _, id := range ids {
+		waiting[id] = make(chan response, 1)
+		t.pending[id] = waiting[id]
+	}
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		for _, id := range ids {
+			delete(t.pending, id)
+		}
+		t.mu.Unlock()
+	}()
+
+	t.writeMu.Lock()
+	err := writeFrame(t.conn, t.framing, msg)
+	t.writeMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make(map[string]response, len(ids))
+	for id, ch := range waiting {
+		select {
+		case r := <-ch:
+			responses[id] = r
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-t.done:
+			return nil, t.err
+		}
+	}
+	return responses, nil
+}
+
+// readLoop hands the responses read from the connection to the calls
+// waiting for them.
+func (t *connTransport) readLoop() {
+	reader := newFrameReader(t.conn, t.framing)
+	var err error
+	for {
+		var msg []byte
+		if msg, err = reader.read(); err != nil {
+			break
+		}
+		responses, decodeErr := decodeResponses(msg)
+		if decodeErr != nil {
+			continue
+		}
+		t.mu.Lock()
+		for _, r := range responses {
+			// an error without id, like a parse error, cannot be matched
+			// to its call and fails all of them
+			if len(r.ID) == 0 || string(r.ID) == "null" {
+				if r.Error != nil {
+					for id, ch := range t.pending {
+						delete(t.pending, id)
+						ch <- r
+					}
+				}
+				continue
+			}
+			if ch, ok := t.pending[string(r.ID)]; ok {
+				delete(t.pending, string(r.ID))
+				ch <- r
+			}
+		}
+		t.mu.Unlock()
+	}
+
+	t.mu.Lock()
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrClosedPipe) || errors.Is(err, net.ErrClosed) {
+		err = ErrClosed
+	}
+	t.err = err
+	t.mu.Unlock()
+	close(t.done)
+}
+
+func (t *connTransport) close() error {
+	return t.conn.Close()
+}
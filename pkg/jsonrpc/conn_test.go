@@ -0,0 +1,211 @@
+package jsonrpc_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/jsonrpc"
+)
+
+// servePipe serves s on one end of a pipe and returns the other end. The
+// returned function closes the client end and waits for ServeConn.
+func servePipe(t *testing.T, s *jsonrpc.Server, framing jsonrpc.Framing) (net.Conn, func()) {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- s.ServeConn(context.Background(), serverConn, framing)
+		serverConn.Close()
+	}()
+	return clientConn, func() {
+		clientConn.Close()
+		if err := <-done; err != nil && !errors.Is(err, io.ErrClosedPipe) {
+			t.Errorf("FAIL: ServeConn: %v", err)
+		}
+	}
+}
+
+func TestConn(t *testing.T) {
+	for _, framing := range []jsonrpc.Framing{jsonrpc.NewlineFraming, jsonrpc.ContentLengthFraming} {
+		t.Run(framing.String(), func(t *testing.T) {
+			conn, stop := servePipe(t, newServer(t), framing)
+			defer stop()
+			client := jsonrpc.NewClient(conn, framing)
+			testClient(t, client)
+
+			// concurrent calls share the connection
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					var greeting string
+					name := fmt.Sprint("user", i)
+					if err := client.Call(context.Background(), "hello", []string{name}, &greeting); err != nil || greeting != "hello "+name {
+						t.Errorf("FAIL: %s: got %q %v", name, greeting, err)
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
+
+func TestConn_Cancel(t *testing.T) {
+	s := jsonrpc.NewServer()
+	release := make(chan struct{})
+	if err := s.Register("wait", func() error { <-release; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	conn, stop := servePipe(t, s, jsonrpc.NewlineFraming)
+	defer stop()
+	defer close(release)
+	client := jsonrpc.NewClient(conn, jsonrpc.NewlineFraming)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.Call(ctx, "wait", nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("FAIL: expected the deadline to be exceeded but got %v", err)
+	}
+}
+
+func TestConn_Close(t *testing.T) {
+	s := jsonrpc.NewServer()
+	if err := s.Register("ping", func() (string, error) { return "pong", nil }); err != nil {
+		t.Fatal(err)
+	}
+	conn, stop := servePipe(t, s, jsonrpc.NewlineFraming)
+	defer stop()
+	client := jsonrpc.NewClient(conn, jsonrpc.NewlineFraming)
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Call(context.Background(), "ping", nil, nil); err == nil {
+		t.Errorf("FAIL: expected an error after Close")
+	}
+}
+
+// TestConn_NullID answers with an error whose id is null, like a server that
+// could not parse the request.
+func TestConn_NullID(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	go func() {
+		reader := bufio.NewReader(serverConn)
+		for {
+			if _, err := reader.ReadString('\n'); err != nil {
+				return
+			}
+			io.WriteString(serverConn, `{"jsonrpc": "2.0", "error": {"code": -32700, "message": "Parse error"}, "id": null}`+"\n")
+		}
+	}()
+	client := jsonrpc.NewClient(clientConn, jsonrpc.NewlineFraming)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var rpcErr *jsonrpc.Error
+	if err := client.Call(ctx, "ping", nil, nil); !errors.As(err, &rpcErr) || rpcErr.Code != jsonrpc.ParseError {
+		t.Errorf("FAIL: expected a parse error but got %v", err)
+	}
+	calls := []jsonrpc.BatchCall{{Method: "a"}, {Method: "b"}}
+	if err := client.Batch(ctx, calls); err != nil {
+		t.Fatal(err)
+	}
+	for _, call := range calls {
+		if !errors.As(call.Error, &rpcErr) || rpcErr.Code != jsonrpc.ParseError {
+			t.Errorf("FAIL: %s: expected a parse error but got %v", call.Method, call.Error)
+		}
+	}
+}
+
+// TestServeConn_Frames writes raw frames, the way another implementation
+// would.
+func TestServeConn_Frames(t *testing.T) {
+	cases := []struct {
+		Framing jsonrpc.Framing
+		In      string
+		Out     []string
+	}{
+		{jsonrpc.NewlineFraming,
+			"{\"jsonrpc\": \"2.0\", \"method\": \"subtract\", \"params\": [42, 23], \"id\": 1}\r\n\n  \n" +
+				"{\"jsonrpc\": \"2.0\", \"method\": \"update\", \"params\": [1]}\n" +
+				"{\"jsonrpc\": \"2.0\", \"method\"\n" +
+				`[{"jsonrpc": "2.0", "method": "sum", "params": [1, 2], "id": "a"}]` + "\n",
+			[]string{
+				`{"jsonrpc":"2.0","result":19,"id":1}` + "\n",
+				`{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error",`,
+				`[{"jsonrpc":"2.0","result":3,"id":"a"}]` + "\n",
+			}},
+		{jsonrpc.ContentLengthFraming,
+			"Content-Length: 69\r\nContent-Type: application/vscode-jsonrpc; charset=utf-8\r\n\r\n" +
+				`{"jsonrpc": "2.0", "method": "subtract", "params": [42, 23], "id": 1}` +
+				"content-length:  69\r\n\r\n" +
+				`{"jsonrpc": "2.0", "method": "subtract", "params": [23, 42], "id": 2}`,
+			[]string{
+				"Content-Length: 36\r\n\r\n" + `{"jsonrpc":"2.0","result":19,"id":1}`,
+				"Content-Length: 37\r\n\r\n" + `{"jsonrpc":"2.0","result":-19,"id":2}`,
+			}},
+	}
+	for _, c := range cases {
+		conn, stop := servePipe(t, newServer(t), c.Framing)
+		go func() {
+			io.WriteString(conn, c.In)
+		}()
+		// responses may come out of order
+		reader := bufio.NewReader(conn)
+		var received []string
+		for range c.Out {
+			received = append(received, readFrame(t, reader, c.Framing))
+		}
+		for _, expected := range c.Out {
+			found := false
+			for _, r := range received {
+				found = found || strings.HasPrefix(r, expected)
+			}
+			if !found {
+				t.Errorf("FAIL: %s: expected %q in %q", c.Framing, expected, received)
+			}
+		}
+		stop()
+	}
+}
+
+func readFrame(t *testing.T, r *bufio.Reader, framing jsonrpc.Framing) string {
+	t.Helper()
+	line, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if framing == jsonrpc.NewlineFraming {
+		return line
+	}
+	var length int
+	if _, err := fmt.Sscanf(line, "Content-Length: %d\r\n", &length); err != nil {
+		t.Fatalf("FAIL: invalid header %q: %v", line, err)
+	}
+	blank, _ := r.ReadString('\n')
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		t.Fatal(err)
+	}
+	return line + blank + string(body)
+}
+
+func TestServeConn_InvalidHeader(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go io.WriteString(clientConn, "Content-Type: application/json\r\n\r\n{}")
+	err := jsonrpc.NewServer().ServeConn(context.Background(), serverConn, jsonrpc.ContentLengthFraming)
+	if err == nil || err.Error() != "missing Content-Length header" {
+		t.Errorf("FAIL: expected a missing header error but got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Package jsonrpc implements JSON-RPC 2.0 servers and clients over HTTP and
+// over streams such as stdio or a net.Conn. Messages are decoded and encoded
+// with pkg/encoding/json.
+package jsonrpc
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/json"
+)
+
+// Version is the value of the "jsonrpc" member of every message.
+const Version = "2.0"
+
+// The error codes defined by the specification.
+const (
+	ParseError     = -32700
+	InvalidRequest = -32600
+	MethodNotFound = -32601
+	InvalidParams  = -32602
+	InternalError  = -32603
+)
+
+var errorMessages = map[int]string{
+	ParseError:     "Parse error",
+	InvalidRequest: "Invalid Request",
+	MethodNotFound: "Method not found",
+	InvalidParams:  "Invalid params",
+	InternalError:  "Internal error",
+}
+
+// Error is the error object of a response. Handlers return it to choose the
+// code and data sent to the client, and Client returns it for error
+// responses.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// NewError returns an error with code and its standard message when message
+// is empty.
+func NewError(code int, message string, data any) *Error {
+	if message == "" {
+		message = errorMessages[code]
+	}
+	return &Error{Code: code, Message: message, Data: data}
+}
+
+func (e *Error) Error() string {
+	if e.Data != nil {
+		return fmt.Sprintf("jsonrpc error %d: %s: %v", e.Code, e.Message, e.Data)
+	}
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+// request is a request, or a notification when ID is empty. A null id is
+// kept as the bytes `null`, so it is told apart from a missing one.
+type request struct {
+	Version string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  json.RawValue `json:"params,omitempty"`
+	ID      json.RawValue `json:"id,omitempty"`
+}
+
+type response struct {
+	Version string        `json:"jsonrpc"`
+	Result  json.RawValue `json:"result,omitempty"`
+	Error   *Error        `json:"error,omitempty"`
+	ID      json.RawValue `json:"id"`
+}
+
+// isBatch reports whether msg holds an array of messages.
+func isBatch(msg []byte) bool {
+	msg = bytes.TrimLeft(msg, " \t\r\n")
+	return len(msg) > 0 && msg[0] == '['
+}
+
+// isStructured reports whether raw is an array or an object, as params must
+// be.
+func isStructured(raw json.RawValue) bool {
+	return len(raw) > 0 && (raw[0] == '[' || raw[0] == '{')
+}
+
+// isValidID reports whether raw is a string, a number or null.
+func isValidID(raw json.RawValue) bool {
+	if len(raw) == 0 {
+		return false
+	}
+	switch c := raw[0]; {
+	case c == '"' || c == '-' || c == 'n':
+		return true
+	case c >= '0' && c <= '9':
+		return true
+	}
+	return false
+}
+
+// decodeResponses decodes a response or a batch of responses.
+func decodeResponses(msg []byte) ([]response, error) {
+	if isBatch(msg) {
+		var responses []response
+		if err := json.Unmarshal(msg, &responses); err != nil {
+			return nil, fmt.Errorf("invalid response: %w", err)
+		}
+		return responses, nil
+	}
+	var r response
+	if err := json.Unmarshal(msg, &r); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return []response{r}, nil
+}
@@ -0,0 +1,267 @@
+package jsonrpc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/jsonrpc"
+)
+
+type subtractParams struct {
+	Minuend    int `json:"minuend"`
+	Subtrahend int `json:"subtrahend"`
+}
+
+// newServer returns a server with the methods of the examples of the
+// specification and a few more.
+func newServer(t *testing.T) *jsonrpc.Server {
+	t.Helper()
+	s := jsonrpc.NewServer()
+	handlers := map[string]any{
+		"subtract": func(a, b int) (int, error) { return a - b, nil },
+		"subtract_named": func(ctx context.Context, p subtractParams) (int, error) {
+			return p.Minuend - p.Subtrahend, nil
+		},
+		"sum": func(numbers []int) (int, error) {
+			total := 0
+			for _, n := range numbers {
+				total += n
+			}
+			return total, nil
+		},
+		"update":       func(numbers []int) error { return nil },
+		"notify_hello": func(n int) error { return nil },
+		"get_data":     func() (any, error) { return []any{"hello", 5}, nil },
+		"hello":        func(ctx context.Context, name string) (string, error) { return "hello " + name, nil },
+		"fail": func() error {
+			return &jsonrpc.Error{Code: -32000, Message: "quota exceeded", Data: map[string]int{"retry": 30}}
+		},
+		"boom":          func() error { return errors.New("database is down") },
+		"panic":         func() error { panic("oops") },
+		"no_params":     func(ctx context.Context) error { return nil },
+		"nil_result":    func() (*subtractParams, error) { return nil, nil },
+		"three_strings": func(a, b, c string) (string, error) { return a + b + c, nil },
+	}
+	for name, handler := range handlers {
+		if err := s.Register(name, handler); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestServer_Handle(t *testing.T) {
+	s := newServer(t)
+	cases := []struct {
+		In  string
+		Out string
+	}{
+		// the examples of the specification
+		{`{"jsonrpc": "2.0", "method": "subtract", "params": [42, 23], "id": 1}`, `{"jsonrpc":"2.0","result":19,"id":1}`},
+		{`{"jsonrpc": "2.0", "method": "subtract", "params": [23, 42], "id": 2}`, `{"jsonrpc":"2.0","result":-19,"id":2}`},
+		{`{"jsonrpc": "2.0", "method": "subtract_named", "params": {"subtrahend": 23, "minuend": 42}, "id": 3}`, `{"jsonrpc":"2.0","result":19,"id":3}`},
+		{`{"jsonrpc": "2.0", "method": "update", "params": [1,2,3,4,5]}`, ``},
+		{`{"jsonrpc": "2.0", "method": "foobar"}`, ``},
+		{`{"jsonrpc": "2.0", "method": "foobar", "id": "1"}`, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"foobar"},"id":"1"}`},
+		{`{"jsonrpc": "2.0", "method": 1, "params": "bar"}`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"cannot unmarshal TokenKindNumber ` + "`1`" + ` into Go value of type string"},"id":null}`},
+		{`{"foo": "boo"}`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"jsonrpc must be \"2.0\""},"id":null}`},
+		{`{"jsonrpc": "2.0", "method": "sum", "params": "bar"}`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"params must be an array or an object"},"id":null}`},
+		{`[{"jsonrpc": "2.0", "method": "notify_hello", "params": [7]}, {"jsonrpc": "2.0", "params": [7]}]`, `[{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"method is missing"},"id":null}]`},
+		{`[]`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"empty batch"},"id":null}`},
+		{`[1]`, `[{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"a request must be an object"},"id":null}]`},
+		{`[1,2,3]`, `[{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"a request must be an object"},"id":null},` +
+			`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"a request must be an object"},"id":null},` +
+			`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"a request must be an object"},"id":null}]`},
+		{`[
+			{"jsonrpc": "2.0", "method": "sum", "params": [1,2,4], "id": "1"},
+			{"jsonrpc": "2.0", "method": "notify_hello", "params": [7]},
+			{"jsonrpc": "2.0", "method": "subtract", "params": [42,23], "id": "2"},
+			{"foo": "boo"},
+			{"jsonrpc": "2.0", "method": "foo.get", "params": {"name": "myself"}, "id": "5"},
+			{"jsonrpc": "2.0", "method": "get_data", "id": "9"}
+		]`, `[{"jsonrpc":"2.0","result":7,"id":"1"},{"jsonrpc":"2.0","result":19,"id":"2"},` +
+			`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"jsonrpc must be \"2.0\""},"id":null},` +
+			`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"foo.get"},"id":"5"},` +
+			`{"jsonrpc":"2.0","result":["hello",5],"id":"9"}]`},
+		{`[{"jsonrpc": "2.0", "method": "notify_sum", "params": [1,2,4]}, {"jsonrpc": "2.0", "method": "notify_hello", "params": [7]}]`, ``},
+
+		// requests
+		{`{"jsonrpc": "2.0", "method": "hello", "params": ["ann"], "id": null}`, `{"jsonrpc":"2.0","result":"hello ann","id":null}`},
+		{`{"jsonrpc": "2.0", "method": "no_params", "id": 1}`, `{"jsonrpc":"2.0","result":null,"id":1}`},
+		{`{"jsonrpc": "2.0", "method": "no_params", "params": {}, "id": 1}`, `{"jsonrpc":"2.0","result":null,"id":1}`},
+		{`{"jsonrpc": "2.0", "method": "nil_result", "id": 1.5}`, `{"jsonrpc":"2.0","result":null,"id":1.5}`},
+		{`{"jsonrpc": "2.0", "method": "three_strings", "params": ["a", "b"], "id": 1}`, `{"jsonrpc":"2.0","result":"ab","id":1}`},
+		{`{"method": "hello", "params": ["ann"], "id": 1}`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"jsonrpc must be \"2.0\""},"id":1}`},
+		{`{"jsonrpc": "2.0", "params": ["ann"], "id": 1}`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"method is missing"},"id":1}`},
+		{`{"jsonrpc": "2.0", "method": "hello", "id": {"a": 1}}`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"id must be a string, a number or null"},"id":null}`},
+		{`{"jsonrpc": "2.0", "method": "hello", "params": "ann", "id": 1}`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"params must be an array or an object"},"id":1}`},
+
+		// params
+		{`{"jsonrpc": "2.0", "method": "subtract", "params": [1, 2, 3], "id": 1}`, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"expected at most 2 params but got 3"},"id":1}`},
+		{`{"jsonrpc": "2.0", "method": "subtract", "params": {"a": 1}, "id": 1}`, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"expected 2 positional params"},"id":1}`},
+		{`{"jsonrpc": "2.0", "method": "subtract", "params": [1, "2"], "id": 1}`, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"param 1: cannot unmarshal TokenKindString ` + "`\\\"2\\\"`" + ` into Go value of type int"},"id":1}`},
+		{`{"jsonrpc": "2.0", "method": "no_params", "params": [1], "id": 1}`, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"expected at most 0 params but got 1"},"id":1}`},
+
+		// handler errors
+		{`{"jsonrpc": "2.0", "method": "fail", "id": 1}`, `{"jsonrpc":"2.0","error":{"code":-32000,"message":"quota exceeded","data":{"retry":30}},"id":1}`},
+		{`{"jsonrpc": "2.0", "method": "boom", "id": 1}`, `{"jsonrpc":"2.0","error":{"code":-32603,"message":"database is down"},"id":1}`},
+		{`{"jsonrpc": "2.0", "method": "panic", "id": 1}`, `{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error","data":"panic: oops"},"id":1}`},
+	}
+	for _, c := range cases {
+		out := s.Handle(context.Background(), []byte(c.In))
+		if string(out) != c.Out {
+			t.Errorf("FAIL: %s\nexpected %s\n but got %s", c.In, c.Out, out)
+		}
+	}
+}
+
+func TestServer_ParseError(t *testing.T) {
+	s := newServer(t)
+	for _, in := range []string{
+		`{"jsonrpc": "2.0", "method": "foobar, "params": "bar", "baz]`,
+		`[
+			{"jsonrpc": "2.0", "method": "sum", "params": [1,2,4], "id": "1"},
+			{"jsonrpc": "2.0", "method"
+		]`,
+		``,
+		`{"jsonrpc": "2.0", "method": "hello"} {}`,
+	} {
+		out := string(s.Handle(context.Background(), []byte(in)))
+		if !strings.HasPrefix(out, `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error","data":`) || !strings.HasSuffix(out, `"id":null}`) {
+			t.Errorf("FAIL: %q: expected a parse error but got %s", in, out)
+		}
+	}
+}
+
+func TestServer_BatchConcurrency(t *testing.T) {
+	s := jsonrpc.NewServer()
+	var running, peak atomic.Int64
+	err := s.Register("work", func() error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := "[" + strings.TrimSuffix(strings.Repeat(`{"jsonrpc": "2.0", "method": "work", "id": 1},`, 100), ",") + "]"
+	out := s.Handle(context.Background(), []byte(batch))
+	if count := strings.Count(string(out), `"result":null`); count != 100 {
+		t.Errorf("FAIL: expected 100 responses but got %d", count)
+	}
+	if limit := int64(runtime.GOMAXPROCS(0)); peak.Load() > limit {
+		t.Errorf("FAIL: expected at most %d requests at once but got %d", limit, peak.Load())
+	}
+}
+
+func TestServer_Register(t *testing.T) {
+	s := jsonrpc.NewServer()
+	invalid := map[string]any{
+		"not a function": 42,
+		"no error":       func() int { return 0 },
+		"error first":    func() (error, int) { return nil, 0 },
+		"variadic":       func(a ...int) error { return nil },
+		"nil":            (func() error)(nil),
+	}
+	for name, handler := range invalid {
+		if err := s.Register(name, handler); err == nil {
+			t.Errorf("FAIL: %s: expected an error", name)
+		}
+	}
+	if err := s.Register("a", func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Register("a", func() error { return nil }); err == nil {
+		t.Errorf("FAIL: expected an error for a method registered twice")
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	ts := httptest.NewServer(newServer(t))
+	defer ts.Close()
+	client := jsonrpc.NewHTTPClient(ts.URL, ts.Client())
+	testClient(t, client)
+
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("FAIL: expected 405 for GET but got %s", resp.Status)
+	}
+
+	resp, err = http.Post(ts.URL, "application/json", strings.NewReader(`{"jsonrpc": "2.0", "method": "update", "params": [1]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("FAIL: expected 204 for a notification but got %s", resp.Status)
+	}
+}
+
+// testClient runs the calls common to every transport.
+func testClient(t *testing.T, client *jsonrpc.Client) {
+	t.Helper()
+	ctx := context.Background()
+
+	var difference int
+	if err := client.Call(ctx, "subtract", []int{42, 23}, &difference); err != nil || difference != 19 {
+		t.Errorf("FAIL: subtract: expected 19 but got %d %v", difference, err)
+	}
+	if err := client.Call(ctx, "subtract_named", subtractParams{Minuend: 1, Subtrahend: 3}, &difference); err != nil || difference != -2 {
+		t.Errorf("FAIL: subtract_named: expected -2 but got %d %v", difference, err)
+	}
+	var data []any
+	if err := client.Call(ctx, "get_data", nil, &data); err != nil || len(data) != 2 || data[0] != "hello" {
+		t.Errorf("FAIL: get_data: got %v %v", data, err)
+	}
+
+	var rpcErr *jsonrpc.Error
+	err := client.Call(ctx, "fail", nil, nil)
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32000 || rpcErr.Message != "quota exceeded" {
+		t.Errorf("FAIL: fail: expected the handler error but got %v", err)
+	}
+	err = client.Call(ctx, "missing", nil, nil)
+	if !errors.As(err, &rpcErr) || rpcErr.Code != jsonrpc.MethodNotFound {
+		t.Errorf("FAIL: missing: expected method not found but got %v", err)
+	}
+	if err := client.Call(ctx, "hello", "ann", nil); err == nil {
+		t.Errorf("FAIL: expected an error for params that are not structured")
+	}
+	if err := client.Notify(ctx, "update", []int{1, 2}); err != nil {
+		t.Errorf("FAIL: update: %v", err)
+	}
+
+	var sum int
+	var greeting string
+	calls := []jsonrpc.BatchCall{
+		{Method: "sum", Params: []int{1, 2, 4}, Result: &sum},
+		{Method: "notify_hello", Params: []int{7}, Notify: true},
+		{Method: "hello", Params: []string{"bob"}, Result: &greeting},
+		{Method: "foo.get", Params: map[string]string{"name": "myself"}},
+	}
+	if err := client.Batch(ctx, calls); err != nil {
+		t.Fatalf("FAIL: batch: %v", err)
+	}
+	if sum != 7 || greeting != "hello bob" || calls[0].Error != nil || calls[1].Error != nil || calls[2].Error != nil {
+		t.Errorf("FAIL: batch: got %d %q %v", sum, greeting, calls)
+	}
+	if !errors.As(calls[3].Error, &rpcErr) || rpcErr.Code != jsonrpc.MethodNotFound {
+		t.Errorf("FAIL: batch: expected method not found but got %v", calls[3].Error)
+	}
+	if err := client.Batch(ctx, []jsonrpc.BatchCall{{Method: "update", Notify: true}}); err != nil {
+		t.Errorf("FAIL: batch of notifications: %v", err)
+	}
+}
@@ -0,0 +1,289 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/GabiBizdoc/golang-playground/pkg/encoding/json"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// method is a registered handler.
+type method struct {
+	fn reflect.Value
+	// context tells whether the handler takes a context.Context first.
+	context bool
+	params  []reflect.Type
+	// result tells whether the handler returns a result before its error.
+	result bool
+}
+
+// Server dispatches requests to the handlers registered with Register. It is
+// safe for concurrent use: the requests of a batch and of a connection run
+// concurrently.
+type Server struct {
+	mu      sync.RWMutex
+	methods map[string]*method
+}
+
+func NewServer() *Server {
+	return &Server{methods: make(map[string]*method)}
+}
+
+// Register makes handler callable as name. handler is a function taking an
+// optional context.Context followed by its parameters, and returning an error
+// optionally preceded by a result:
+//
+//	func(ctx context.Context, a, b int) (int, error)
+//	func(params SearchParams) ([]Item, error)
+//	func(ctx context.Context) error
+//
+// Parameters are decoded with json.Unmarshal. A handler with a single
+// parameter receives named params (an object) or, when the parameter is a
+// slice or an array, positional params as a whole; otherwise positional
+// params are assigned in order and missing ones are left zero. The result is
+// encoded with json.Marshal. A handler error is sent as is when it is an
+// *Error, and as an InternalError with its message otherwise.
+func (s *Server) Register(name string, handler any) error {
+	fn := reflect.ValueOf(handler)
+	if fn.Kind() != reflect.Func || fn.IsNil() {
+		return fmt.Errorf("cannot register %s: handler of type %T is not a function", name, handler)
+	}
+	t := fn.Type()
+	if t.IsVariadic() {
+		return fmt.Errorf("cannot register %s: variadic handlers are not supported", name)
+	}
+	switch {
+	case t.NumOut() == 1 && t.Out(0) == errorType:
+	case t.NumOut() == 2 && t.Out(1) == errorType:
+	default:
+		return fmt.Errorf("cannot register %s: handler must return an error, optionally preceded by a result", name)
+	}
+
+	m := &method{fn: fn, result: t.NumOut() == 2}
+	for i := 0; i < t.NumIn(); i++ {
+		if i == 0 && t.In(0) == contextType {
+			m.context = true
+			continue
+		}
+		m.params = append(m.params, t.In(i))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.methods[name]; ok {
+		return fmt.Errorf("cannot register %s: the method is already registered", name)
+	}
+	s.methods[name] = m
+	return nil
+}
+
+// Handle answers a message holding a request, a notification or a batch of
+// them. It returns nil when there is nothing to answer, as for notifications.
+//
+// The message is parsed once, when it is validated, and the requests are read
+// through lazy views over its bytes. The requests of a batch run on at most
+// runtime.GOMAXPROCS(0) goroutines.
+func (s *Server) Handle(ctx context.Context, msg []byte) []byte {
+	if !isBatch(msg) {
+		var raw json.RawValue
+		if err := json.Unmarshal(msg, &raw); err != nil {
+			return s.encode(response{Error: NewError(ParseError, "", err.Error())})
+		}
+		r, ok := s.handle(ctx, raw)
+		if !ok {
+			return nil
+		}
+		return s.encode(r)
+	}
+
+	var batch []json.RawValue
+	if err := json.Unmarshal(msg, &batch); err != nil {
+		return s.encode(response{Error: NewError(ParseError, "", err.Error())})
+	}
+	if len(batch) == 0 {
+		return s.encode(response{Error: NewError(InvalidRequest, "", "empty batch")})
+	}
+	responses := make([]response, len(batch))
+	answered := make([]bool, len(batch))
+	var wg sync.WaitGroup
+	var next atomic.Int64
+	for i := 0; i < min(len(batch), runtime.GOMAXPROCS(0)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := int(next.Add(1) - 1); j < len(batch); j = int(next.Add(1) - 1) {
+				responses[j], answered[j] = s.handle(ctx, batch[j])
+			}
+		}()
+	}
+	wg.Wait()
+
+	out := []byte{'['}
+	for i, r := range responses {
+		if !answered[i] {
+			continue
+		}
+		if len(out) > 1 {
+			out = append(out, ',')
+		}
+		out = append(out, s.encode(r)...)
+	}
+	if len(out) == 1 {
+		return nil
+	}
+	return append(out, ']')
+}
+
+// handle runs a single request, already validated, and returns its response,
+// and false for a notification.
+func (s *Server) handle(ctx context.Context, raw json.RawValue) (response, bool) {
+	if len(raw) == 0 || raw[0] != '{' {
+		return response{Error: NewError(InvalidRequest, "", "a request must be an object")}, true
+	}
+	req, err := decodeRequest(raw)
+	if err != nil {
+		return response{Error: NewError(InvalidRequest, "", err.Error())}, true
+	}
+
+	// a request without id is a notification only once it is valid, invalid
+	// ones are always answered
+	notification := len(req.ID) == 0
+	switch {
+	case !notification && !isValidID(req.ID):
+		return response{Error: NewError(InvalidRequest, "", "id must be a string, a number or null")}, true
+	case req.Version != Version:
+		return response{ID: req.ID, Error: NewError(InvalidRequest, "", `jsonrpc must be "2.0"`)}, true
+	case req.Method == "":
+		return response{ID: req.ID, Error: NewError(InvalidRequest, "", "method is missing")}, true
+	case len(req.Params) > 0 && !isStructured(req.Params):
+		return response{ID: req.ID, Error: NewError(InvalidRequest, "", "params must be an array or an object")}, true
+	}
+
+	s.mu.RLock()
+	m, ok := s.methods[req.Method]
+	s.mu.RUnlock()
+	if !ok {
+		return response{ID: req.ID, Error: NewError(MethodNotFound, "", req.Method)}, !notification
+	}
+	result, rpcErr := m.call(ctx, req.Params)
+	return response{ID: req.ID, Result: result, Error: rpcErr}, !notification
+}
+
+// decodeRequest reads the members of a request from the index of raw,
+// leaving the params and the id undecoded.
+func decodeRequest(raw json.RawValue) (request, error) {
+	var req request
+	obj, err := raw.Object()
+	if err != nil {
+		return req, err
+	}
+	req.Params, _ = obj.Raw("params")
+	req.ID, _ = obj.Raw("id")
+	if version, ok := obj.Raw("jsonrpc"); ok {
+		if err := json.Unmarshal(version, &req.Version); err != nil {
+			return req, err
+		}
+	}
+	if method, ok := obj.Raw("method"); ok {
+		if err := json.Unmarshal(method, &req.Method); err != nil {
+			return req, err
+		}
+	}
+	return req, nil
+}
+
+func (s *Server) encode(r response) []byte {
+	r.Version = Version
+	out, err := json.Marshal(r)
+	if err != nil {
+		// only the data of an error may fail to encode
+		r.Error = NewError(InternalError, "", err.Error())
+		out, _ = json.Marshal(r)
+	}
+	return out
+}
+
+// call decodes the params, runs the handler and encodes its result.
+func (m *method) call(ctx context.Context, params json.RawValue) (result json.RawValue, rpcErr *Error) {
+	args, rpcErr := m.arguments(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if m.context {
+		args = append([]reflect.Value{reflect.ValueOf(ctx)}, args...)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			result, rpcErr = nil, NewError(InternalError, "", fmt.Sprintf("panic: %v", p))
+		}
+	}()
+	out := m.fn.Call(args)
+	if err, _ := out[len(out)-1].Interface().(error); err != nil {
+		if errors.As(err, &rpcErr) {
+			return nil, rpcErr
+		}
+		return nil, NewError(InternalError, err.Error(), nil)
+	}
+
+	result = json.RawValue("null")
+	if m.result {
+		encoded, err := json.Marshal(out[0].Interface())
+		if err != nil {
+			return nil, NewError(InternalError, "", err.Error())
+		}
+		result = encoded
+	}
+	return result, nil
+}
+
+// arguments decodes params into the parameters of the handler.
+func (m *method) arguments(params json.RawValue) ([]reflect.Value, *Error) {
+	args := make([]reflect.Value, len(m.params))
+	for i, t := range m.params {
+		args[i] = reflect.New(t).Elem()
+	}
+	if len(params) == 0 {
+		return args, nil
+	}
+
+	named := params[0] == '{'
+	if len(m.params) == 1 {
+		kind := m.params[0].Kind()
+		if named || kind == reflect.Slice || kind == reflect.Array {
+			if err := json.Unmarshal(params, args[0].Addr().Interface()); err != nil {
+				return nil, NewError(InvalidParams, "", err.Error())
+			}
+			return args, nil
+		}
+	}
+
+	var positional []json.RawValue
+	if err := json.Unmarshal(params, &positional); err != nil {
+		// named params for a handler taking none or several parameters
+		var members map[string]json.RawValue
+		if json.Unmarshal(params, &members) == nil && len(members) == 0 && len(m.params) == 0 {
+			return args, nil
+		}
+		return nil, NewError(InvalidParams, "", fmt.Sprintf("expected %d positional params", len(m.params)))
+	}
+	if len(positional) > len(m.params) {
+		return nil, NewError(InvalidParams, "", fmt.Sprintf("expected at most %d params but got %d", len(m.params), len(positional)))
+	}
+	for i, raw := range positional {
+		if err := json.Unmarshal(raw, args[i].Addr().Interface()); err != nil {
+			return nil, NewError(InvalidParams, "", fmt.Sprintf("param %d: %v", i, err))
+		}
+	}
+	return args, nil
+}
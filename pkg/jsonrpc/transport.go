@@ -0,0 +1,222 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxMessageSize is the size of the largest message read from a stream or
+// an HTTP body.
+const maxMessageSize = 16 << 20
+
+// Framing is how messages are delimited on a stream.
+type Framing int8
+
+const (
+	// NewlineFraming writes every message on its own line. Messages must not
+	// contain newlines, which compact JSON never does.
+	NewlineFraming Framing = iota
+	// ContentLengthFraming precedes every message with a `Content-Length`
+	// header and a blank line, like the Language Server Protocol. Other
+	// headers are ignored.
+	ContentLengthFraming
+)
+
+func (f Framing) String() string {
+	switch f {
+	case NewlineFraming:
+		return "newline"
+	case ContentLengthFraming:
+		return "Content-Length"
+	}
+	return "Framing(" + strconv.Itoa(int(f)) + ")"
+}
+
+// frameReader reads the messages of a stream.
+type frameReader struct {
+	r       *bufio.Reader
+	framing Framing
+}
+
+func newFrameReader(r io.Reader, framing Framing) *frameReader {
+	return &frameReader{r: bufio.NewReader(r), framing: framing}
+}
+
+// read returns the next message, or io.EOF at the end of the stream.
+func (f *frameReader) read() ([]byte, error) {
+	if f.framing == ContentLengthFraming {
+		return f.readContentLength()
+	}
+	for {
+		line, err := f.readLine(maxMessageSize)
+		if len(strings.TrimSpace(string(line))) > 0 {
+			return line, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readLine returns the next line without its line ending. A last line
+// without a newline is returned with a nil error.
+func (f *frameReader) readLine(limit int) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := f.r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > limit {
+			return nil, fmt.Errorf("message larger than %d bytes", limit)
+		}
+		switch {
+		case err == nil:
+			return trimLineEnding(line), nil
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF) && len(line) > 0:
+			return line, nil
+		default:
+			return nil, err
+		}
+	}
+}
+
+func trimLineEnding(line []byte) []byte {
+	line = line[:len(line)-1]
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+func (f *frameReader) readContentLength() ([]byte, error) {
+	length := -1
+	for headers := 0; ; headers++ {
+		line, err := f.readLine(4 << 10)
+		if err != nil {
+			if errors.Is(err, io.EOF) && headers > 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if len(line) == 0 {
+			if headers == 0 {
+				continue
+			}
+			break
+		}
+		name, value, ok := strings.Cut(string(line), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid header %q", line)
+		}
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("invalid Content-Length %q", value)
+			}
+		}
+	}
+	switch {
+	case length < 0:
+		return nil, errors.New("missing Content-Length header")
+	case length > maxMessageSize:
+		return nil, fmt.Errorf("message larger than %d bytes", maxMessageSize)
+	}
+	msg := make([]byte, length)
+	if _, err := io.ReadFull(f.r, msg); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return msg, nil
+}
+
+// writeFrame writes msg to w in a single call.
+func writeFrame(w io.Writer, framing Framing, msg []byte) error {
+	var frame []byte
+	if framing == ContentLengthFraming {
+		frame = append(frame, "Content-Length: "...)
+		frame = strconv.AppendInt(frame, int64(len(msg)), 10)
+		frame = append(frame, "\r\n\r\n"...)
+		frame = append(frame, msg...)
+	} else {
+		frame = append(append(frame, msg...), '\n')
+	}
+	_, err := w.Write(frame)
+	return err
+}
+
+// ServeConn answers the messages read from conn until it ends, like stdio or
+// a net.Conn. Messages are handled concurrently and their responses written
+// as soon as they are ready, so they may come out of order. It returns nil
+// once conn is exhausted and every response written, or the first read or
+// write error.
+func (s *Server) ServeConn(ctx context.Context, conn io.ReadWriter, framing Framing) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		writeErr error
+	)
+	reader := newFrameReader(conn, framing)
+	for {
+		msg, err := reader.read()
+		if err != nil {
+			wg.Wait()
+			if errors.Is(err, io.EOF) {
+				return writeErr
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out := s.Handle(ctx, msg)
+			if out == nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if writeErr == nil {
+				writeErr = writeFrame(conn, framing, out)
+			}
+		}()
+	}
+}
+
+// ServeHTTP answers a message posted in the body of r. Notifications get an
+// empty 204 response.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+	out := s.Handle(r.Context(), body)
+	if out == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
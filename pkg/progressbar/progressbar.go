@@ -42,6 +42,23 @@ func (p *ProgressBar) Update(progress int) {
 	}
 }
 
+// Set moves the bar to current out of total, and marks it done when current
+// reaches total. Its signature fits progress callbacks such as the one of
+// json.ParseContext. Calls after done are ignored.
+func (p *ProgressBar) Set(current, total int64) {
+	if p.done {
+		return
+	}
+	if total <= 0 {
+		total = 1
+	}
+	p.Size = int(total)
+	p.Update(int(current) - p.Current)
+	if current >= total {
+		p.Done()
+	}
+}
+
 func (p *ProgressBar) Done() {
 	p.done = true
 